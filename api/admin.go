@@ -0,0 +1,145 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/authz"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+)
+
+// Admin endpoints act on any user rather than the authenticated one. They live under
+// /api/v1/admin and every route declares the permission it needs.
+
+// userRolesResponse represents the API response structure for a user's stored roles. Roles
+// granted through Cognito groups aren't included; they're managed in Cognito.
+type userRolesResponse struct {
+	CognitoSub string   `json:"cognito_sub"`
+	Roles      []string `json:"roles"`
+}
+
+// adminGetUser handles requests to get any user with their stored roles
+func (server *Server) adminGetUser(ctx *gin.Context) {
+	cognitoSub := ctx.Param("cognito_sub")
+
+	user, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user", err))
+		return
+	}
+
+	roles, err := server.store.ListUserRoles(ctx.Request.Context(), cognitoSub)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user roles", err))
+		return
+	}
+
+	response := convertGetUserRowToResponse(user)
+	response.Roles = mergeRoles(roles)
+	ctx.JSON(http.StatusOK, response)
+}
+
+// adminDeleteUser handles requests to delete any user
+func (server *Server) adminDeleteUser(ctx *gin.Context) {
+	cognitoSub := ctx.Param("cognito_sub")
+
+	// Admins delete their own account through the regular endpoint
+	if cognitoSub == ctx.GetString("cognito_sub") {
+		apierror.Write(ctx, apierror.Conflict("Use DELETE /api/v1/users/:cognito_sub to delete your own account"))
+		return
+	}
+
+	// Check if user exists
+	_, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user", err))
+		return
+	}
+
+	if err := server.store.DeleteUser(ctx.Request.Context(), cognitoSub); err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to delete user", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
+}
+
+// listUserRoles handles requests to list the roles stored for a user
+func (server *Server) listUserRoles(ctx *gin.Context) {
+	cognitoSub := ctx.Param("cognito_sub")
+
+	roles, err := server.store.ListUserRoles(ctx.Request.Context(), cognitoSub)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user roles", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, userRolesResponse{CognitoSub: cognitoSub, Roles: mergeRoles(roles)})
+}
+
+// grantUserRole handles requests to give a user an application-wide role
+func (server *Server) grantUserRole(ctx *gin.Context) {
+	cognitoSub := ctx.Param("cognito_sub")
+	role := ctx.Param("role")
+
+	if !authz.IsRole(role) {
+		apierror.Write(ctx, apierror.Invalid("Unknown role").With("roles", authz.Roles()))
+		return
+	}
+
+	// Check if user exists
+	_, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("User not found"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user", err))
+		return
+	}
+
+	err = server.store.GrantUserRole(ctx.Request.Context(), db.GrantUserRoleParams{
+		CognitoSub: cognitoSub,
+		Role:       role,
+		GrantedBy:  sql.NullString{String: ctx.GetString("cognito_sub"), Valid: true},
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to grant role", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role granted successfully"})
+}
+
+// revokeUserRole handles requests to take an application-wide role away from a user
+func (server *Server) revokeUserRole(ctx *gin.Context) {
+	cognitoSub := ctx.Param("cognito_sub")
+	role := ctx.Param("role")
+
+	// Keep admins from locking themselves out
+	if cognitoSub == ctx.GetString("cognito_sub") && role == authz.RoleAdmin {
+		apierror.Write(ctx, apierror.Conflict("You can't revoke your own admin role"))
+		return
+	}
+
+	err := server.store.RevokeUserRole(ctx.Request.Context(), db.RevokeUserRoleParams{
+		CognitoSub: cognitoSub,
+		Role:       role,
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to revoke role", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Role revoked successfully"})
+}
@@ -0,0 +1,243 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/authz"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/identity"
+	"github.com/mbaxamb3/nusli/logging"
+)
+
+// API tokens let scripts and tools call the API as the user who minted them, without the
+// browser login flow. They are sent as bearer tokens like access tokens and are told apart
+// by their prefix. Only a hash is stored; the token is returned once when it's created.
+
+const (
+	apiTokenPrefix     = "nsl_"
+	apiTokenDefaultTTL = 90 * 24 * time.Hour
+)
+
+// apiTokenProvider verifies API tokens and hands every other token to the identity provider
+type apiTokenProvider struct {
+	store *db.Store
+	next  identity.IdentityProvider
+}
+
+// Name identifies the provider for access tokens
+func (p apiTokenProvider) Name() string {
+	return p.next.Name()
+}
+
+// Verify checks an API token against the stored hashes
+func (p apiTokenProvider) Verify(ctx context.Context, token string) (identity.Claims, error) {
+	if !strings.HasPrefix(token, apiTokenPrefix) {
+		return p.next.Verify(ctx, token)
+	}
+
+	stored, err := p.store.GetAPITokenByHash(ctx, hashAPIToken(token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return identity.Claims{}, fmt.Errorf("%w: unknown API token", identity.ErrInvalidToken)
+		}
+		return identity.Claims{}, err
+	}
+	if stored.RevokedAt.Valid {
+		return identity.Claims{}, fmt.Errorf("%w: API token %d was revoked", identity.ErrInvalidToken, stored.TokenID)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return identity.Claims{}, fmt.Errorf("%w: API token %d has expired", identity.ErrInvalidToken, stored.TokenID)
+	}
+
+	if err := p.store.TouchAPIToken(ctx, stored.TokenID); err != nil {
+		logging.FromContext(ctx).Warn("Failed to record API token use", "token_id", stored.TokenID, "error", err)
+	}
+
+	scopes := stored.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return identity.Claims{
+		Subject: stored.CognitoSub,
+		Issuer:  "api-token",
+		Expiry:  stored.ExpiresAt,
+		Scopes:  scopes,
+	}, nil
+}
+
+// requireTokenScope is middleware for the /api/v1 routes that stops requests made with an
+// API token whose scopes don't cover the route. Reads are GET and HEAD requests; everything
+// else needs write access. Requests with access tokens pass through.
+func requireTokenScope(ctx *gin.Context) {
+	scopes, scoped := ctx.Get("token_scopes")
+	if !scoped {
+		ctx.Next()
+		return
+	}
+
+	write := ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead
+	if !authz.ScopesAllow(scopes.([]string), routeResource(ctx), write) {
+		apierror.Abort(ctx, apierror.Forbidden("This API token doesn't have access to this resource"))
+		return
+	}
+	ctx.Next()
+}
+
+// routeResource returns the resource a request is for: the first segment of the route
+// after /api/v1, or the entity type of the shared upload routes
+func routeResource(ctx *gin.Context) string {
+	path := strings.TrimPrefix(ctx.FullPath(), "/api/v1/")
+	resource, _, _ := strings.Cut(path, "/")
+	if resource == ":entity_type" {
+		resource = ctx.Param("entity_type")
+	}
+	return resource
+}
+
+// createAPITokenRequest represents the request body for minting an API token
+type createAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"` // 90 days when omitted
+}
+
+// apiTokenResponse represents the API response structure for an API token
+type apiTokenResponse struct {
+	TokenID     int32      `json:"token_id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at,omitempty"`
+	Token       string     `json:"token,omitempty"` // Only when the token is created
+}
+
+// convertAPITokenToResponse converts a database API token model to an API response
+func convertAPITokenToResponse(token db.ApiToken) apiTokenResponse {
+	response := apiTokenResponse{
+		TokenID:     token.TokenID,
+		Name:        token.Name,
+		TokenPrefix: token.TokenPrefix,
+		Scopes:      token.Scopes,
+		ExpiresAt:   token.ExpiresAt,
+		CreatedAt:   token.CreatedAt.Time,
+	}
+	if token.LastUsedAt.Valid {
+		response.LastUsedAt = &token.LastUsedAt.Time
+	}
+	return response
+}
+
+// createAPIToken handles requests to mint an API token for the authenticated user
+func (server *Server) createAPIToken(ctx *gin.Context) {
+	var req createAPITokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+
+	for _, scope := range req.Scopes {
+		if !authz.IsScope(scope) {
+			apierror.Write(ctx, apierror.Invalid(fmt.Sprintf("Unknown scope %q", scope), apierror.FieldError{
+				Field: "scopes", Reason: "scope", Message: `must be "read", "write" or a resource followed by ":read" or ":write"`,
+			}).With("resources", authz.ScopeResources))
+			return
+		}
+	}
+
+	ttl := apiTokenDefaultTTL
+	if req.ExpiresInDays > 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+
+	token, err := newAPIToken()
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to create API token", err))
+		return
+	}
+
+	created, err := server.store.CreateAPIToken(ctx.Request.Context(), db.CreateAPITokenParams{
+		CognitoSub:  ctx.GetString("cognito_sub"),
+		Name:        req.Name,
+		TokenHash:   hashAPIToken(token),
+		TokenPrefix: token[:len(apiTokenPrefix)+6],
+		Scopes:      req.Scopes,
+		ExpiresAt:   time.Now().Add(ttl),
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to create API token", err))
+		return
+	}
+
+	response := convertAPITokenToResponse(created)
+	response.Token = token
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// listAPITokens handles requests to list the authenticated user's API tokens
+func (server *Server) listAPITokens(ctx *gin.Context) {
+	tokens, err := server.store.ListAPITokensByUser(ctx.Request.Context(), ctx.GetString("cognito_sub"))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch API tokens", err))
+		return
+	}
+
+	responses := make([]apiTokenResponse, len(tokens))
+	for i, token := range tokens {
+		responses[i] = convertAPITokenToResponse(token)
+	}
+
+	ctx.JSON(http.StatusOK, responses)
+}
+
+// revokeAPIToken handles requests to revoke one of the authenticated user's API tokens
+func (server *Server) revokeAPIToken(ctx *gin.Context) {
+	tokenID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid ID format"))
+		return
+	}
+
+	_, err = server.store.RevokeAPIToken(ctx.Request.Context(), db.RevokeAPITokenParams{
+		TokenID:    int32(tokenID),
+		CognitoSub: ctx.GetString("cognito_sub"),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("API token not found"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to revoke API token", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API token revoked successfully"})
+}
+
+// newAPIToken generates a new API token
+func newAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashAPIToken returns the form of an API token that is stored
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
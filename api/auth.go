@@ -6,7 +6,9 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"time"
@@ -17,37 +19,28 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 	"github.com/coreos/go-oidc"
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/util"
 	"golang.org/x/oauth2"
 )
 
-const (
-	clientID     = "6bo0q3c938g1oa0hjggqbdv0b"
-	clientSecret = "rhlqr4vp21s2v5rfp7fijltlhe8ha3aj4i5oar561h8hgsvslam"
-	redirectURL  = "http://localhost:8080/callback"
-	issuerURL    = "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_177Be0rjJ"
-	region       = "us-east-1"
-)
-
 var (
 	provider      *oidc.Provider
 	oauth2Config  oauth2.Config
 	cognitoClient *cognitoidentityprovider.Client
 )
 
-// Initialize OAuth and Cognito
-func InitAuth() {
-	var err error
-
-	// Load AWS SDK Config
+// initializeAuth sets up the Cognito client and OAuth flow used by the sign-up and login
+// routes. Those routes are only served when tokens come from Cognito.
+func initializeAuth(cfg util.Config, logger *slog.Logger) {
+	// Load AWS SDK Config with region
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Using hardcoded region
-	awsCfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(region))
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.CognitoRegion))
 	if err != nil {
-		fmt.Println("Failed to load AWS SDK config:", err)
+		logger.Error("Failed to load AWS SDK config", "error", err)
 		return
 	}
 	cognitoClient = cognitoidentityprovider.NewFromConfig(awsCfg)
@@ -56,27 +49,37 @@ func InitAuth() {
 	providerCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	provider, err = oidc.NewProvider(providerCtx, issuerURL)
+	provider, err = oidc.NewProvider(providerCtx, cfg.CognitoIssuer())
 	if err != nil {
-		fmt.Println("Failed to initialize OIDC provider:", err)
+		logger.Error("Failed to initialize OIDC provider", "issuer", cfg.CognitoIssuer(), "error", err)
 		return
 	}
 
 	oauth2Config = oauth2.Config{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		RedirectURL:  redirectURL,
+		ClientID:     cfg.CognitoClientID,
+		ClientSecret: cfg.CognitoClientSecret,
+		RedirectURL:  cfg.CognitoRedirectURL,
 		Endpoint:     provider.Endpoint(),
 		Scopes:       []string{"openid", "email", "profile", "aws.cognito.signin.user.admin"},
 	}
 
-	fmt.Println("Auth initialization completed successfully")
+	logger.Info("Hosted sign-up and login are ready", "issuer", cfg.CognitoIssuer())
+}
+
+// requireHostedAuth is route middleware for the sign-up and login routes, which need the
+// Cognito client and OAuth flow set up by initializeAuth
+func requireHostedAuth(ctx *gin.Context) {
+	if cognitoClient == nil || provider == nil {
+		apierror.Abort(ctx, apierror.Unavailable("Sign-up and login are not available"))
+		return
+	}
+	ctx.Next()
 }
 
 // Generate Cognito secret hash
-func generateSecretHash(username string) string {
-	h := hmac.New(sha256.New, []byte(clientSecret))
-	h.Write([]byte(username + clientID))
+func (server *Server) generateSecretHash(username string) string {
+	h := hmac.New(sha256.New, []byte(server.config.CognitoClientSecret))
+	h.Write([]byte(username + server.config.CognitoClientID))
 	return base64.StdEncoding.EncodeToString(h.Sum(nil))
 }
 
@@ -91,16 +94,16 @@ type SignUpRequest struct {
 func (server *Server) handleSignUp(ctx *gin.Context) {
 	var req SignUpRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Generate secret hash
-	secretHash := generateSecretHash(req.Email)
+	secretHash := server.generateSecretHash(req.Email)
 
 	// Register user in Cognito
 	input := &cognitoidentityprovider.SignUpInput{
-		ClientId:   aws.String(clientID),
+		ClientId:   aws.String(server.config.CognitoClientID),
 		Username:   &req.Email,
 		Password:   &req.Password,
 		SecretHash: &secretHash,
@@ -112,7 +115,7 @@ func (server *Server) handleSignUp(ctx *gin.Context) {
 
 	resp, err := cognitoClient.SignUp(context.TODO(), input)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Signup failed: " + err.Error()})
+		apierror.Write(ctx, cognitoError(err, "Signup failed"))
 		return
 	}
 
@@ -121,19 +124,50 @@ func (server *Server) handleSignUp(ctx *gin.Context) {
 
 	// Store user in your database
 	// Modify this to match your database structure
-	_, dbErr := server.store.CreateUser(ctx, db.CreateUserParams{
+	_, dbErr := server.store.CreateUser(ctx.Request.Context(), db.CreateUserParams{
 		Username:   req.Email,
 		CognitoSub: cognitoSub,
 	})
 
 	if dbErr != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store user: " + dbErr.Error()})
+		apierror.Write(ctx, apierror.Internal("Failed to store user", dbErr))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{"message": "User registered. Check your email for the confirmation code."})
 }
 
+// cognitoError converts an error from a Cognito sign-up call. Problems with what the user
+// entered are reported to them; anything else is an internal error with the given message.
+func cognitoError(err error, message string) *apierror.Error {
+	var usernameExists *types.UsernameExistsException
+	var invalidPassword *types.InvalidPasswordException
+	var codeMismatch *types.CodeMismatchException
+	var expiredCode *types.ExpiredCodeException
+	var userNotFound *types.UserNotFoundException
+
+	switch {
+	case errors.As(err, &usernameExists):
+		return apierror.Conflict("An account with this email already exists").WithCause(err)
+	case errors.As(err, &invalidPassword):
+		// Cognito explains which password rule wasn't met
+		return apierror.Invalid("Password doesn't meet the requirements", apierror.FieldError{
+			Field: "password", Reason: "policy", Message: aws.ToString(invalidPassword.Message),
+		}).WithCause(err)
+	case errors.As(err, &codeMismatch):
+		return apierror.Invalid("Confirmation code is wrong", apierror.FieldError{
+			Field: "code", Reason: "mismatch", Message: "doesn't match the code that was sent",
+		}).WithCause(err)
+	case errors.As(err, &expiredCode):
+		return apierror.Invalid("Confirmation code has expired", apierror.FieldError{
+			Field: "code", Reason: "expired", Message: "has expired; request a new one",
+		}).WithCause(err)
+	case errors.As(err, &userNotFound):
+		return apierror.NotFound("No account exists for this email").WithCause(err)
+	}
+	return apierror.Internal(message, err)
+}
+
 // Confirm signup request structure
 type ConfirmSignUpRequest struct {
 	Email string `json:"email" binding:"required,email"`
@@ -144,20 +178,20 @@ type ConfirmSignUpRequest struct {
 func (server *Server) handleConfirmSignUp(ctx *gin.Context) {
 	var req ConfirmSignUpRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	input := &cognitoidentityprovider.ConfirmSignUpInput{
-		ClientId:         aws.String(clientID),
+		ClientId:         aws.String(server.config.CognitoClientID),
 		Username:         &req.Email,
 		ConfirmationCode: &req.Code,
-		SecretHash:       aws.String(generateSecretHash(req.Email)),
+		SecretHash:       aws.String(server.generateSecretHash(req.Email)),
 	}
 
 	_, err := cognitoClient.ConfirmSignUp(context.TODO(), input)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Account confirmation failed: " + err.Error()})
+		apierror.Write(ctx, cognitoError(err, "Account confirmation failed"))
 		return
 	}
 
@@ -181,7 +215,7 @@ func (server *Server) handleLogin(ctx *gin.Context) {
 func (server *Server) handleCallback(ctx *gin.Context) {
 	code := ctx.Query("code")
 	if code == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing authorization code"})
+		apierror.Write(ctx, apierror.Invalid("Missing authorization code"))
 		return
 	}
 
@@ -198,7 +232,7 @@ func (server *Server) handleCallback(ctx *gin.Context) {
 
 	token, err := oauth2Config.Exchange(exchangeCtx, code)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange token: " + err.Error()})
+		apierror.Write(ctx, apierror.Internal("Failed to exchange token", err))
 		return
 	}
 
@@ -206,20 +240,19 @@ func (server *Server) handleCallback(ctx *gin.Context) {
 	accessToken := token.AccessToken
 	refreshToken, ok := token.Extra("refresh_token").(string)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Missing refresh token"})
+		apierror.Write(ctx, apierror.Internal("Missing refresh token", nil))
 		return
 	}
 
 	rawIDToken, ok := token.Extra("id_token").(string)
 	if !ok {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Missing ID token"})
+		apierror.Write(ctx, apierror.Internal("Missing ID token", nil))
 		return
 	}
 
 	// Redirect to frontend with tokens and the return URL
-	frontendURL := "http://localhost:5173/callback"
 	redirectURL := fmt.Sprintf("%s?access_token=%s&id_token=%s&refresh_token=%s&return_url=%s",
-		frontendURL,
+		server.config.FrontendCallbackURL,
 		url.QueryEscape(accessToken),
 		url.QueryEscape(rawIDToken),
 		url.QueryEscape(refreshToken),
@@ -235,7 +268,7 @@ func (server *Server) handleRefreshToken(ctx *gin.Context) {
 	}
 
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
@@ -245,7 +278,7 @@ func (server *Server) handleRefreshToken(ctx *gin.Context) {
 
 	token, err := oauth2Config.TokenSource(refreshCtx, &oauth2.Token{RefreshToken: req.RefreshToken}).Token()
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to refresh token: " + err.Error()})
+		apierror.Write(ctx, apierror.Unauthorized("Failed to refresh token").WithCause(err))
 		return
 	}
 
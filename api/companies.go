@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -17,12 +18,14 @@ type createCompanyRequest struct {
 	Website     string `json:"website" binding:"omitempty"`
 	Address     string `json:"address" binding:"omitempty"`
 	Description string `json:"description" binding:"omitempty"`
+	WorkspaceID *int32 `json:"workspace_id" binding:"omitempty"` // Defaults to the user's own workspace
 }
 
 // companyResponse represents the API response structure for company data
 type companyResponse struct {
 	CompanyID   int32     `json:"company_id"`
 	CognitoSub  string    `json:"cognito_sub,omitempty"`
+	WorkspaceID int32     `json:"workspace_id,omitempty"`
 	CompanyName string    `json:"company_name"`
 	Industry    string    `json:"industry,omitempty"`
 	Website     string    `json:"website,omitempty"`
@@ -76,6 +79,7 @@ func convertCompanyToResponse(company db.GetCompanyByIDRow) companyResponse {
 	return companyResponse{
 		CompanyID:   company.CompanyID,
 		CognitoSub:  cognitoSub,
+		WorkspaceID: company.WorkspaceID.Int32,
 		CompanyName: company.CompanyName,
 		Industry:    industry,
 		Website:     website,
@@ -130,8 +134,8 @@ func convertCompanyListToResponse(company db.ListCompaniesRow) companyResponse {
 	}
 }
 
-// Function overload to handle cognito sub company rows
-func convertCompanyCognitoToResponse(company db.GetCompaniesByCognitoSubRow) companyResponse {
+// Function overload to handle company rows listed by workspace membership
+func convertCompanyMemberToResponse(company db.ListCompaniesByMemberRow) companyResponse {
 	createdAt := time.Time{}
 	if company.CreatedAt.Valid {
 		createdAt = company.CreatedAt.Time
@@ -166,6 +170,7 @@ func convertCompanyCognitoToResponse(company db.GetCompaniesByCognitoSubRow) com
 	return companyResponse{
 		CompanyID:   company.CompanyID,
 		CognitoSub:  cognitoSub,
+		WorkspaceID: company.WorkspaceID.Int32,
 		CompanyName: company.CompanyName,
 		Industry:    industry,
 		Website:     website,
@@ -179,20 +184,27 @@ func convertCompanyCognitoToResponse(company db.GetCompaniesByCognitoSubRow) com
 func (server *Server) createCompany(ctx *gin.Context) {
 	var req createCompanyRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	// New companies go into the requested workspace or the user's own
+	workspaceID, ok := server.resolveWorkspace(ctx, cognitoSub.(string), req.WorkspaceID)
+	if !ok {
 		return
 	}
 
 	// Convert request to database params
 	arg := db.CreateCompanyParams{
 		CognitoSub:  sql.NullString{String: cognitoSub.(string), Valid: true},
+		WorkspaceID: workspaceID,
 		CompanyName: req.CompanyName,
 		Industry:    sql.NullString{String: req.Industry, Valid: req.Industry != ""},
 		Website:     sql.NullString{String: req.Website, Valid: req.Website != ""},
@@ -201,9 +213,9 @@ func (server *Server) createCompany(ctx *gin.Context) {
 	}
 
 	// Create company in database
-	company, err := server.store.CreateCompany(ctx, arg)
+	company, err := server.store.CreateCompany(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create company"})
+		apierror.Write(ctx, apierror.Internal("Failed to create company", err))
 		return
 	}
 
@@ -211,6 +223,7 @@ func (server *Server) createCompany(ctx *gin.Context) {
 	result := companyResponse{
 		CompanyID:   company.CompanyID,
 		CognitoSub:  cognitoSub.(string),
+		WorkspaceID: workspaceID.Int32,
 		CompanyName: company.CompanyName,
 		Industry:    req.Industry,
 		Website:     req.Website,
@@ -226,7 +239,7 @@ func (server *Server) getCompanyByID(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -234,24 +247,29 @@ func (server *Server) getCompanyByID(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
 	// Get company from database
-	company, err := server.store.GetCompanyByID(ctx, int32(id))
+	company, err := server.store.GetCompanyByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
 
-	// Ensure the user owns this company
-	if !company.CognitoSub.Valid || company.CognitoSub.String != cognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this company"})
+	// Ensure the user is a member of the company's workspace
+	hasAccess, err := server.userHasAccessToCompany(ctx, company.CompanyID, cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to check company access", err))
+		return
+	}
+	if !hasAccess {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to access this company"))
 		return
 	}
 
@@ -264,7 +282,7 @@ func (server *Server) listCompanies(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -288,21 +306,28 @@ func (server *Server) listCompanies(ctx *gin.Context) {
 		offset = 0
 	}
 
-	// Get companies from database for the authenticated user
-	companies, err := server.store.GetCompaniesByCognitoSub(ctx, db.GetCompaniesByCognitoSubParams{
-		CognitoSub: sql.NullString{String: cognitoSub.(string), Valid: true},
-		Limit:      int32(limit),
-		Offset:     int32(offset),
+	// Optionally only list one of the user's workspaces
+	workspaceID, ok := workspaceFilter(ctx)
+	if !ok {
+		return
+	}
+
+	// Get companies from database in the workspaces of the authenticated user
+	companies, err := server.store.ListCompaniesByMember(ctx.Request.Context(), db.ListCompaniesByMemberParams{
+		CognitoSub:  cognitoSub.(string),
+		WorkspaceID: workspaceID,
+		RowLimit:    int32(limit),
+		RowOffset:   int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch companies"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch companies", err))
 		return
 	}
 
 	// Convert companies to response format
 	companyResponses := make([]companyResponse, len(companies))
 	for i, company := range companies {
-		companyResponses[i] = convertCompanyCognitoToResponse(company)
+		companyResponses[i] = convertCompanyMemberToResponse(company)
 	}
 
 	ctx.JSON(http.StatusOK, companyResponses)
@@ -313,7 +338,7 @@ func (server *Server) getCompaniesByUser(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	authedCognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -323,7 +348,7 @@ func (server *Server) getCompaniesByUser(ctx *gin.Context) {
 	// Only allow users to get their own companies unless they have admin privileges
 	// TODO: Add admin check if admin functionality is needed
 	if requestedCognitoSub != authedCognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this user's companies"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to access this user's companies"))
 		return
 	}
 
@@ -347,21 +372,28 @@ func (server *Server) getCompaniesByUser(ctx *gin.Context) {
 		offset = 0
 	}
 
-	// Get companies from database
-	companies, err := server.store.GetCompaniesByCognitoSub(ctx, db.GetCompaniesByCognitoSubParams{
-		CognitoSub: sql.NullString{String: requestedCognitoSub, Valid: true},
-		Limit:      int32(limit),
-		Offset:     int32(offset),
+	// Optionally only list one of the user's workspaces
+	workspaceID, ok := workspaceFilter(ctx)
+	if !ok {
+		return
+	}
+
+	// Get companies from database in the workspaces of the user
+	companies, err := server.store.ListCompaniesByMember(ctx.Request.Context(), db.ListCompaniesByMemberParams{
+		CognitoSub:  requestedCognitoSub,
+		WorkspaceID: workspaceID,
+		RowLimit:    int32(limit),
+		RowOffset:   int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch companies"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch companies", err))
 		return
 	}
 
 	// Convert companies to response format
 	companyResponses := make([]companyResponse, len(companies))
 	for i, company := range companies {
-		companyResponses[i] = convertCompanyCognitoToResponse(company)
+		companyResponses[i] = convertCompanyMemberToResponse(company)
 	}
 
 	ctx.JSON(http.StatusOK, companyResponses)
@@ -372,7 +404,7 @@ func (server *Server) updateCompany(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -380,31 +412,36 @@ func (server *Server) updateCompany(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
 	// Parse request body
 	var req updateCompanyRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Check if company exists
-	company, err := server.store.GetCompanyByID(ctx, int32(id))
+	company, err := server.store.GetCompanyByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
 
-	// Ensure the user owns this company
-	if !company.CognitoSub.Valid || company.CognitoSub.String != cognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this company"})
+	// Ensure the user may edit in the company's workspace
+	canEdit, err := server.userCanEditCompany(ctx, company.CompanyID, cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to check company access", err))
+		return
+	}
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to update this company"))
 		return
 	}
 
@@ -418,9 +455,9 @@ func (server *Server) updateCompany(ctx *gin.Context) {
 		Description: sql.NullString{String: req.Description, Valid: req.Description != ""},
 	}
 
-	updatedCompany, err := server.store.UpdateCompany(ctx, arg)
+	updatedCompany, err := server.store.UpdateCompany(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update company"})
+		apierror.Write(ctx, apierror.Internal("Failed to update company", err))
 		return
 	}
 
@@ -429,6 +466,7 @@ func (server *Server) updateCompany(ctx *gin.Context) {
 		CompanyID:   updatedCompany.CompanyID,
 		CompanyName: updatedCompany.CompanyName,
 		CognitoSub:  updatedCompany.CognitoSub.String,
+		WorkspaceID: company.WorkspaceID.Int32,
 		Industry:    req.Industry,
 		Website:     req.Website,
 		Address:     req.Address,
@@ -443,7 +481,7 @@ func (server *Server) deleteCompany(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -451,31 +489,36 @@ func (server *Server) deleteCompany(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the user
-	company, err := server.store.GetCompanyByID(ctx, int32(id))
+	// Check if company exists
+	company, err := server.store.GetCompanyByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
 
-	// Ensure the user owns this company
-	if !company.CognitoSub.Valid || company.CognitoSub.String != cognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this company"})
+	// Ensure the user may edit in the company's workspace
+	canEdit, err := server.userCanEditCompany(ctx, company.CompanyID, cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to check company access", err))
+		return
+	}
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to delete this company"))
 		return
 	}
 
 	// Delete company
-	err = server.store.DeleteCompany(ctx, int32(id))
+	err = server.store.DeleteCompany(ctx.Request.Context(), int32(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete company"})
+		apierror.Write(ctx, apierror.Internal("Failed to delete company", err))
 		return
 	}
 
@@ -3,11 +3,13 @@
 package api
 
 import (
+	"bytes"
 	"database/sql"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -64,7 +66,7 @@ func (server *Server) createAndAssociateDatasource(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -72,43 +74,56 @@ func (server *Server) createAndAssociateDatasource(ctx *gin.Context) {
 	companyIDParam := ctx.Param("company_id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
-	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
+	// Check if company exists and the user may edit in its workspace
+	canEdit, err := server.userCanEditCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add datasources to this company"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to add datasources to this company"))
 		return
 	}
 
 	// Parse request body
 	var req createDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
+	// Files go to the blob store rather than the database
+	var blobHash sql.NullString
+	if len(req.FileData) > 0 {
+		blobHash, err = server.storeDatasourceFile(ctx.Request.Context(), bytes.NewReader(req.FileData))
+		if err != nil {
+			apierror.Write(ctx, apierror.Internal("Failed to store file", err))
+			return
+		}
+	}
+
 	// Create datasource
 	datasourceArg := db.CreateDatasourceParams{
 		SourceType: req.SourceType,
 		Link:       sql.NullString{String: req.Link, Valid: req.Link != ""},
-		FileData:   req.FileData,
 		FileName:   sql.NullString{String: req.FileName, Valid: req.FileName != ""},
+		BlobHash:   blobHash,
 	}
 
-	datasource, err := server.store.CreateDatasource(ctx, datasourceArg)
+	datasource, err := server.store.CreateDatasource(ctx.Request.Context(), datasourceArg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create datasource"})
+		if blobHash.Valid {
+			server.releaseBlob(ctx.Request.Context(), blobHash.String)
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to create datasource", err))
 		return
 	}
 
@@ -118,11 +133,11 @@ func (server *Server) createAndAssociateDatasource(ctx *gin.Context) {
 		DatasourceID: datasource.DatasourceID,
 	}
 
-	err = server.store.AssociateDatasourceWithCompany(ctx, associateArg)
+	err = server.store.AssociateDatasourceWithCompany(ctx.Request.Context(), associateArg)
 	if err != nil {
 		// Rollback datasource creation if association fails
-		_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with company"})
+		server.discardDatasource(ctx.Request.Context(), datasource)
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with company", err))
 		return
 	}
 
@@ -139,7 +154,7 @@ func (server *Server) associateDatasourceWithCompany(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -147,63 +162,59 @@ func (server *Server) associateDatasourceWithCompany(ctx *gin.Context) {
 	companyIDParam := ctx.Param("company_id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
-	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
+	// Check if company exists and the user may edit in its workspace
+	canEdit, err := server.userCanEditCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to associate datasources with this company"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to associate datasources with this company"))
 		return
 	}
 
 	// Parse request body
 	var req associateDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
-	// Check if datasource exists
-	_, err = server.store.GetDatasourceByID(ctx, req.DatasourceID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Datasource not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasource"})
+	// Check that the datasource exists and the user may edit it already; otherwise
+	// associating it would hand another workspace's datasource over
+	if err := server.authz.CanEditDatasource(ctx.Request.Context(), cognitoSub.(string), req.DatasourceID); err != nil {
+		respondEditError(ctx, err, "datasource")
 		return
 	}
 
 	// Check if association already exists
-	_, err = server.store.GetCompanyDatasourceAssociation(ctx, db.GetCompanyDatasourceAssociationParams{
+	_, err = server.store.GetCompanyDatasourceAssociation(ctx.Request.Context(), db.GetCompanyDatasourceAssociationParams{
 		CompanyID:    int32(companyID),
 		DatasourceID: req.DatasourceID,
 	})
 	if err == nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datasource is already associated with this company"})
+		apierror.Write(ctx, apierror.Invalid("Datasource is already associated with this company"))
 		return
 	} else if err != sql.ErrNoRows {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Associate datasource with company
-	err = server.store.AssociateDatasourceWithCompany(ctx, db.AssociateDatasourceWithCompanyParams{
+	err = server.store.AssociateDatasourceWithCompany(ctx.Request.Context(), db.AssociateDatasourceWithCompanyParams{
 		CompanyID:    int32(companyID),
 		DatasourceID: req.DatasourceID,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with company"})
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with company", err))
 		return
 	}
 
@@ -220,7 +231,7 @@ func (server *Server) removeDatasourceFromCompany(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -230,52 +241,52 @@ func (server *Server) removeDatasourceFromCompany(ctx *gin.Context) {
 
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
 	datasourceID, err := strconv.Atoi(datasourceIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datasource ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
-	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
+	// Check if company exists and the user may edit in its workspace
+	canEdit, err := server.userCanEditCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to remove datasources from this company"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to remove datasources from this company"))
 		return
 	}
 
 	// Check if association exists
-	_, err = server.store.GetCompanyDatasourceAssociation(ctx, db.GetCompanyDatasourceAssociationParams{
+	_, err = server.store.GetCompanyDatasourceAssociation(ctx.Request.Context(), db.GetCompanyDatasourceAssociationParams{
 		CompanyID:    int32(companyID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Association between company and datasource not found"})
+			apierror.Write(ctx, apierror.NotFound("Association between company and datasource not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Remove association
-	err = server.store.RemoveDatasourceFromCompany(ctx, db.RemoveDatasourceFromCompanyParams{
+	err = server.store.RemoveDatasourceFromCompany(ctx.Request.Context(), db.RemoveDatasourceFromCompanyParams{
 		CompanyID:    int32(companyID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove datasource from company"})
+		apierror.Write(ctx, apierror.Internal("Failed to remove datasource from company", err))
 		return
 	}
 
@@ -288,7 +299,7 @@ func (server *Server) listDatasourcesByCompany(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -296,22 +307,22 @@ func (server *Server) listDatasourcesByCompany(ctx *gin.Context) {
 	companyIDParam := ctx.Param("company_id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
+	// Check if company exists and the user is a member of its workspace
 	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
 	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view datasources for this company"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to view datasources for this company"))
 		return
 	}
 
@@ -335,13 +346,13 @@ func (server *Server) listDatasourcesByCompany(ctx *gin.Context) {
 	}
 
 	// Get datasources for company
-	datasources, err := server.store.ListDatasourcesByCompany(ctx, db.ListDatasourcesByCompanyParams{
+	datasources, err := server.store.ListDatasourcesByCompany(ctx.Request.Context(), db.ListDatasourcesByCompanyParams{
 		CompanyID: int32(companyID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasources"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasources", err))
 		return
 	}
 
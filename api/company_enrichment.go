@@ -0,0 +1,410 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+)
+
+// maxEnrichmentContacts caps how many existing contacts are compared against person facts
+const maxEnrichmentContacts = 1000
+
+// Company fields that can be filled from extracted facts
+const (
+	enrichmentFieldIndustry    = "industry"
+	enrichmentFieldAddress     = "address"
+	enrichmentFieldDescription = "description"
+)
+
+// factResponse represents the API response structure for an extracted fact
+type factResponse struct {
+	FactID       int32             `json:"fact_id"`
+	DatasourceID int32             `json:"datasource_id"`
+	FactType     string            `json:"fact_type"`
+	Key          string            `json:"key"`
+	Value        string            `json:"value"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+	Source       string            `json:"source,omitempty"`
+	SourceURL    string            `json:"source_url,omitempty"`
+	CreatedAt    time.Time         `json:"created_at,omitempty"`
+}
+
+// enrichmentFieldSuggestion is a proposed value for an empty company field
+type enrichmentFieldSuggestion struct {
+	Field     string `json:"field"`
+	Value     string `json:"value"`
+	FactID    int32  `json:"fact_id"`
+	SourceURL string `json:"source_url,omitempty"`
+}
+
+// enrichmentContactSuggestion is a person found on the company website who isn't a contact yet
+type enrichmentContactSuggestion struct {
+	FactID    int32  `json:"fact_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Position  string `json:"position,omitempty"`
+	Email     string `json:"email,omitempty"`
+	Phone     string `json:"phone,omitempty"`
+	SourceURL string `json:"source_url,omitempty"`
+}
+
+// companyEnrichmentResponse lists everything the extracted facts can add to a company
+type companyEnrichmentResponse struct {
+	CompanyID int32                         `json:"company_id"`
+	Fields    []enrichmentFieldSuggestion   `json:"fields"`
+	Contacts  []enrichmentContactSuggestion `json:"contacts"`
+}
+
+// applyEnrichmentRequest represents the suggestions the user accepted
+type applyEnrichmentRequest struct {
+	Fields         []string `json:"fields"`
+	ContactFactIDs []int32  `json:"contact_fact_ids"`
+}
+
+// applyEnrichmentResponse represents the result of applying accepted suggestions
+type applyEnrichmentResponse struct {
+	Company  companyResponse   `json:"company"`
+	Contacts []contactResponse `json:"contacts"`
+}
+
+// convertFactToResponse converts a database fact to an API response
+func convertFactToResponse(fact db.DatasourceFact) factResponse {
+	response := factResponse{
+		FactID:       fact.FactID,
+		DatasourceID: fact.DatasourceID,
+		FactType:     string(fact.FactType),
+		Key:          fact.FactKey,
+		Value:        fact.Value,
+		Attributes:   factAttributes(fact),
+		Source:       fact.Source.String,
+		SourceURL:    fact.SourceUrl.String,
+	}
+	if fact.CreatedAt.Valid {
+		response.CreatedAt = fact.CreatedAt.Time
+	}
+	return response
+}
+
+// factAttributes decodes the JSON attributes stored with a fact
+func factAttributes(fact db.DatasourceFact) map[string]string {
+	if !fact.Attributes.Valid {
+		return nil
+	}
+	var attributes map[string]string
+	if err := json.Unmarshal(fact.Attributes.RawMessage, &attributes); err != nil {
+		return nil
+	}
+	return attributes
+}
+
+// listCompanyFacts handles requests to list the facts extracted from a company's datasources
+func (server *Server) listCompanyFacts(ctx *gin.Context) {
+	company, ok := server.loadAuthorizedCompany(ctx, false, "You don't have permission to view this company")
+	if !ok {
+		return
+	}
+
+	facts, err := server.store.ListFactsByCompany(ctx.Request.Context(), company.CompanyID)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company facts", err))
+		return
+	}
+
+	response := make([]factResponse, 0, len(facts))
+	for _, fact := range facts {
+		response = append(response, convertFactToResponse(fact))
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// getCompanyEnrichment handles requests to preview what extracted facts can add to a company
+func (server *Server) getCompanyEnrichment(ctx *gin.Context) {
+	company, ok := server.loadAuthorizedCompany(ctx, false, "You don't have permission to view this company")
+	if !ok {
+		return
+	}
+
+	suggestions, err := server.companyEnrichmentSuggestions(ctx, company)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to build enrichment suggestions", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, suggestions)
+}
+
+// applyCompanyEnrichment handles requests to fill company fields and create contacts from extracted facts
+func (server *Server) applyCompanyEnrichment(ctx *gin.Context) {
+	company, ok := server.loadAuthorizedCompany(ctx, true, "You don't have permission to update this company")
+	if !ok {
+		return
+	}
+
+	var req applyEnrichmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+
+	// Suggestions are rebuilt so only empty fields and unknown people can be applied
+	suggestions, err := server.companyEnrichmentSuggestions(ctx, company)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to build enrichment suggestions", err))
+		return
+	}
+
+	fieldValues := make(map[string]string, len(suggestions.Fields))
+	for _, suggestion := range suggestions.Fields {
+		fieldValues[suggestion.Field] = suggestion.Value
+	}
+	contactSuggestions := make(map[int32]enrichmentContactSuggestion, len(suggestions.Contacts))
+	for _, suggestion := range suggestions.Contacts {
+		contactSuggestions[suggestion.FactID] = suggestion
+	}
+
+	// Validate the whole request before changing anything
+	for _, field := range req.Fields {
+		if _, ok := fieldValues[field]; !ok {
+			apierror.Write(ctx, apierror.Invalid("No suggestion available for field "+field))
+			return
+		}
+	}
+	for _, factID := range req.ContactFactIDs {
+		if _, ok := contactSuggestions[factID]; !ok {
+			apierror.Write(ctx, apierror.Invalid("No contact suggestion available for fact "+strconv.Itoa(int(factID))))
+			return
+		}
+	}
+
+	arg := db.UpdateCompanyParams{
+		CompanyID:   company.CompanyID,
+		CompanyName: company.CompanyName,
+		Industry:    company.Industry,
+		Website:     company.Website,
+		Address:     company.Address,
+		Description: company.Description,
+	}
+	for _, field := range req.Fields {
+		value := sql.NullString{String: fieldValues[field], Valid: true}
+		switch field {
+		case enrichmentFieldIndustry:
+			arg.Industry = value
+		case enrichmentFieldAddress:
+			arg.Address = value
+		case enrichmentFieldDescription:
+			arg.Description = value
+		}
+	}
+
+	txArg := db.ApplyCompanyEnrichmentTxParams{Contacts: make([]db.CreateContactParams, 0, len(req.ContactFactIDs))}
+	if len(req.Fields) > 0 {
+		txArg.Company = &arg
+	}
+	for _, factID := range req.ContactFactIDs {
+		suggestion := contactSuggestions[factID]
+		txArg.Contacts = append(txArg.Contacts, db.CreateContactParams{
+			CompanyID: company.CompanyID,
+			FirstName: suggestion.FirstName,
+			LastName:  suggestion.LastName,
+			Position:  sql.NullString{String: suggestion.Position, Valid: suggestion.Position != ""},
+			Email:     sql.NullString{String: suggestion.Email, Valid: suggestion.Email != ""},
+			Phone:     sql.NullString{String: suggestion.Phone, Valid: suggestion.Phone != ""},
+			Notes:     sql.NullString{String: "Imported from " + suggestion.SourceURL, Valid: suggestion.SourceURL != ""},
+		})
+	}
+
+	// The company and its new contacts are saved together, so a failure leaves nothing half-applied
+	contacts, err := server.store.ApplyCompanyEnrichmentTx(ctx.Request.Context(), txArg)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to apply enrichment", err))
+		return
+	}
+
+	createdContacts := make([]contactResponse, 0, len(contacts))
+	for _, contact := range contacts {
+		createdContacts = append(createdContacts, convertContactToResponse(contact))
+	}
+
+	updatedCompany, err := server.store.GetCompanyByID(ctx.Request.Context(), company.CompanyID)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, applyEnrichmentResponse{
+		Company:  convertCompanyToResponse(updatedCompany),
+		Contacts: createdContacts,
+	})
+}
+
+// loadAuthorizedCompany fetches the company from the URL and checks that the authenticated user
+// may read it, or change it when edit is set. It writes the error response itself and returns
+// false when the request should stop.
+func (server *Server) loadAuthorizedCompany(ctx *gin.Context, edit bool, forbiddenMessage string) (db.GetCompanyByIDRow, bool) {
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return db.GetCompanyByIDRow{}, false
+	}
+
+	// Get company ID from URL param
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
+		return db.GetCompanyByIDRow{}, false
+	}
+
+	company, err := server.store.GetCompanyByID(ctx.Request.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
+			return db.GetCompanyByIDRow{}, false
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
+		return db.GetCompanyByIDRow{}, false
+	}
+
+	// Ensure the user has the required role in the company's workspace
+	check := server.userHasAccessToCompany
+	if edit {
+		check = server.userCanEditCompany
+	}
+	allowed, err := check(ctx, company.CompanyID, cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to check company access", err))
+		return db.GetCompanyByIDRow{}, false
+	}
+	if !allowed {
+		apierror.Write(ctx, apierror.Forbidden(forbiddenMessage))
+		return db.GetCompanyByIDRow{}, false
+	}
+
+	return company, true
+}
+
+// companyEnrichmentSuggestions loads a company's facts and contacts and builds suggestions from them
+func (server *Server) companyEnrichmentSuggestions(ctx *gin.Context, company db.GetCompanyByIDRow) (companyEnrichmentResponse, error) {
+	facts, err := server.store.ListFactsByCompany(ctx.Request.Context(), company.CompanyID)
+	if err != nil {
+		return companyEnrichmentResponse{}, err
+	}
+
+	contacts, err := server.store.ListContactsByCompanyID(ctx.Request.Context(), db.ListContactsByCompanyIDParams{
+		CompanyID: company.CompanyID,
+		Limit:     maxEnrichmentContacts,
+		Offset:    0,
+	})
+	if err != nil {
+		return companyEnrichmentResponse{}, err
+	}
+
+	return buildEnrichmentSuggestions(company, facts, contacts), nil
+}
+
+// buildEnrichmentSuggestions proposes values for empty company fields and contacts for unknown people
+func buildEnrichmentSuggestions(company db.GetCompanyByIDRow, facts []db.DatasourceFact, contacts []db.Contact) companyEnrichmentResponse {
+	response := companyEnrichmentResponse{
+		CompanyID: company.CompanyID,
+		Fields:    []enrichmentFieldSuggestion{},
+		Contacts:  []enrichmentContactSuggestion{},
+	}
+
+	// Only suggest values for fields the user hasn't filled in
+	emptyFields := []struct {
+		field string
+		empty bool
+		match func(db.DatasourceFact) bool
+	}{
+		{enrichmentFieldIndustry, isEmptyField(company.Industry), func(f db.DatasourceFact) bool {
+			return f.FactType == db.FactTypeOrganization && f.FactKey == "industry"
+		}},
+		{enrichmentFieldAddress, isEmptyField(company.Address), func(f db.DatasourceFact) bool {
+			return f.FactType == db.FactTypeAddress
+		}},
+		{enrichmentFieldDescription, isEmptyField(company.Description), func(f db.DatasourceFact) bool {
+			return (f.FactType == db.FactTypeOrganization && f.FactKey == "description") ||
+				(f.FactType == db.FactTypeOpenGraph && (f.FactKey == "description" || f.FactKey == "og:description"))
+		}},
+	}
+	for _, candidate := range emptyFields {
+		if !candidate.empty {
+			continue
+		}
+		for _, fact := range facts {
+			if candidate.match(fact) {
+				response.Fields = append(response.Fields, enrichmentFieldSuggestion{
+					Field:     candidate.field,
+					Value:     fact.Value,
+					FactID:    fact.FactID,
+					SourceURL: fact.SourceUrl.String,
+				})
+				break
+			}
+		}
+	}
+
+	// Index existing contacts so people already on file aren't suggested again
+	knownEmails := make(map[string]bool, len(contacts))
+	knownNames := make(map[string]bool, len(contacts))
+	for _, contact := range contacts {
+		if contact.Email.Valid {
+			knownEmails[strings.ToLower(contact.Email.String)] = true
+		}
+		knownNames[normalizePersonName(contact.FirstName+" "+contact.LastName)] = true
+	}
+
+	for _, fact := range facts {
+		if fact.FactType != db.FactTypePerson {
+			continue
+		}
+
+		attributes := factAttributes(fact)
+		email := attributes["email"]
+		name := normalizePersonName(fact.Value)
+		if knownNames[name] || (email != "" && knownEmails[strings.ToLower(email)]) {
+			continue
+		}
+		// The same person can be found on several datasources
+		knownNames[name] = true
+
+		firstName, lastName := splitPersonName(fact.Value)
+		response.Contacts = append(response.Contacts, enrichmentContactSuggestion{
+			FactID:    fact.FactID,
+			FirstName: firstName,
+			LastName:  lastName,
+			Position:  attributes["job_title"],
+			Email:     email,
+			Phone:     attributes["telephone"],
+			SourceURL: fact.SourceUrl.String,
+		})
+	}
+
+	return response
+}
+
+// isEmptyField reports whether a nullable company field has no value
+func isEmptyField(field sql.NullString) bool {
+	return !field.Valid || strings.TrimSpace(field.String) == ""
+}
+
+// normalizePersonName lowercases a name and collapses its whitespace for comparisons
+func normalizePersonName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// splitPersonName splits a full name into a first name and the remaining last name
+func splitPersonName(name string) (string, string) {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return "", ""
+	}
+	return parts[0], strings.Join(parts[1:], " ")
+}
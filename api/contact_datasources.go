@@ -3,11 +3,13 @@
 package api
 
 import (
+	"bytes"
 	"database/sql"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -43,39 +45,46 @@ func (server *Server) createAndAssociateContactDatasource(ctx *gin.Context) {
 	contactIDParam := ctx.Param("contact_id")
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
-	// Check if contact exists
-	_, err = server.store.GetContactByID(ctx, int32(contactID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+	// Check if contact exists and the user may edit in its company's workspace
+	if !server.authorizeContact(ctx, int32(contactID), true) {
 		return
 	}
 
 	// Parse request body
 	var req createDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
+	// Files go to the blob store rather than the database
+	var blobHash sql.NullString
+	if len(req.FileData) > 0 {
+		blobHash, err = server.storeDatasourceFile(ctx.Request.Context(), bytes.NewReader(req.FileData))
+		if err != nil {
+			apierror.Write(ctx, apierror.Internal("Failed to store file", err))
+			return
+		}
+	}
+
 	// Create datasource
 	datasourceArg := db.CreateDatasourceParams{
 		SourceType: req.SourceType,
 		Link:       sql.NullString{String: req.Link, Valid: req.Link != ""},
-		FileData:   req.FileData,
 		FileName:   sql.NullString{String: req.FileName, Valid: req.FileName != ""},
+		BlobHash:   blobHash,
 	}
 
-	datasource, err := server.store.CreateDatasource(ctx, datasourceArg)
+	datasource, err := server.store.CreateDatasource(ctx.Request.Context(), datasourceArg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create datasource"})
+		if blobHash.Valid {
+			server.releaseBlob(ctx.Request.Context(), blobHash.String)
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to create datasource", err))
 		return
 	}
 
@@ -85,11 +94,11 @@ func (server *Server) createAndAssociateContactDatasource(ctx *gin.Context) {
 		DatasourceID: datasource.DatasourceID,
 	}
 
-	err = server.store.AssociateDatasourceWithContact(ctx, associateArg)
+	err = server.store.AssociateDatasourceWithContact(ctx.Request.Context(), associateArg)
 	if err != nil {
 		// Rollback datasource creation if association fails
-		_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with contact"})
+		server.discardDatasource(ctx.Request.Context(), datasource)
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with contact", err))
 		return
 	}
 
@@ -103,63 +112,60 @@ func (server *Server) createAndAssociateContactDatasource(ctx *gin.Context) {
 
 // associateDatasourceWithContact handles requests to associate an existing datasource with a contact
 func (server *Server) associateDatasourceWithContact(ctx *gin.Context) {
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
 	// Get contact ID from URL param
 	contactIDParam := ctx.Param("contact_id")
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
-	// Check if contact exists
-	_, err = server.store.GetContactByID(ctx, int32(contactID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+	// Check if contact exists and the user may edit in its company's workspace
+	if !server.authorizeContact(ctx, int32(contactID), true) {
 		return
 	}
 
 	// Parse request body
 	var req associateDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
-	// Check if datasource exists
-	_, err = server.store.GetDatasourceByID(ctx, req.DatasourceID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Datasource not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasource"})
+	// Check that the datasource exists and the user may edit it already; otherwise
+	// associating it would hand another workspace's datasource over
+	if err := server.authz.CanEditDatasource(ctx.Request.Context(), cognitoSub.(string), req.DatasourceID); err != nil {
+		respondEditError(ctx, err, "datasource")
 		return
 	}
 
 	// Check if association already exists
-	_, err = server.store.GetContactDatasourceAssociation(ctx, db.GetContactDatasourceAssociationParams{
+	_, err = server.store.GetContactDatasourceAssociation(ctx.Request.Context(), db.GetContactDatasourceAssociationParams{
 		ContactID:    int32(contactID),
 		DatasourceID: req.DatasourceID,
 	})
 	if err == nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datasource is already associated with this contact"})
+		apierror.Write(ctx, apierror.Invalid("Datasource is already associated with this contact"))
 		return
 	} else if err != sql.ErrNoRows {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Associate datasource with contact
-	err = server.store.AssociateDatasourceWithContact(ctx, db.AssociateDatasourceWithContactParams{
+	err = server.store.AssociateDatasourceWithContact(ctx.Request.Context(), db.AssociateDatasourceWithContactParams{
 		ContactID:    int32(contactID),
 		DatasourceID: req.DatasourceID,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with contact", err))
 		return
 	}
 
@@ -179,37 +185,42 @@ func (server *Server) removeDatasourceFromContact(ctx *gin.Context) {
 
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
 	datasourceID, err := strconv.Atoi(datasourceIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datasource ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
+		return
+	}
+
+	// Check if contact exists and the user may edit in its company's workspace
+	if !server.authorizeContact(ctx, int32(contactID), true) {
 		return
 	}
 
 	// Check if association exists
-	_, err = server.store.GetContactDatasourceAssociation(ctx, db.GetContactDatasourceAssociationParams{
+	_, err = server.store.GetContactDatasourceAssociation(ctx.Request.Context(), db.GetContactDatasourceAssociationParams{
 		ContactID:    int32(contactID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Association between contact and datasource not found"})
+			apierror.Write(ctx, apierror.NotFound("Association between contact and datasource not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Remove association
-	err = server.store.RemoveDatasourceFromContact(ctx, db.RemoveDatasourceFromContactParams{
+	err = server.store.RemoveDatasourceFromContact(ctx.Request.Context(), db.RemoveDatasourceFromContactParams{
 		ContactID:    int32(contactID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove datasource from contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to remove datasource from contact", err))
 		return
 	}
 
@@ -223,18 +234,12 @@ func (server *Server) listDatasourcesByContact(ctx *gin.Context) {
 	contactIDParam := ctx.Param("contact_id")
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
-	// Check if contact exists
-	_, err = server.store.GetContactByID(ctx, int32(contactID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+	// Check if contact exists and the user is a member of its company's workspace
+	if !server.authorizeContact(ctx, int32(contactID), false) {
 		return
 	}
 
@@ -258,13 +263,13 @@ func (server *Server) listDatasourcesByContact(ctx *gin.Context) {
 	}
 
 	// Get datasources for contact
-	datasources, err := server.store.ListDatasourcesByContact(ctx, db.ListDatasourcesByContactParams{
+	datasources, err := server.store.ListDatasourcesByContact(ctx.Request.Context(), db.ListDatasourcesByContactParams{
 		ContactID: int32(contactID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasources"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasources", err))
 		return
 	}
 
@@ -276,3 +281,26 @@ func (server *Server) listDatasourcesByContact(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, responses)
 }
+
+// authorizeContact checks that the contact exists and the authenticated user may read it, or
+// change it when edit is set. It writes the error response itself and returns false when the
+// request should stop.
+func (server *Server) authorizeContact(ctx *gin.Context, contactID int32, edit bool) bool {
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return false
+	}
+	if edit {
+		if err := server.authz.CanEditContact(ctx.Request.Context(), cognitoSub.(string), contactID); err != nil {
+			respondEditError(ctx, err, "contact")
+			return false
+		}
+		return true
+	}
+	if err := server.authz.CanAccessContact(ctx.Request.Context(), cognitoSub.(string), contactID); err != nil {
+		respondAccessError(ctx, err, "contact")
+		return false
+	}
+	return true
+}
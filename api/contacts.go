@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -85,44 +86,33 @@ func convertContactToResponse(contact db.Contact) contactResponse {
 	}
 }
 
-// Helper function to check if a user has access to a company
-func (server *Server) userHasAccessToCompany(ctx *gin.Context, companyID int32, cognitoSub string) (bool, error) {
-	company, err := server.store.GetCompanyByID(ctx, companyID)
-	if err != nil {
-		return false, err
-	}
-	return company.CognitoSub.Valid && company.CognitoSub.String == cognitoSub, nil
-}
-
 // createContact handles requests to create a new contact
 func (server *Server) createContact(ctx *gin.Context) {
 	var req createContactRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
-	company, err := server.store.GetCompanyByID(ctx, req.CompanyID)
+	// Check that the company exists and the user may edit in its workspace
+	canEdit, err := server.userCanEditCompany(ctx, req.CompanyID, cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
-
-	// Verify that the company belongs to the authenticated user
-	if !company.CognitoSub.Valid || company.CognitoSub.String != cognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add contacts to this company"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to add contacts to this company"))
 		return
 	}
 
@@ -138,9 +128,9 @@ func (server *Server) createContact(ctx *gin.Context) {
 	}
 
 	// Create contact in database
-	contact, err := server.store.CreateContact(ctx, arg)
+	contact, err := server.store.CreateContact(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to create contact", err))
 		return
 	}
 
@@ -153,7 +143,7 @@ func (server *Server) getContactByID(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -161,29 +151,29 @@ func (server *Server) getContactByID(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
 	// Get contact from database
-	contact, err := server.store.GetContactByID(ctx, int32(id))
+	contact, err := server.store.GetContactByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			apierror.Write(ctx, apierror.NotFound("Contact not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch contact", err))
 		return
 	}
 
-	// Check if the contact's company belongs to the authenticated user
+	// Check if the user is a member of the workspace of the contact's company
 	hasAccess, err := server.userHasAccessToCompany(ctx, contact.CompanyID, cognitoSub.(string))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify company ownership"})
+		apierror.Write(ctx, apierror.Internal("Failed to verify company access", err))
 		return
 	}
 	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this contact"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to access this contact"))
 		return
 	}
 
@@ -196,14 +186,14 @@ func (server *Server) listContacts(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	_, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
 	// This is a placeholder implementation since there's no direct "list all contacts" query
 	// In a real implementation, you would need to add a new query in your SQL files
 	// For now, we'll return a 501 Not Implemented
-	ctx.JSON(http.StatusNotImplemented, gin.H{"error": "This endpoint is not yet implemented"})
+	apierror.Write(ctx, apierror.New(http.StatusNotImplemented, apierror.CodeNotImplemented, "This endpoint is not yet implemented"))
 }
 
 // listContactsByCompany handles requests to get contacts for a specific company
@@ -211,7 +201,7 @@ func (server *Server) listContactsByCompany(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -219,22 +209,22 @@ func (server *Server) listContactsByCompany(ctx *gin.Context) {
 	companyIDParam := ctx.Param("company_id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
-	// Check if the company belongs to the authenticated user
+	// Check if the user is a member of the company's workspace
 	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify company ownership"})
+		apierror.Write(ctx, apierror.Internal("Failed to verify company access", err))
 		return
 	}
 	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access contacts for this company"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to access contacts for this company"))
 		return
 	}
 
@@ -258,13 +248,13 @@ func (server *Server) listContactsByCompany(ctx *gin.Context) {
 	}
 
 	// Get contacts from database
-	contacts, err := server.store.ListContactsByCompanyID(ctx, db.ListContactsByCompanyIDParams{
+	contacts, err := server.store.ListContactsByCompanyID(ctx.Request.Context(), db.ListContactsByCompanyIDParams{
 		CompanyID: int32(companyID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contacts"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch contacts", err))
 		return
 	}
 
@@ -282,14 +272,14 @@ func (server *Server) searchContactsByName(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
 	// Get search query from URL param
 	query := ctx.Query("q")
 	if query == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		apierror.Write(ctx, apierror.Invalid("Search query is required"))
 		return
 	}
 
@@ -316,13 +306,13 @@ func (server *Server) searchContactsByName(ctx *gin.Context) {
 	// Get contacts from database
 	// Note: This needs to be modified to only return contacts for companies owned by the user
 	// You may need to create a new database query for this
-	contacts, err := server.store.SearchContactsByName(ctx, db.SearchContactsByNameParams{
+	contacts, err := server.store.SearchContactsByName(ctx.Request.Context(), db.SearchContactsByNameParams{
 		Column1: sql.NullString{String: query, Valid: true},
 		Limit:   int32(limit),
 		Offset:  int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search contacts"})
+		apierror.Write(ctx, apierror.Internal("Failed to search contacts", err))
 		return
 	}
 
@@ -350,7 +340,7 @@ func (server *Server) updateContact(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -358,36 +348,36 @@ func (server *Server) updateContact(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
 	// Parse request body
 	var req updateContactRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Check if contact exists and get its company ID
-	contact, err := server.store.GetContactByID(ctx, int32(id))
+	contact, err := server.store.GetContactByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			apierror.Write(ctx, apierror.NotFound("Contact not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch contact", err))
 		return
 	}
 
-	// Check if the contact's company belongs to the authenticated user
-	hasAccess, err := server.userHasAccessToCompany(ctx, contact.CompanyID, cognitoSub.(string))
+	// Check if the user may edit in the workspace of the contact's company
+	canEdit, err := server.userCanEditCompany(ctx, contact.CompanyID, cognitoSub.(string))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify company ownership"})
+		apierror.Write(ctx, apierror.Internal("Failed to verify company access", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this contact"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to update this contact"))
 		return
 	}
 
@@ -402,9 +392,9 @@ func (server *Server) updateContact(ctx *gin.Context) {
 		Notes:     sql.NullString{String: req.Notes, Valid: req.Notes != ""},
 	}
 
-	updatedContact, err := server.store.UpdateContact(ctx, arg)
+	updatedContact, err := server.store.UpdateContact(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to update contact", err))
 		return
 	}
 
@@ -417,7 +407,7 @@ func (server *Server) deleteContact(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -425,36 +415,36 @@ func (server *Server) deleteContact(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
 	// Check if contact exists
-	contact, err := server.store.GetContactByID(ctx, int32(id))
+	contact, err := server.store.GetContactByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			apierror.Write(ctx, apierror.NotFound("Contact not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch contact", err))
 		return
 	}
 
-	// Check if the contact's company belongs to the authenticated user
-	hasAccess, err := server.userHasAccessToCompany(ctx, contact.CompanyID, cognitoSub.(string))
+	// Check if the user may edit in the workspace of the contact's company
+	canEdit, err := server.userCanEditCompany(ctx, contact.CompanyID, cognitoSub.(string))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify company ownership"})
+		apierror.Write(ctx, apierror.Internal("Failed to verify company access", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this contact"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to delete this contact"))
 		return
 	}
 
 	// Delete contact
-	err = server.store.DeleteContact(ctx, int32(id))
+	err = server.store.DeleteContact(ctx.Request.Context(), int32(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to delete contact", err))
 		return
 	}
 
@@ -4,11 +4,11 @@ package api
 
 import (
 	"database/sql"
-	"io"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -38,23 +38,12 @@ func convertCompanyDatasourceToResponse(datasource db.ListDatasourcesByCompanyRo
 	}
 }
 
-// Helper function to check if a user has access to a contact through company ownership
-func (server *Server) userHasAccessToContact(ctx *gin.Context, contactID int32, cognitoSub string) (bool, error) {
-	contact, err := server.store.GetContactByID(ctx, contactID)
-	if err != nil {
-		return false, err
-	}
-
-	// Check if the user owns the company that this contact belongs to
-	return server.userHasAccessToCompany(ctx, contact.CompanyID, cognitoSub)
-}
-
 // createCompanyDatasource handles creating datasources for companies
 func (server *Server) createCompanyDatasource(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -62,29 +51,29 @@ func (server *Server) createCompanyDatasource(ctx *gin.Context) {
 	companyIDParam := ctx.Param("id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
-	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
+	// Check if company exists and the user may edit in its workspace
+	canEdit, err := server.userCanEditCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add datasources to this company"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to add datasources to this company"))
 		return
 	}
 
 	// Parse request body
 	var req createDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
@@ -96,9 +85,9 @@ func (server *Server) createCompanyDatasource(ctx *gin.Context) {
 		FileName:   sql.NullString{String: req.FileName, Valid: req.FileName != ""},
 	}
 
-	datasource, err := server.store.CreateDatasource(ctx, datasourceArg)
+	datasource, err := server.store.CreateDatasource(ctx.Request.Context(), datasourceArg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create datasource"})
+		apierror.Write(ctx, apierror.Internal("Failed to create datasource", err))
 		return
 	}
 
@@ -108,11 +97,11 @@ func (server *Server) createCompanyDatasource(ctx *gin.Context) {
 		DatasourceID: datasource.DatasourceID,
 	}
 
-	err = server.store.AssociateDatasourceWithCompany(ctx, associateArg)
+	err = server.store.AssociateDatasourceWithCompany(ctx.Request.Context(), associateArg)
 	if err != nil {
 		// Rollback datasource creation if association fails
-		_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with company"})
+		_ = server.store.DeleteDatasource(ctx.Request.Context(), datasource.DatasourceID)
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with company", err))
 		return
 	}
 
@@ -131,7 +120,7 @@ func (server *Server) createContactDatasource(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -139,29 +128,29 @@ func (server *Server) createContactDatasource(ctx *gin.Context) {
 	contactIDParam := ctx.Param("id")
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
-	// Check if contact exists and belongs to a company owned by the authenticated user
-	hasAccess, err := server.userHasAccessToContact(ctx, int32(contactID), cognitoSub.(string))
+	// Check if contact exists and the user may edit in its company's workspace
+	canEdit, err := server.userCanEditContact(ctx, int32(contactID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			apierror.Write(ctx, apierror.NotFound("Contact not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch contact", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to add datasources to this contact"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to add datasources to this contact"))
 		return
 	}
 
 	// Parse request body
 	var req createDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
@@ -173,9 +162,9 @@ func (server *Server) createContactDatasource(ctx *gin.Context) {
 		FileName:   sql.NullString{String: req.FileName, Valid: req.FileName != ""},
 	}
 
-	datasource, err := server.store.CreateDatasource(ctx, datasourceArg)
+	datasource, err := server.store.CreateDatasource(ctx.Request.Context(), datasourceArg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create datasource"})
+		apierror.Write(ctx, apierror.Internal("Failed to create datasource", err))
 		return
 	}
 
@@ -185,11 +174,11 @@ func (server *Server) createContactDatasource(ctx *gin.Context) {
 		DatasourceID: datasource.DatasourceID,
 	}
 
-	err = server.store.AssociateDatasourceWithContact(ctx, associateArg)
+	err = server.store.AssociateDatasourceWithContact(ctx.Request.Context(), associateArg)
 	if err != nil {
 		// Rollback datasource creation if association fails
-		_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with contact"})
+		_ = server.store.DeleteDatasource(ctx.Request.Context(), datasource.DatasourceID)
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with contact", err))
 		return
 	}
 
@@ -203,170 +192,12 @@ func (server *Server) createContactDatasource(ctx *gin.Context) {
 	})
 }
 
-// uploadDatasource handles file uploads for both companies and contacts
-func (server *Server) uploadDatasource(ctx *gin.Context) {
-	// Get authenticated user's cognito_sub from context
-	cognitoSub, exists := ctx.Get("cognito_sub")
-	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
-		return
-	}
-
-	// Determine if this is for a company or contact
-	entityType := ctx.Param("entity_type") // "companies" or "contacts"
-	entityID, err := strconv.Atoi(ctx.Param("id"))
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
-		return
-	}
-
-	// Validate entity exists and user has access
-	var hasAccess bool
-	if entityType == "companies" {
-		hasAccess, err = server.userHasAccessToCompany(ctx, int32(entityID), cognitoSub.(string))
-		if err != nil {
-			if err == sql.ErrNoRows {
-				ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
-			return
-		}
-		if !hasAccess {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to upload files to this company"})
-			return
-		}
-	} else if entityType == "contacts" {
-		hasAccess, err = server.userHasAccessToContact(ctx, int32(entityID), cognitoSub.(string))
-		if err != nil {
-			if err == sql.ErrNoRows {
-				ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
-				return
-			}
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
-			return
-		}
-		if !hasAccess {
-			ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to upload files to this contact"})
-			return
-		}
-	} else {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity type, must be 'companies' or 'contacts'"})
-		return
-	}
-
-	// Parse form data
-	sourceType := ctx.PostForm("source_type")
-	link := ctx.PostForm("link")
-
-	// Determine datasource type
-	var datasourceType db.DatasourceType
-	if sourceType == "website" {
-		datasourceType = db.DatasourceTypeWebsite
-	} else if sourceType == "pdf" {
-		datasourceType = db.DatasourceTypePdf
-	} else if sourceType == "word_document" {
-		datasourceType = db.DatasourceTypeWordDocument
-	} else if sourceType == "excel" {
-		datasourceType = db.DatasourceTypeExcel
-	} else if sourceType == "powerpoint" {
-		datasourceType = db.DatasourceTypePowerpoint
-	} else if sourceType == "mp3" {
-		datasourceType = db.DatasourceTypeMp3
-	} else if sourceType == "plain_text" {
-		datasourceType = db.DatasourceTypePlainText
-	} else {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid source type"})
-		return
-	}
-
-	// Handle file upload if provided
-	var fileData []byte
-	var fileName string
-	file, header, err := ctx.Request.FormFile("file")
-	if err == nil {
-		// File was provided
-		defer file.Close()
-		fileName = header.Filename
-		fileData, err = io.ReadAll(file)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
-			return
-		}
-	} else if link == "" {
-		// If no file and no link provided
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Either file or link must be provided"})
-		return
-	}
-
-	// Create datasource
-	datasourceArg := db.CreateDatasourceParams{
-		SourceType: datasourceType,
-		Link:       sql.NullString{String: link, Valid: link != ""},
-		FileData:   fileData,
-		FileName:   sql.NullString{String: fileName, Valid: fileName != ""},
-	}
-
-	datasource, err := server.store.CreateDatasource(ctx, datasourceArg)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create datasource"})
-		return
-	}
-
-	// Associate datasource with entity
-	if entityType == "companies" {
-		associateArg := db.AssociateDatasourceWithCompanyParams{
-			CompanyID:    int32(entityID),
-			DatasourceID: datasource.DatasourceID,
-		}
-
-		err = server.store.AssociateDatasourceWithCompany(ctx, associateArg)
-		if err != nil {
-			// Rollback datasource creation if association fails
-			_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with company"})
-			return
-		}
-
-		ctx.JSON(http.StatusCreated, gin.H{
-			"datasource_id": datasource.DatasourceID,
-			"company_id":    entityID,
-			"source_type":   string(datasource.SourceType),
-			"link":          link,
-			"file_name":     fileName,
-			"message":       "Datasource created and associated with company successfully",
-		})
-	} else {
-		associateArg := db.AssociateDatasourceWithContactParams{
-			ContactID:    int32(entityID),
-			DatasourceID: datasource.DatasourceID,
-		}
-
-		err = server.store.AssociateDatasourceWithContact(ctx, associateArg)
-		if err != nil {
-			// Rollback datasource creation if association fails
-			_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with contact"})
-			return
-		}
-
-		ctx.JSON(http.StatusCreated, gin.H{
-			"datasource_id": datasource.DatasourceID,
-			"contact_id":    entityID,
-			"source_type":   string(datasource.SourceType),
-			"link":          link,
-			"file_name":     fileName,
-			"message":       "Datasource created and associated with contact successfully",
-		})
-	}
-}
-
 // listCompanyDatasources handles listing datasources for a company
 func (server *Server) listCompanyDatasources(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -374,22 +205,22 @@ func (server *Server) listCompanyDatasources(ctx *gin.Context) {
 	companyIDParam := ctx.Param("id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
+	// Check if company exists and the user is a member of its workspace
 	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
 	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view datasources for this company"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to view datasources for this company"))
 		return
 	}
 
@@ -413,13 +244,13 @@ func (server *Server) listCompanyDatasources(ctx *gin.Context) {
 	}
 
 	// Get datasources for company
-	datasources, err := server.store.ListDatasourcesByCompany(ctx, db.ListDatasourcesByCompanyParams{
+	datasources, err := server.store.ListDatasourcesByCompany(ctx.Request.Context(), db.ListDatasourcesByCompanyParams{
 		CompanyID: int32(companyID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasources"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasources", err))
 		return
 	}
 
@@ -437,7 +268,7 @@ func (server *Server) listContactDatasources(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -445,22 +276,22 @@ func (server *Server) listContactDatasources(ctx *gin.Context) {
 	contactIDParam := ctx.Param("id")
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
-	// Check if contact exists and belongs to a company owned by the authenticated user
+	// Check if contact exists and the user is a member of its company's workspace
 	hasAccess, err := server.userHasAccessToContact(ctx, int32(contactID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			apierror.Write(ctx, apierror.NotFound("Contact not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch contact", err))
 		return
 	}
 	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view datasources for this contact"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to view datasources for this contact"))
 		return
 	}
 
@@ -484,13 +315,13 @@ func (server *Server) listContactDatasources(ctx *gin.Context) {
 	}
 
 	// Get datasources for contact
-	datasources, err := server.store.ListDatasourcesByContact(ctx, db.ListDatasourcesByContactParams{
+	datasources, err := server.store.ListDatasourcesByContact(ctx.Request.Context(), db.ListDatasourcesByContactParams{
 		ContactID: int32(contactID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasources"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasources", err))
 		return
 	}
 
@@ -508,7 +339,7 @@ func (server *Server) deleteCompanyDatasource(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -518,52 +349,52 @@ func (server *Server) deleteCompanyDatasource(ctx *gin.Context) {
 
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
 	datasourceID, err := strconv.Atoi(datasourceIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datasource ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
 		return
 	}
 
-	// Check if company exists and belongs to the authenticated user
-	hasAccess, err := server.userHasAccessToCompany(ctx, int32(companyID), cognitoSub.(string))
+	// Check if company exists and the user may edit in its workspace
+	canEdit, err := server.userCanEditCompany(ctx, int32(companyID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+			apierror.Write(ctx, apierror.NotFound("Company not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch company", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete datasources from this company"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to delete datasources from this company"))
 		return
 	}
 
 	// Check if association exists
-	_, err = server.store.GetCompanyDatasourceAssociation(ctx, db.GetCompanyDatasourceAssociationParams{
+	_, err = server.store.GetCompanyDatasourceAssociation(ctx.Request.Context(), db.GetCompanyDatasourceAssociationParams{
 		CompanyID:    int32(companyID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Association between company and datasource not found"})
+			apierror.Write(ctx, apierror.NotFound("Association between company and datasource not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Remove association
-	err = server.store.RemoveDatasourceFromCompany(ctx, db.RemoveDatasourceFromCompanyParams{
+	err = server.store.RemoveDatasourceFromCompany(ctx.Request.Context(), db.RemoveDatasourceFromCompanyParams{
 		CompanyID:    int32(companyID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove datasource from company"})
+		apierror.Write(ctx, apierror.Internal("Failed to remove datasource from company", err))
 		return
 	}
 
@@ -576,7 +407,7 @@ func (server *Server) deleteContactDatasource(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -586,52 +417,52 @@ func (server *Server) deleteContactDatasource(ctx *gin.Context) {
 
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
 	datasourceID, err := strconv.Atoi(datasourceIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datasource ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
 		return
 	}
 
-	// Check if contact exists and belongs to a company owned by the authenticated user
-	hasAccess, err := server.userHasAccessToContact(ctx, int32(contactID), cognitoSub.(string))
+	// Check if contact exists and the user may edit in its company's workspace
+	canEdit, err := server.userCanEditContact(ctx, int32(contactID), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			apierror.Write(ctx, apierror.NotFound("Contact not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch contact", err))
 		return
 	}
-	if !hasAccess {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete datasources from this contact"})
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to delete datasources from this contact"))
 		return
 	}
 
 	// Check if association exists
-	_, err = server.store.GetContactDatasourceAssociation(ctx, db.GetContactDatasourceAssociationParams{
+	_, err = server.store.GetContactDatasourceAssociation(ctx.Request.Context(), db.GetContactDatasourceAssociationParams{
 		ContactID:    int32(contactID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Association between contact and datasource not found"})
+			apierror.Write(ctx, apierror.NotFound("Association between contact and datasource not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Remove association
-	err = server.store.RemoveDatasourceFromContact(ctx, db.RemoveDatasourceFromContactParams{
+	err = server.store.RemoveDatasourceFromContact(ctx.Request.Context(), db.RemoveDatasourceFromContactParams{
 		ContactID:    int32(contactID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove datasource from contact"})
+		apierror.Write(ctx, apierror.Internal("Failed to remove datasource from contact", err))
 		return
 	}
 
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/logging"
+	"github.com/mbaxamb3/nusli/progress"
+)
+
+// Limits for uploaded archives, so a small zip can't expand into something that exhausts the server
+const (
+	maxArchiveUploadSize   = 200 << 20 // Size of the .zip itself
+	maxArchiveEntries      = 500       // Files in the archive, not counting directories
+	maxArchiveEntrySize    = 100 << 20 // Uncompressed size of a single file
+	maxArchiveExpandedSize = 1 << 30   // Uncompressed size of all files together
+	maxArchiveFileName     = 255       // Bytes in a stored file name, the limit of most file systems
+	maxArchiveFileExt      = 16        // Bytes in an extension that is kept when a name is shortened
+)
+
+// Outcomes of an archive entry
+const (
+	archiveEntryCreated = "created" // A datasource was created
+	archiveEntrySkipped = "skipped" // The file was left out, e.g. an unsupported type
+	archiveEntryFailed  = "failed"  // The file should have become a datasource but something went wrong
+)
+
+// archiveEntryResult describes what happened to one file of an uploaded archive
+type archiveEntryResult struct {
+	Path         string `json:"path"`
+	FileName     string `json:"file_name,omitempty"`
+	Size         int64  `json:"size"`
+	Status       string `json:"status"`
+	SourceType   string `json:"source_type,omitempty"`
+	DatasourceID int32  `json:"datasource_id,omitempty"`
+	RunID        string `json:"run_id,omitempty"`
+	EventsURL    string `json:"events_url,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// archiveUploadResponse is the per-file manifest of an archive upload
+type archiveUploadResponse struct {
+	EntityType string               `json:"entity_type"`
+	EntityID   int32                `json:"entity_id"`
+	Created    int                  `json:"created"`
+	Skipped    int                  `json:"skipped"`
+	Failed     int                  `json:"failed"`
+	Files      []archiveEntryResult `json:"files"`
+}
+
+// queuedDatasource is a datasource from an archive waiting to be processed
+type queuedDatasource struct {
+	run        *processingRun
+	datasource db.GetDatasourceByIDRow
+}
+
+// uploadDatasourceArchive handles .zip uploads. Every supported file in the archive becomes
+// a datasource associated with the company, contact or project, and is queued for processing.
+func (server *Server) uploadDatasourceArchive(ctx *gin.Context) {
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	entityType := ctx.Param("entity_type")
+	entityID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid ID format"))
+		return
+	}
+
+	if !server.authorizeUploadTarget(ctx, entityType, int32(entityID), cognitoSub.(string)) {
+		return
+	}
+
+	// Leave room for the multipart framing around the file
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxArchiveUploadSize+1<<20)
+	file, header, err := ctx.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apierror.Write(ctx, apierror.TooLarge(fmt.Sprintf("Archive must be smaller than %d MB", maxArchiveUploadSize>>20)))
+			return
+		}
+		apierror.Write(ctx, apierror.Invalid("A .zip file must be uploaded in the file field"))
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxArchiveUploadSize {
+		apierror.Write(ctx, apierror.TooLarge(fmt.Sprintf("Archive must be smaller than %d MB", maxArchiveUploadSize>>20)))
+		return
+	}
+
+	archive, err := zip.NewReader(file, header.Size)
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("File is not a valid .zip archive"))
+		return
+	}
+
+	fileCount := 0
+	for _, entry := range archive.File {
+		if !entry.FileInfo().IsDir() {
+			fileCount++
+		}
+	}
+	if fileCount > maxArchiveEntries {
+		apierror.Write(ctx, apierror.TooLarge(fmt.Sprintf("Archive contains %d files; at most %d are allowed", fileCount, maxArchiveEntries)))
+		return
+	}
+
+	response := archiveUploadResponse{EntityType: entityType, EntityID: int32(entityID), Files: []archiveEntryResult{}}
+	var queue []queuedDatasource
+	var expanded int64
+	// No entry can be larger than the largest file of any type
+	entryLimit := min(int64(maxArchiveEntrySize), server.uploadLimits.max())
+
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+
+		result := archiveEntryResult{Path: entry.Name, Size: int64(entry.UncompressedSize64)}
+		fileName, reason := archiveEntryFileName(entry.Name)
+		if reason != "" {
+			result.Status, result.Reason = archiveEntrySkipped, reason
+			response.add(result)
+			continue
+		}
+		result.FileName = fileName
+
+		// The declared size can't be trusted, so the real size is checked while reading
+		data, err := readArchiveEntry(entry, entryLimit)
+		if errors.Is(err, errArchiveEntryTooLarge) {
+			result.Status, result.Reason = archiveEntryFailed, fmt.Sprintf("file is larger than %d MB", entryLimit>>20)
+			response.add(result)
+			continue
+		}
+		if err != nil {
+			logging.FromContext(ctx.Request.Context()).Warn("Failed to read archive entry", "path", entry.Name, "error", err)
+			result.Status, result.Reason = archiveEntryFailed, "failed to read file"
+			response.add(result)
+			continue
+		}
+		result.Size = int64(len(data))
+
+		expanded += int64(len(data))
+		if expanded > maxArchiveExpandedSize {
+			result.Status, result.Reason = archiveEntryFailed, fmt.Sprintf("archive expands to more than %d MB; remaining files were not unpacked", maxArchiveExpandedSize>>20)
+			response.add(result)
+			break
+		}
+
+		sourceType, ok := detectDatasourceType(fileName, bytes.NewReader(data), int64(len(data)))
+		if !ok {
+			result.Status, result.Reason = archiveEntrySkipped, "unsupported file type"
+			response.add(result)
+			continue
+		}
+		result.SourceType = string(sourceType)
+
+		// Files in an archive get the same limits as files uploaded on their own
+		if limit := server.uploadLimits[sourceType]; int64(len(data)) > limit {
+			result.Status, result.Reason = archiveEntryFailed, fmt.Sprintf("%s files must be smaller than %d MB", sourceType, limit>>20)
+			response.add(result)
+			continue
+		}
+
+		blobHash, err := server.storeDatasourceFile(ctx.Request.Context(), bytes.NewReader(data))
+		if err != nil {
+			result.Status, result.Reason = archiveEntryFailed, "failed to store file"
+			response.add(result)
+			continue
+		}
+
+		datasource, err := server.store.CreateDatasource(ctx.Request.Context(), db.CreateDatasourceParams{
+			SourceType: sourceType,
+			FileName:   sql.NullString{String: fileName, Valid: true},
+			BlobHash:   blobHash,
+		})
+		if err != nil {
+			server.releaseBlob(ctx.Request.Context(), blobHash.String)
+			result.Status, result.Reason = archiveEntryFailed, "failed to create datasource"
+			response.add(result)
+			continue
+		}
+
+		if err := server.associateDatasource(ctx.Request.Context(), entityType, int32(entityID), datasource.DatasourceID); err != nil {
+			// Rollback datasource creation if association fails
+			server.discardDatasource(ctx.Request.Context(), datasource)
+			result.Status, result.Reason = archiveEntryFailed, "failed to associate datasource"
+			response.add(result)
+			continue
+		}
+
+		result.Status = archiveEntryCreated
+		result.DatasourceID = datasource.DatasourceID
+
+		if isProcessableType(sourceType) {
+			run := server.runs.start(datasource.DatasourceID, cognitoSub.(string))
+			result.RunID = run.ID
+			result.EventsURL = fmt.Sprintf("/api/v1/datasources/%d/runs/%s/events", datasource.DatasourceID, run.ID)
+			queue = append(queue, queuedDatasource{
+				run: run,
+				datasource: db.GetDatasourceByIDRow{
+					DatasourceID: datasource.DatasourceID,
+					SourceType:   datasource.SourceType,
+					Link:         datasource.Link,
+					FileName:     datasource.FileName,
+					CreatedAt:    datasource.CreatedAt,
+					BlobHash:     datasource.BlobHash,
+				},
+			})
+		}
+		response.add(result)
+	}
+
+	// The request context ends with this response, so the queue becomes a background job
+	if len(queue) > 0 {
+		requestCtx := ctx.Request.Context()
+		started := server.lifecycle.Go(func(jobCtx context.Context) {
+			server.processQueuedDatasources(backgroundContext(jobCtx, requestCtx), queue)
+		})
+		if !started {
+			// The datasources are stored and can be processed again later
+			for _, queued := range queue {
+				server.runs.finish(queued.run, progress.Event{Type: progress.EventFailed, Message: errShuttingDown.Error()})
+			}
+		}
+	}
+
+	status := http.StatusCreated
+	if response.Created == 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	ctx.JSON(status, response)
+}
+
+// add records the result of an archive entry and updates the totals
+func (r *archiveUploadResponse) add(result archiveEntryResult) {
+	switch result.Status {
+	case archiveEntryCreated:
+		r.Created++
+	case archiveEntrySkipped:
+		r.Skipped++
+	case archiveEntryFailed:
+		r.Failed++
+	}
+	r.Files = append(r.Files, result)
+}
+
+// processQueuedDatasources processes datasources one after another, so a large archive
+// doesn't start hundreds of scrapers at once
+func (server *Server) processQueuedDatasources(ctx context.Context, queue []queuedDatasource) {
+	for _, queued := range queue {
+		// Errors are reported through the run's events
+		_, _, _ = server.executeProcessingRun(ctx, queued.run, queued.datasource)
+	}
+}
+
+// archiveEntryFileName returns the file name to store for an archive entry, or a reason to skip it.
+// Entries that would escape the extraction directory (zip-slip) are rejected, and only the base
+// name is kept so no stored name can point outside a temporary directory later.
+func archiveEntryFileName(name string) (string, string) {
+	normalized := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(normalized, "/") || (len(normalized) > 1 && normalized[1] == ':') {
+		return "", "absolute paths are not allowed"
+	}
+	for _, segment := range strings.Split(normalized, "/") {
+		if segment == ".." {
+			return "", "paths leaving the archive are not allowed"
+		}
+	}
+	if strings.ContainsRune(normalized, 0) || !utf8.ValidString(normalized) {
+		return "", "invalid file name"
+	}
+
+	base := path.Base(path.Clean(normalized))
+	switch {
+	case strings.HasPrefix(normalized, "__MACOSX/"), strings.HasPrefix(base, "._"),
+		base == ".DS_Store", strings.EqualFold(base, "Thumbs.db"), strings.EqualFold(base, "desktop.ini"):
+		return "", "system file"
+	case strings.HasPrefix(base, "~$"):
+		return "", "Office lock file"
+	case base == "." || base == "":
+		return "", "invalid file name"
+	}
+
+	if len(base) > maxArchiveFileName {
+		// Keep the extension unless it's too long to be one
+		ext := path.Ext(base)
+		if len(ext) > maxArchiveFileExt {
+			ext = ""
+		}
+		base = truncateUTF8(strings.TrimSuffix(base, ext), maxArchiveFileName-len(ext)) + ext
+	}
+	return base, ""
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// errArchiveEntryTooLarge is returned for archive entries over the size limit
+var errArchiveEntryTooLarge = errors.New("archive entry too large")
+
+// readArchiveEntry reads a file from the archive, failing once it exceeds limit bytes
+func readArchiveEntry(entry *zip.File, limit int64) ([]byte, error) {
+	if entry.UncompressedSize64 > uint64(limit) {
+		return nil, errArchiveEntryTooLarge
+	}
+
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, errArchiveEntryTooLarge
+	}
+	return data, nil
+}
+
+// isProcessableType reports whether datasources of a type can be processed into paragraphs
+func isProcessableType(sourceType db.DatasourceType) bool {
+	switch sourceType {
+	case db.DatasourceTypeWordDocument, db.DatasourceTypeEmail:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveEntryFileName(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    string
+		fileName string
+		reason   string
+	}{
+		{name: "nested file", entry: "reports/2025/q1.docx", fileName: "q1.docx"},
+		{name: "windows separators", entry: `reports\q1.docx`, fileName: "q1.docx"},
+		{name: "zip-slip", entry: "../../etc/passwd", reason: "paths leaving the archive are not allowed"},
+		{name: "absolute path", entry: "/etc/passwd", reason: "absolute paths are not allowed"},
+		{name: "drive letter", entry: "C:/Windows/win.ini", reason: "absolute paths are not allowed"},
+		{name: "invalid UTF-8", entry: "bad\xffname.eml", reason: "invalid file name"},
+		{name: "macOS metadata", entry: "__MACOSX/._q1.docx", reason: "system file"},
+		{name: "Office lock file", entry: "~$q1.docx", reason: "Office lock file"},
+		{name: "long name", entry: strings.Repeat("a", 300) + ".docx", fileName: strings.Repeat("a", 250) + ".docx"},
+		{name: "long extension", entry: "a." + strings.Repeat("x", 300), fileName: "a." + strings.Repeat("x", 253)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fileName, reason := archiveEntryFileName(tc.entry)
+			require.Equal(t, tc.reason, reason)
+			require.Equal(t, tc.fileName, fileName)
+		})
+	}
+}
+
+func TestArchiveEntryFileNameKeepsCharacters(t *testing.T) {
+	// 3-byte characters, so the 255-byte cut falls inside one
+	fileName, reason := archiveEntryFileName(strings.Repeat("日本", 60) + ".eml")
+	require.Empty(t, reason)
+	require.True(t, utf8.ValidString(fileName))
+	require.LessOrEqual(t, len(fileName), maxArchiveFileName)
+	require.True(t, strings.HasSuffix(fileName, ".eml"))
+}
+
+func TestReadArchiveEntry(t *testing.T) {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"small.txt": "hello", "large.txt": strings.Repeat("x", 100)} {
+		w, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	for _, entry := range archive.File {
+		data, err := readArchiveEntry(entry, 10)
+		if entry.Name == "large.txt" {
+			require.ErrorIs(t, err, errArchiveEntryTooLarge)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, "hello", string(data))
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mbaxamb3/nusli/blobstore"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/logging"
+)
+
+// storeDatasourceFile puts a file in the blob store and records the blob, returning the hash
+// to reference it by. Identical files share one blob. The content is stored while the blob's
+// row is held, so it can't be released in between; if it's released before a datasource
+// references it, the datasource's foreign key fails instead of pointing at missing content.
+func (server *Server) storeDatasourceFile(ctx context.Context, r io.ReadSeeker) (sql.NullString, error) {
+	blob, err := blobstore.HashContent(r)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	err = server.store.CreateBlobTx(ctx, db.CreateBlobParams{Hash: blob.Hash, Size: blob.Size}, func() error {
+		if err := blobstore.PutBlob(ctx, server.blobs, blob, r); err != nil {
+			return fmt.Errorf("failed to store file: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: blob.Hash, Valid: true}, nil
+}
+
+// openDatasourceFile opens the file of a datasource. Files not yet moved to the blob store
+// are still read from the database.
+func (server *Server) openDatasourceFile(ctx context.Context, datasource db.GetDatasourceByIDRow) (io.ReadSeekCloser, error) {
+	if datasource.BlobHash.Valid {
+		return server.blobs.Get(ctx, blobstore.Key(datasource.BlobHash.String))
+	}
+
+	datasourceFull, err := server.store.GetFullDatasourceByID(ctx, datasource.DatasourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch full datasource data: %w", err)
+	}
+	return nopSeekCloser{bytes.NewReader(datasourceFull.FileData)}, nil
+}
+
+// nopSeekCloser adds a no-op Close to a file held in memory
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+// writeDatasourceFile copies the file of a datasource to a temporary file for the scrapers,
+// which read from disk. The returned function removes it.
+func (server *Server) writeDatasourceFile(ctx context.Context, datasource db.GetDatasourceByIDRow) (string, func(), error) {
+	src, err := server.openDatasourceFile(ctx, datasource)
+	if err != nil {
+		return "", nil, err
+	}
+	defer src.Close()
+
+	dir, err := os.MkdirTemp("", fmt.Sprintf("datasource_%d_", datasource.DatasourceID))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	// Keep the original name, since some scrapers look at the extension
+	name := filepath.Base(datasource.FileName.String)
+	if name == "." || name == "/" || name == "" {
+		name = "file"
+	}
+	path := filepath.Join(dir, name)
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to save temporary file: %w", err)
+	}
+	_, err = io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to save temporary file: %w", err)
+	}
+	return path, cleanup, nil
+}
+
+// discardDatasource deletes a datasource that couldn't be set up, along with its blob
+// when no other datasource uses it
+func (server *Server) discardDatasource(ctx context.Context, datasource db.Datasource) {
+	_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
+	if datasource.BlobHash.Valid {
+		server.releaseBlob(ctx, datasource.BlobHash.String)
+	}
+}
+
+// releaseBlob removes a blob that is no longer referenced. The row and the content are
+// deleted while the row is held, so an upload of the same content waits and stores it again,
+// and no datasource can start referencing the blob in the meantime.
+func (server *Server) releaseBlob(ctx context.Context, hash string) {
+	_, err := server.store.DeleteBlobTx(ctx, hash, func() error {
+		return server.blobs.Delete(ctx, blobstore.Key(hash))
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to release blob", "hash", hash, "error", err)
+	}
+}
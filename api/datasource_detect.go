@@ -0,0 +1,102 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	docscraper "github.com/mbaxamb3/nusli/document_scraper"
+	emailscraper "github.com/mbaxamb3/nusli/email_scraper"
+)
+
+// sniffLength is how much of a file is inspected to recognise text formats
+const sniffLength = 64 << 10
+
+// detectDatasourceType determines the datasource type of a file from its content. The file name is
+// only used to tell legacy Office formats apart, since they share one container format.
+func detectDatasourceType(fileName string, r io.ReaderAt, size int64) (db.DatasourceType, bool) {
+	ext := strings.ToLower(path.Ext(fileName))
+
+	switch format := docscraper.DetectFormatReader(r, size); format {
+	case docscraper.FormatDOCX, docscraper.FormatODT, docscraper.FormatRTF:
+		return db.DatasourceTypeWordDocument, true
+	case docscraper.FormatDOC:
+		switch ext {
+		case ".xls":
+			return db.DatasourceTypeExcel, true
+		case ".ppt", ".pps":
+			return db.DatasourceTypePowerpoint, true
+		case ".msg":
+			return "", false
+		}
+		return db.DatasourceTypeWordDocument, true
+	}
+
+	head := make([]byte, sniffLength)
+	n, _ := r.ReadAt(head, 0)
+	head = head[:n]
+
+	switch {
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return db.DatasourceTypePdf, true
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return officePackageType(r, size)
+	case bytes.HasPrefix(head, []byte("ID3")), len(head) > 1 && head[0] == 0xFF && head[1]&0xE0 == 0xE0:
+		return db.DatasourceTypeMp3, true
+	case emailscraper.LooksLikeEmail(head):
+		return db.DatasourceTypeEmail, true
+	case looksLikeText(head, size > int64(len(head))):
+		return db.DatasourceTypePlainText, true
+	}
+	return "", false
+}
+
+// officePackageType recognises spreadsheets and presentations among Office Open XML packages
+func officePackageType(r io.ReaderAt, size int64) (db.DatasourceType, bool) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", false
+	}
+	for _, f := range archive.File {
+		switch f.Name {
+		case "xl/workbook.xml":
+			return db.DatasourceTypeExcel, true
+		case "ppt/presentation.xml":
+			return db.DatasourceTypePowerpoint, true
+		}
+	}
+	return "", false
+}
+
+// looksLikeText reports whether sample is UTF-8 text without binary control characters.
+// truncated says the sample was cut from a longer file.
+func looksLikeText(sample []byte, truncated bool) bool {
+	if truncated {
+		// Don't fail on a multi-byte character cut in half
+		for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+			sample = sample[:len(sample)-1]
+		}
+	}
+	if len(bytes.TrimSpace(sample)) == 0 || !utf8.Valid(sample) {
+		return false
+	}
+	for _, b := range sample {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' && b != '\f' {
+			return false
+		}
+	}
+	return true
+}
+
+// compatibleTypes reports whether a file detected as detected may be stored as declared.
+// Emails are plain text, so they can be kept as such when the client asks for it.
+func compatibleTypes(declared, detected db.DatasourceType) bool {
+	if declared == detected {
+		return true
+	}
+	return declared == db.DatasourceTypePlainText && detected == db.DatasourceTypeEmail
+}
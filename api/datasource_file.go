@@ -0,0 +1,232 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/blobstore"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+)
+
+// Limits for previews, which return the whole document unless a page is asked for
+const (
+	defaultPreviewLimit = 500
+	maxPreviewLimit     = 2000
+)
+
+// fileContentTypes maps the extensions of supported files to their content type
+var fileContentTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	".doc":  "application/msword",
+	".odt":  "application/vnd.oasis.opendocument.text",
+	".rtf":  "application/rtf",
+	".xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	".xls":  "application/vnd.ms-excel",
+	".pptx": "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	".ppt":  "application/vnd.ms-powerpoint",
+	".mp3":  "audio/mpeg",
+	".txt":  "text/plain; charset=utf-8",
+	".csv":  "text/csv; charset=utf-8",
+	".md":   "text/markdown; charset=utf-8",
+	".eml":  "message/rfc822",
+	".mbox": "application/mbox",
+}
+
+// sourceTypeContentTypes is the content type of a file whose extension says nothing
+var sourceTypeContentTypes = map[db.DatasourceType]string{
+	db.DatasourceTypePdf:        "application/pdf",
+	db.DatasourceTypeMp3:        "audio/mpeg",
+	db.DatasourceTypePlainText:  "text/plain; charset=utf-8",
+	db.DatasourceTypeEmail:      "message/rfc822",
+	db.DatasourceTypeExcel:      "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	db.DatasourceTypePowerpoint: "application/vnd.openxmlformats-officedocument.presentationml.presentation",
+}
+
+// fileContentType returns the content type to serve a datasource file with
+func fileContentType(fileName string, sourceType db.DatasourceType) string {
+	if contentType, ok := fileContentTypes[strings.ToLower(path.Ext(fileName))]; ok {
+		return contentType
+	}
+	if contentType, ok := sourceTypeContentTypes[sourceType]; ok {
+		return contentType
+	}
+	return "application/octet-stream"
+}
+
+// inlineContentTypes can be shown by browsers without risk; everything else is always
+// downloaded, so an uploaded file can never run as a page on the API's origin
+var inlineContentTypes = map[string]bool{
+	"application/pdf":           true,
+	"audio/mpeg":                true,
+	"text/plain; charset=utf-8": true,
+}
+
+// loadDatasource fetches the datasource from the URL. Access was checked by the route's
+// requireDatasourceAccess middleware. It writes the error response itself and returns false
+// when the request should stop.
+func (server *Server) loadDatasource(ctx *gin.Context) (db.GetDatasourceByIDRow, bool) {
+	// Get datasource ID from URL param
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
+		return db.GetDatasourceByIDRow{}, false
+	}
+
+	datasource, err := server.store.GetDatasourceByID(ctx.Request.Context(), int32(id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("Datasource not found"))
+			return db.GetDatasourceByIDRow{}, false
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasource", err))
+		return db.GetDatasourceByIDRow{}, false
+	}
+
+	return datasource, true
+}
+
+// downloadDatasourceFile serves the uploaded file of a datasource. Range requests and
+// conditional requests are supported; ?inline=true shows safe types in the browser.
+func (server *Server) downloadDatasourceFile(ctx *gin.Context) {
+	datasource, ok := server.loadDatasource(ctx)
+	if !ok {
+		return
+	}
+	if datasource.SourceType == db.DatasourceTypeWebsite || !datasource.FileName.Valid {
+		apierror.Write(ctx, apierror.NotFound("Datasource has no file"))
+		return
+	}
+
+	file, err := server.openDatasourceFile(ctx.Request.Context(), datasource)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			apierror.Write(ctx, apierror.NotFound("Datasource file is missing from storage"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to open datasource file", err))
+		return
+	}
+	defer file.Close()
+
+	fileName := path.Base(strings.ReplaceAll(datasource.FileName.String, "\\", "/"))
+	contentType := fileContentType(fileName, datasource.SourceType)
+
+	disposition := "attachment"
+	if ctx.Query("inline") == "true" && inlineContentTypes[contentType] {
+		disposition = "inline"
+	}
+
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": fileName}))
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Cache-Control", "private, no-cache")
+	if datasource.BlobHash.Valid {
+		// Content-addressed, so the hash is a strong validator
+		header.Set("ETag", `"`+datasource.BlobHash.String+`"`)
+	}
+
+	var modified time.Time
+	if datasource.CreatedAt.Valid {
+		modified = datasource.CreatedAt.Time
+	}
+	// ServeContent handles Range, If-Range, If-None-Match and If-Modified-Since
+	http.ServeContent(ctx.Writer, ctx.Request, fileName, modified, file)
+}
+
+// previewParagraph is an extracted paragraph in a preview
+type previewParagraph struct {
+	ParagraphID int32  `json:"paragraph_id"`
+	Content     string `json:"content"`
+}
+
+// previewSection is a run of paragraphs under the same heading
+type previewSection struct {
+	Heading    string             `json:"heading,omitempty"`
+	Paragraphs []previewParagraph `json:"paragraphs"`
+}
+
+// datasourcePreviewResponse shows what the processor captured from a datasource
+type datasourcePreviewResponse struct {
+	DatasourceID   int32            `json:"datasource_id"`
+	SourceType     string           `json:"source_type"`
+	FileName       string           `json:"file_name,omitempty"`
+	Link           string           `json:"link,omitempty"`
+	ParagraphCount int              `json:"paragraph_count"`
+	Offset         int              `json:"offset"`
+	NextOffset     *int             `json:"next_offset,omitempty"` // Set when more paragraphs follow
+	Sections       []previewSection `json:"sections"`
+}
+
+// previewDatasource returns the extracted paragraphs of a datasource in document order,
+// grouped under their headings
+func (server *Server) previewDatasource(ctx *gin.Context) {
+	datasource, ok := server.loadDatasource(ctx)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(defaultPreviewLimit)))
+	if err != nil || limit < 1 {
+		limit = defaultPreviewLimit
+	}
+	if limit > maxPreviewLimit {
+		limit = maxPreviewLimit
+	}
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	// One extra paragraph tells whether there is another page
+	paragraphs, err := server.store.ListParagraphsByDatasource(ctx.Request.Context(), db.ListParagraphsByDatasourceParams{
+		DatasourceID: datasource.DatasourceID,
+		Limit:        int32(limit + 1),
+		Offset:       int32(offset),
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch paragraphs", err))
+		return
+	}
+
+	response := datasourcePreviewResponse{
+		DatasourceID: datasource.DatasourceID,
+		SourceType:   string(datasource.SourceType),
+		FileName:     datasource.FileName.String,
+		Link:         datasource.Link.String,
+		Offset:       offset,
+		Sections:     []previewSection{},
+	}
+	if len(paragraphs) > limit {
+		paragraphs = paragraphs[:limit]
+		next := offset + limit
+		response.NextOffset = &next
+	}
+	response.ParagraphCount = len(paragraphs)
+
+	// Paragraphs are stored in the order they were extracted; consecutive ones with the
+	// same title belong to the same section
+	for _, paragraph := range paragraphs {
+		heading := paragraph.Title.String
+		last := len(response.Sections) - 1
+		if last < 0 || response.Sections[last].Heading != heading {
+			response.Sections = append(response.Sections, previewSection{Heading: heading})
+			last++
+		}
+		response.Sections[last].Paragraphs = append(response.Sections[last].Paragraphs, previewParagraph{
+			ParagraphID: paragraph.ParagraphID,
+			Content:     paragraph.Content,
+		})
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
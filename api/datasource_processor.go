@@ -3,18 +3,28 @@
 package api
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 	docscraper "github.com/mbaxamb3/nusli/document_scraper"
+	emailscraper "github.com/mbaxamb3/nusli/email_scraper"
+	"github.com/mbaxamb3/nusli/logging"
+	"github.com/mbaxamb3/nusli/metrics"
+	"github.com/mbaxamb3/nusli/progress"
 	"github.com/mbaxamb3/nusli/scraper"
+	"github.com/sqlc-dev/pqtype"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // processDatasourceRequest represents the request to process a datasource
@@ -26,16 +36,25 @@ type processDatasourceRequest struct {
 type processDatasourceResponse struct {
 	DatasourceID   int32  `json:"datasource_id"`
 	SourceType     string `json:"source_type"`
+	RunID          string `json:"run_id"`
 	ParagraphCount int    `json:"paragraph_count"`
 	Message        string `json:"message"`
 }
 
-// processDatasourceByID handles processing a specific datasource and generating paragraphs
+// processingRunResponse represents the response when a datasource is processed in the background
+type processingRunResponse struct {
+	DatasourceID int32  `json:"datasource_id"`
+	RunID        string `json:"run_id"`
+	EventsURL    string `json:"events_url"`
+}
+
+// processDatasourceByID handles processing a specific datasource and generating paragraphs.
+// With ?async=true it returns immediately and progress can be followed on the run's event stream.
 func (server *Server) processDatasourceByID(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
-	_, exists := ctx.Get("cognito_sub")
+	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -43,78 +62,98 @@ func (server *Server) processDatasourceByID(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datasource ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
 		return
 	}
 
 	// Get basic datasource info first
-	datasourceBasic, err := server.store.GetDatasourceByID(ctx, int32(id))
+	datasourceBasic, err := server.store.GetDatasourceByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Datasource not found"})
+			apierror.Write(ctx, apierror.NotFound("Datasource not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasource"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasource", err))
 		return
 	}
 
-	var paragraphCount int
-	var message string
-
+	// Make sure the datasource can be processed before starting a run
 	switch datasourceBasic.SourceType {
 	case db.DatasourceTypeWebsite:
-		// Process website using web scraper
 		if !datasourceBasic.Link.Valid {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Website datasource has no link"})
+			apierror.Write(ctx, apierror.Invalid("Website datasource has no link"))
 			return
 		}
 
-		count, msg, err := processWebsiteDatasource(ctx, server.store, datasourceBasic)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to process website",
-				"details": err.Error(),
-			})
-			return
-		}
-		paragraphCount = count
-		message = msg
-
 	case db.DatasourceTypeWordDocument:
-		// Process Word document - need to get the full datasource with file data
-		datasourceFull, err := server.store.GetFullDatasourceByID(ctx, int32(id))
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch full datasource data"})
+		if !datasourceBasic.FileName.Valid {
+			apierror.Write(ctx, apierror.Invalid("Word document datasource has no file name"))
 			return
 		}
 
-		if !datasourceFull.FileName.Valid {
-			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Word document datasource has no file name"})
+	case db.DatasourceTypeEmail:
+		if !datasourceBasic.FileName.Valid {
+			apierror.Write(ctx, apierror.Invalid("Email datasource has no file name"))
 			return
 		}
 
-		count, msg, err := processWordDocumentDatasource(ctx, server.store, datasourceFull)
-		if err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "Failed to process Word document",
-				"details": err.Error(),
-			})
-			return
-		}
-		paragraphCount = count
-		message = msg
-
 	case db.DatasourceTypePdf:
 		// For future implementation
-		ctx.JSON(http.StatusNotImplemented, gin.H{
-			"error": fmt.Sprintf("Processing %s datasources is not yet implemented", datasourceBasic.SourceType),
-		})
+		apierror.Write(ctx, apierror.New(http.StatusNotImplemented, apierror.CodeUnsupportedSourceType,
+			fmt.Sprintf("Processing %s datasources is not yet implemented", datasourceBasic.SourceType)))
 		return
 
 	default:
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("Processing for datasource type %s is not supported", datasourceBasic.SourceType),
+		apierror.Write(ctx, apierror.UnsupportedSourceType(fmt.Sprintf("Processing for datasource type %s is not supported", datasourceBasic.SourceType)))
+		return
+	}
+
+	run := server.runs.start(datasourceBasic.DatasourceID, cognitoSub.(string))
+
+	if ctx.Query("async") == "true" {
+		// The request context ends with this response, so the run becomes a background job
+		requestCtx := ctx.Request.Context()
+		started := server.lifecycle.Go(func(jobCtx context.Context) {
+			server.executeProcessingRun(backgroundContext(jobCtx, requestCtx), run, datasourceBasic)
 		})
+		if !started {
+			server.runs.finish(run, progress.Event{Type: progress.EventFailed, Message: errShuttingDown.Error()})
+			apierror.Write(ctx, apierror.Unavailable("The server is shutting down, try again shortly"))
+			return
+		}
+
+		ctx.JSON(http.StatusAccepted, processingRunResponse{
+			DatasourceID: datasourceBasic.DatasourceID,
+			RunID:        run.ID,
+			EventsURL:    fmt.Sprintf("/api/v1/datasources/%d/runs/%s/events", datasourceBasic.DatasourceID, run.ID),
+		})
+		return
+	}
+
+	paragraphCount, message, err := server.executeProcessingRun(ctx.Request.Context(), run, datasourceBasic)
+	if errors.Is(err, docscraper.ErrConverterUnavailable) {
+		// Legacy .doc files need LibreOffice, which this server doesn't have
+		apierror.Write(ctx, apierror.New(http.StatusNotImplemented, apierror.CodeUnsupportedFileFormat,
+			"Legacy .doc files can't be processed on this server; upload the document as .docx, .odt or .rtf").
+			WithCause(err).With("run_id", run.ID))
+		return
+	}
+	if errors.Is(err, emailscraper.ErrOutlookMessage) {
+		apierror.Write(ctx, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedFileFormat,
+			"Outlook .msg files can't be processed; upload the message as .eml or the folder as mbox").
+			WithCause(err).With("run_id", run.ID))
+		return
+	}
+	if err != nil {
+		errorMessage := "Failed to process website"
+		switch datasourceBasic.SourceType {
+		case db.DatasourceTypeWordDocument:
+			errorMessage = "Failed to process Word document"
+		case db.DatasourceTypeEmail:
+			errorMessage = "Failed to process email"
+		}
+		// The run ID lets the client look up the run's events
+		apierror.Write(ctx, apierror.Internal(errorMessage, err).With("run_id", run.ID))
 		return
 	}
 
@@ -122,145 +161,399 @@ func (server *Server) processDatasourceByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, processDatasourceResponse{
 		DatasourceID:   datasourceBasic.DatasourceID,
 		SourceType:     string(datasourceBasic.SourceType),
+		RunID:          run.ID,
 		ParagraphCount: paragraphCount,
 		Message:        message,
 	})
 }
 
+// executeProcessingRun processes a datasource while relaying the scrapers' progress events to the run
+func (server *Server) executeProcessingRun(ctx context.Context, run *processingRun, datasource db.GetDatasourceByIDRow) (paragraphCount int, message string, err error) {
+	ctx, span := tracer.Start(ctx, "process datasource", trace.WithAttributes(
+		attribute.Int("datasource.id", int(datasource.DatasourceID)),
+		attribute.String("datasource.source_type", string(datasource.SourceType)),
+		attribute.String("run.id", run.ID),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int("paragraphs", paragraphCount))
+		endSpan(span, err)
+	}()
+
+	logger := logging.FromContext(ctx).With(
+		"datasource_id", datasource.DatasourceID,
+		"source_type", datasource.SourceType,
+		"run_id", run.ID,
+	)
+	ctx = logging.WithLogger(ctx, logger)
+	logger.Info("Processing datasource")
+	start := time.Now()
+
+	events := make(chan progress.Event, runEventBuffer)
+	relayed := make(chan struct{})
+	go func() {
+		run.relay(events)
+		close(relayed)
+	}()
+
+	switch datasource.SourceType {
+	case db.DatasourceTypeWebsite:
+		// Process website using web scraper
+		paragraphCount, message, err = processWebsiteDatasource(ctx, server.store, datasource, server.config.ScraperExtractDepth, events)
+
+	case db.DatasourceTypeWordDocument, db.DatasourceTypeEmail:
+		// Files are read from disk by the scrapers
+		var filePath string
+		var cleanup func()
+		filePath, cleanup, err = server.writeDatasourceFile(ctx, datasource)
+		if err != nil {
+			break
+		}
+		defer cleanup()
+
+		if datasource.SourceType == db.DatasourceTypeEmail {
+			paragraphCount, message, err = processEmailDatasource(ctx, server.store, datasource, filePath, run.CognitoSub, events)
+		} else {
+			paragraphCount, message, err = processWordDocumentDatasource(ctx, server.store, datasource, filePath, events)
+		}
+
+	default:
+		err = fmt.Errorf("processing for datasource type %s is not supported", datasource.SourceType)
+	}
+
+	// Let every scraper event reach the run before the final one
+	close(events)
+	<-relayed
+
+	metrics.ObserveProcessing(string(datasource.SourceType), time.Since(start), paragraphCount, err)
+	if err != nil {
+		logger.Error("Failed to process datasource", "paragraphs", paragraphCount, "error", err)
+		server.runs.finish(run, progress.Event{Type: progress.EventFailed, Message: runFailureMessage(datasource.SourceType, err)})
+		return paragraphCount, "", err
+	}
+	logger.Info("Processed datasource", "paragraphs", paragraphCount)
+	server.runs.finish(run, progress.Event{Type: progress.EventCompleted, Count: paragraphCount, Message: message})
+	return paragraphCount, message, nil
+}
+
+// extractionError marks a failure to read a datasource's content, as opposed to a failure
+// of the server, such as an unreachable website or a corrupt file
+type extractionError struct{ err error }
+
+func (e extractionError) Error() string { return e.err.Error() }
+func (e extractionError) Unwrap() error { return e.err }
+
+// runFailureMessage returns the message a failed run reports to clients. Errors can name
+// temporary files, hosts and queries, so they are only logged.
+func runFailureMessage(sourceType db.DatasourceType, err error) string {
+	var extractErr extractionError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "Processing was cancelled before it finished"
+	case errors.As(err, &extractErr):
+		switch sourceType {
+		case db.DatasourceTypeWebsite:
+			return "The website couldn't be scraped"
+		case db.DatasourceTypeWordDocument:
+			return "The document couldn't be read; check that it's a valid Word, OpenDocument or RTF file"
+		case db.DatasourceTypeEmail:
+			return "The email couldn't be read; check that it's a valid .eml file or mbox archive"
+		}
+		return "The datasource's content couldn't be read"
+	}
+	return "Processing failed because of a server error"
+}
+
 // processWebsiteDatasource processes a website datasource using the scraper
-func processWebsiteDatasource(ctx *gin.Context, store *db.Store, datasource db.GetDatasourceByIDRow) (int, string, error) {
+func processWebsiteDatasource(ctx context.Context, store *db.Store, datasource db.GetDatasourceByIDRow, depth int, events chan<- progress.Event) (int, string, error) {
 	// Create enhanced scraper with the link
 	link := datasource.Link.String
-	fmt.Printf("Starting scraper for link: %s\n", link)
+	logger := logging.FromContext(ctx).With("url", link)
+	logger.Info("Starting scraper", "depth", depth)
 
-	enhancedScraper, err := scraper.NewEnhancedScraper(link, 1) // Depth 1 to avoid going too deep
+	enhancedScraper, err := scraper.NewEnhancedScraper(link, depth) // SCRAPER_EXTRACT_DEPTH, 1 by default to avoid going too deep
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to create scraper: %w", err)
 	}
+	enhancedScraper.Progress = events
+	enhancedScraper.Logger = logger
+	enhancedScraper.Context = ctx
+
+	// Only extract the pages the user selected, if they chose any
+	enhancedScraper.Selection, err = loadPageSelection(ctx, store, datasource.DatasourceID)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to load page selection: %w", err)
+	}
 
 	// Extract content
 	err = enhancedScraper.Run()
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to scrape website: %w", err)
+		return 0, "", extractionError{fmt.Errorf("failed to scrape website: %w", err)}
 	}
 
-	fmt.Printf("Extracted %d content items from %s\n", len(enhancedScraper.ContentItems), link)
+	logger.Info("Extracted content", "content_items", len(enhancedScraper.ContentItems), "facts", len(enhancedScraper.Facts))
+	metrics.ObserveCrawl(enhancedScraper.PagesFetched())
+	metrics.ObserveDedup(string(datasource.SourceType), enhancedScraper.ItemsBeforeDedup, enhancedScraper.DuplicatesRemoved)
 
 	// Create paragraphs from extracted content
-	paragraphCount := 0
-	for i, item := range enhancedScraper.ContentItems {
+	var paragraphs []db.CreateParagraphParams
+	paragraphsPerPage := make(map[string]int)
+	for _, item := range enhancedScraper.ContentItems {
 		// Skip items with very short paragraphs
 		if len(item.Paragraph) < 100 {
 			continue
 		}
 
-		// Add debugging to identify truncation
-		paragraphLen := len(item.Paragraph)
-		var lastChars string
-		if paragraphLen > 30 {
-			lastChars = item.Paragraph[paragraphLen-30:]
-		} else {
-			lastChars = item.Paragraph
-		}
-
-		fmt.Printf("Content item #%d: Title='%s', Length=%d, Ends with: '%s'\n",
-			i, item.Title, paragraphLen, lastChars)
-
-		// Check for ellipsis at the end
+		// Scraped text sometimes arrives already cut off with an ellipsis
 		if strings.HasSuffix(item.Paragraph, "...") {
-			fmt.Printf("WARNING: Content item #%d ends with '...', possible truncation detected\n", i)
-
-			// Attempt to fix truncation by replacing any programmatic truncation
-			// This is a temporary solution until you find the source of truncation
-			if paragraphLen > 3 && strings.HasSuffix(item.Paragraph, "...") {
-				// Only remove ellipsis if it's likely added programmatically
-				// You might want to refine this logic based on your findings
-				patternFound := false
-
-				// Check if it matches patterns from truncateString function
-				// For example, looking for text that might have been truncated at a specific limit
-				common_limits := []int{100, 200, 250, 500, 1000, 2000, 5000}
-				for _, limit := range common_limits {
-					if paragraphLen == limit || paragraphLen == limit-3 {
-						patternFound = true
-						break
-					}
-				}
-
-				if patternFound {
-					fmt.Printf("Attempting to fix truncation in item #%d\n", i)
-				}
-			}
+			logger.Debug("Content item may be truncated", "page", item.URL, "title", item.Title, "length", len(item.Paragraph))
 		}
 
-		// Create paragraph
-		paragraphParams := db.CreateParagraphParams{
+		paragraphs = append(paragraphs, db.CreateParagraphParams{
 			DatasourceID: datasource.DatasourceID,
 			Title:        sql.NullString{String: item.Title, Valid: item.Title != ""},
 			MainIdea:     sql.NullString{String: "", Valid: false}, // Could implement a summarizer in the future
 			Content:      item.Paragraph,
-		}
+		})
+		paragraphsPerPage[item.URL]++
+	}
 
-		_, err := store.CreateParagraph(ctx, paragraphParams)
-		if err != nil {
-			return paragraphCount, "", fmt.Errorf("failed to create paragraph: %w", err)
-		}
-		paragraphCount++
+	// Replace the paragraphs of an earlier run, e.g. a refresh of the stored selection
+	paragraphCount, err := saveParagraphs(ctx, store, db.ReplaceParagraphsTxParams{DatasourceID: datasource.DatasourceID, Paragraphs: paragraphs})
+	if err != nil {
+		return 0, "", err
+	}
+
+	logger.Info("Saved paragraphs", "paragraphs", paragraphCount)
+
+	// Keep the crawled site tree so users can see what was scraped
+	if err := saveWebsiteSiteTree(ctx, store, datasource.DatasourceID, enhancedScraper.Scraper, paragraphsPerPage); err != nil {
+		return paragraphCount, "", err
+	}
+
+	// Replace previously extracted facts with the ones from this run
+	factCount, err := saveWebsiteFacts(ctx, store, datasource.DatasourceID, enhancedScraper.Facts)
+	if err != nil {
+		return paragraphCount, "", err
 	}
 
-	fmt.Printf("Saved %d paragraphs to database from %s\n", paragraphCount, link)
-	message := fmt.Sprintf("Successfully extracted %d paragraphs from %s", paragraphCount, link)
+	message := fmt.Sprintf("Successfully extracted %d paragraphs and %d facts from %s", paragraphCount, factCount, link)
 	return paragraphCount, message, nil
 }
 
-// processWordDocumentDatasource processes a Word document datasource
-func processWordDocumentDatasource(ctx *gin.Context, store *db.Store, datasource db.Datasource) (int, string, error) {
-	// Create a temporary file to save the Word document
-	tempDir := os.TempDir()
-	tempFile := filepath.Join(tempDir, fmt.Sprintf("doc_%d_%s", datasource.DatasourceID, datasource.FileName.String))
+// saveParagraphs replaces the paragraphs of a datasource and returns how many were saved
+func saveParagraphs(ctx context.Context, store *db.Store, arg db.ReplaceParagraphsTxParams) (count int, err error) {
+	ctx, span := tracer.Start(ctx, "save paragraphs")
+	defer func() {
+		span.SetAttributes(attribute.Int("paragraphs", count))
+		endSpan(span, err)
+	}()
+
+	paragraphs, err := store.ReplaceParagraphsTx(ctx, arg)
+	if err != nil {
+		return 0, err
+	}
+	return len(paragraphs), nil
+}
+
+// saveWebsiteSiteTree stores the crawled site tree annotated with the paragraphs saved per page
+func saveWebsiteSiteTree(ctx context.Context, store *db.Store, datasourceID int32, siteScraper *scraper.Scraper, paragraphsPerPage map[string]int) error {
+	tree, err := siteScraper.BuildSiteTree()
+	if err != nil {
+		return fmt.Errorf("failed to build site tree: %w", err)
+	}
+	tree.SetParagraphCounts(paragraphsPerPage)
+
+	encoded, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("failed to encode site tree: %w", err)
+	}
 
-	// Save file data to temporary file
-	err := os.WriteFile(tempFile, datasource.FileData, 0644)
+	_, err = store.UpsertDatasourceSiteTree(ctx, db.UpsertDatasourceSiteTreeParams{
+		DatasourceID: datasourceID,
+		Tree:         encoded,
+		PageCount:    int32(len(siteScraper.Data)),
+	})
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to save temporary file: %w", err)
+		return fmt.Errorf("failed to save site tree: %w", err)
+	}
+	return nil
+}
+
+// saveWebsiteFacts stores the structured facts found while scraping a website datasource
+func saveWebsiteFacts(ctx context.Context, store *db.Store, datasourceID int32, facts []scraper.Fact) (int, error) {
+	if err := store.DeleteFactsByDatasource(ctx, datasourceID); err != nil {
+		return 0, fmt.Errorf("failed to clear previous facts: %w", err)
+	}
+
+	factCount := 0
+	for _, fact := range facts {
+		attributes := pqtype.NullRawMessage{}
+		if len(fact.Attributes) > 0 {
+			encoded, err := json.Marshal(fact.Attributes)
+			if err != nil {
+				return factCount, fmt.Errorf("failed to encode fact attributes: %w", err)
+			}
+			attributes = pqtype.NullRawMessage{RawMessage: encoded, Valid: true}
+		}
+
+		_, err := store.CreateDatasourceFact(ctx, db.CreateDatasourceFactParams{
+			DatasourceID: datasourceID,
+			FactType:     db.FactType(fact.Type),
+			FactKey:      fact.Key,
+			Value:        fact.Value,
+			Attributes:   attributes,
+			Source:       sql.NullString{String: fact.Source, Valid: fact.Source != ""},
+			SourceUrl:    sql.NullString{String: fact.URL, Valid: fact.URL != ""},
+		})
+		if err != nil {
+			return factCount, fmt.Errorf("failed to create fact: %w", err)
+		}
+		factCount++
 	}
-	defer os.Remove(tempFile) // Clean up
 
+	return factCount, nil
+}
+
+// processWordDocumentDatasource processes a Word document datasource. The file may be
+// .docx, legacy .doc, OpenDocument or RTF; the scraper detects the format from its content.
+func processWordDocumentDatasource(ctx context.Context, store *db.Store, datasource db.GetDatasourceByIDRow, filePath string, events chan<- progress.Event) (int, string, error) {
 	// Create document scraper
-	docScraper, err := docscraper.NewDocumentScraper(tempFile)
+	docScraper, err := docscraper.NewDocumentScraper(filePath)
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to create document scraper: %w", err)
 	}
+	docScraper.Progress = events
+	docScraper.Logger = logging.FromContext(ctx)
+	docScraper.Context = ctx
+	docScraper.Name = datasource.FileName.String
 
 	// Extract content
 	err = docScraper.Run()
 	if err != nil {
-		return 0, "", fmt.Errorf("failed to scrape document: %w", err)
+		return 0, "", extractionError{fmt.Errorf("failed to scrape document: %w", err)}
 	}
+	metrics.ObserveDedup(string(datasource.SourceType), docScraper.ItemsBeforeDedup, docScraper.DuplicatesRemoved)
 
 	// Create paragraphs from extracted content
-	paragraphCount := 0
+	var paragraphs []db.CreateParagraphParams
 	for _, item := range docScraper.ContentItems {
-		// Skip items with very short paragraphs
-		if len(item.Paragraph) < 100 {
+		// Skip very short body paragraphs; lists, tables, notes and comments are kept whole
+		if item.Kind == docscraper.ItemParagraph && len(item.Paragraph) < 100 {
 			continue
 		}
 
-		// Create paragraph
-		paragraphParams := db.CreateParagraphParams{
+		// Notes and comments keep their annotation so the paragraph reads on its own
+		content := item.Paragraph
+		if item.Annotation != "" {
+			content = fmt.Sprintf("[%s] %s", item.Annotation, item.Paragraph)
+		}
+
+		paragraphs = append(paragraphs, db.CreateParagraphParams{
 			DatasourceID: datasource.DatasourceID,
 			Title:        sql.NullString{String: item.Title, Valid: item.Title != ""},
 			MainIdea:     sql.NullString{String: "", Valid: false}, // Could implement a summarizer in the future
+			Content:      content,
+		})
+	}
+
+	paragraphCount, err := saveParagraphs(ctx, store, db.ReplaceParagraphsTxParams{DatasourceID: datasource.DatasourceID, Paragraphs: paragraphs})
+	if err != nil {
+		return 0, "", err
+	}
+
+	message := fmt.Sprintf("Successfully extracted %d paragraphs from %s document %s", paragraphCount, docScraper.Format, datasource.FileName.String)
+	return paragraphCount, message, nil
+}
+
+// processEmailDatasource processes an .eml file or mbox archive. Every paragraph keeps the
+// sender, recipients and date of its message, and senders that match one of the user's
+// contacts get the datasource linked to that contact.
+func processEmailDatasource(ctx context.Context, store *db.Store, datasource db.GetDatasourceByIDRow, filePath string, cognitoSub string, events chan<- progress.Event) (int, string, error) {
+	emailScraper, err := emailscraper.NewEmailScraper(filePath)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create email scraper: %w", err)
+	}
+	emailScraper.Progress = events
+	emailScraper.Name = datasource.FileName.String
+	emailScraper.Logger = logging.FromContext(ctx).With("file_name", datasource.FileName.String)
+
+	if err := emailScraper.Run(); err != nil {
+		return 0, "", extractionError{fmt.Errorf("failed to scrape email: %w", err)}
+	}
+
+	saveArg := db.ReplaceParagraphsTxParams{
+		DatasourceID:  datasource.DatasourceID,
+		Paragraphs:    make([]db.CreateParagraphParams, 0, len(emailScraper.ContentItems)),
+		EmailMetadata: make([]db.CreateParagraphEmailMetadataParams, 0, len(emailScraper.ContentItems)),
+	}
+	for _, item := range emailScraper.ContentItems {
+		saveArg.Paragraphs = append(saveArg.Paragraphs, db.CreateParagraphParams{
+			DatasourceID: datasource.DatasourceID,
+			Title:        sql.NullString{String: item.Title, Valid: item.Title != ""},
+			MainIdea:     sql.NullString{String: "", Valid: false},
 			Content:      item.Paragraph,
+		})
+		saveArg.EmailMetadata = append(saveArg.EmailMetadata, db.CreateParagraphEmailMetadataParams{
+			MessageID:   sql.NullString{String: item.MessageID, Valid: item.MessageID != ""},
+			Subject:     sql.NullString{String: item.Title, Valid: item.Title != ""},
+			SenderName:  sql.NullString{String: item.SenderName, Valid: item.SenderName != ""},
+			SenderEmail: item.SenderEmail,
+			Recipients:  item.Recipients,
+			SentAt:      sql.NullTime{Time: item.Date, Valid: !item.Date.IsZero()},
+		})
+	}
+
+	paragraphCount, err := saveParagraphs(ctx, store, saveArg)
+	if err != nil {
+		return 0, "", err
+	}
+
+	linkedCount, err := linkEmailSendersToContacts(ctx, store, datasource.DatasourceID, cognitoSub, emailScraper.SenderEmails())
+	if err != nil {
+		return paragraphCount, "", err
+	}
+
+	message := fmt.Sprintf("Successfully extracted %d paragraphs from %d messages in %s and linked %d contacts",
+		paragraphCount, len(emailScraper.Messages), datasource.FileName.String, linkedCount)
+	return paragraphCount, message, nil
+}
+
+// linkEmailSendersToContacts associates an email datasource with the contacts the user may
+// edit whose address matches one of the senders. It returns the number of new associations.
+func linkEmailSendersToContacts(ctx context.Context, store *db.Store, datasourceID int32, cognitoSub string, senders []string) (int, error) {
+	if len(senders) == 0 {
+		return 0, nil
+	}
+
+	contacts, err := store.ListUserContactsByEmails(ctx, db.ListUserContactsByEmailsParams{
+		CognitoSub: cognitoSub,
+		Emails:     senders,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up contacts by email: %w", err)
+	}
+
+	linked := 0
+	for _, contact := range contacts {
+		_, err := store.GetContactDatasourceAssociation(ctx, db.GetContactDatasourceAssociationParams{
+			ContactID:    contact.ContactID,
+			DatasourceID: datasourceID,
+		})
+		if err == nil {
+			continue // Already linked
+		}
+		if err != sql.ErrNoRows {
+			return linked, fmt.Errorf("failed to check contact association: %w", err)
 		}
 
-		_, err := store.CreateParagraph(ctx, paragraphParams)
+		err = store.AssociateDatasourceWithContact(ctx, db.AssociateDatasourceWithContactParams{
+			ContactID:    contact.ContactID,
+			DatasourceID: datasourceID,
+		})
 		if err != nil {
-			return paragraphCount, "", fmt.Errorf("failed to create paragraph: %w", err)
+			return linked, fmt.Errorf("failed to link contact %d: %w", contact.ContactID, err)
 		}
-		paragraphCount++
+		linked++
 	}
-
-	message := fmt.Sprintf("Successfully extracted %d paragraphs from document %s", paragraphCount, datasource.FileName.String)
-	return paragraphCount, message, nil
+	return linked, nil
 }
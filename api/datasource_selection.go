@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/logging"
+	"github.com/mbaxamb3/nusli/scraper"
+)
+
+// pageSelectionRequest represents the pages and subtrees a user wants extracted
+type pageSelectionRequest struct {
+	URLs     []string `json:"urls"`
+	Subtrees []string `json:"subtrees"`
+}
+
+// pageSelectionResponse represents the stored page selection of a website datasource
+type pageSelectionResponse struct {
+	DatasourceID int32                  `json:"datasource_id"`
+	AllPages     bool                   `json:"all_pages"`
+	Selection    *scraper.PageSelection `json:"selection,omitempty"`
+}
+
+// pageDiscoveryResponse represents the candidate pages found on a website
+type pageDiscoveryResponse struct {
+	DatasourceID int32                  `json:"datasource_id"`
+	URLs         []string               `json:"urls"`
+	Tree         *scraper.TreeNode      `json:"tree"`
+	Selection    *scraper.PageSelection `json:"selection,omitempty"`
+}
+
+// discoverDatasourcePages handles requests to list the pages of a website before extraction
+func (server *Server) discoverDatasourcePages(ctx *gin.Context) {
+	// Crawl depth limits come from SCRAPER_DISCOVERY_DEPTH and SCRAPER_MAX_DISCOVERY_DEPTH
+	depth, maxDepth := server.config.ScraperDiscoveryDepth, server.config.ScraperMaxDiscoveryDepth
+	if depthParam := ctx.Query("depth"); depthParam != "" {
+		parsed, err := strconv.Atoi(depthParam)
+		if err != nil || parsed < 1 || parsed > maxDepth {
+			apierror.Write(ctx, apierror.Invalid(fmt.Sprintf("Depth must be between 1 and %d", maxDepth)))
+			return
+		}
+		depth = parsed
+	}
+
+	datasource, ok := server.loadWebsiteDatasource(ctx)
+	if !ok {
+		return
+	}
+
+	siteScraper, err := scraper.NewScraper(datasource.Link.String, depth)
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Datasource has an invalid link"))
+		return
+	}
+	// The crawl stops when the client goes away and logs with the request's ID
+	siteScraper.Context = ctx.Request.Context()
+	siteScraper.Logger = logging.FromContext(ctx.Request.Context()).With(
+		"datasource_id", datasource.DatasourceID,
+		"source_type", datasource.SourceType,
+		"url", datasource.Link.String,
+	)
+
+	tree, err := siteScraper.Discover()
+	if err != nil {
+		apierror.Write(ctx, apierror.New(http.StatusBadGateway, apierror.CodeUpstreamFailed, "Failed to crawl website").WithCause(err))
+		return
+	}
+
+	selection, err := loadPageSelection(ctx.Request.Context(), server.store, datasource.DatasourceID)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch page selection", err))
+		return
+	}
+
+	urls := make([]string, 0, len(siteScraper.LinksToVisit))
+	for link := range siteScraper.LinksToVisit {
+		urls = append(urls, link)
+	}
+	sort.Strings(urls)
+
+	ctx.JSON(http.StatusOK, pageDiscoveryResponse{
+		DatasourceID: datasource.DatasourceID,
+		URLs:         urls,
+		Tree:         tree,
+		Selection:    selection,
+	})
+}
+
+// getDatasourcePageSelection handles requests for the stored page selection of a website datasource
+func (server *Server) getDatasourcePageSelection(ctx *gin.Context) {
+	datasource, ok := server.loadWebsiteDatasource(ctx)
+	if !ok {
+		return
+	}
+
+	selection, err := loadPageSelection(ctx.Request.Context(), server.store, datasource.DatasourceID)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch page selection", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageSelectionResponse{
+		DatasourceID: datasource.DatasourceID,
+		AllPages:     selection.IsEmpty(),
+		Selection:    selection,
+	})
+}
+
+// updateDatasourcePageSelection handles requests to choose which pages of a website are extracted.
+// The selection is used by every later processing run; an empty selection extracts all pages again.
+func (server *Server) updateDatasourcePageSelection(ctx *gin.Context) {
+	datasource, ok := server.loadWebsiteDatasource(ctx)
+	if !ok {
+		return
+	}
+
+	var req pageSelectionRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+
+	selection := &scraper.PageSelection{URLs: req.URLs, Subtrees: req.Subtrees}
+	if err := selection.Validate(datasource.Link.String); err != nil {
+		// The selection's errors are written for users
+		apierror.Write(ctx, apierror.Invalid("Invalid page selection: "+err.Error()))
+		return
+	}
+
+	if selection.IsEmpty() {
+		if err := server.store.DeleteDatasourcePageSelection(ctx.Request.Context(), datasource.DatasourceID); err != nil {
+			apierror.Write(ctx, apierror.Internal("Failed to clear page selection", err))
+			return
+		}
+		ctx.JSON(http.StatusOK, pageSelectionResponse{DatasourceID: datasource.DatasourceID, AllPages: true})
+		return
+	}
+
+	encoded, err := json.Marshal(selection)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to encode page selection", err))
+		return
+	}
+
+	_, err = server.store.UpsertDatasourcePageSelection(ctx.Request.Context(), db.UpsertDatasourcePageSelectionParams{
+		DatasourceID: datasource.DatasourceID,
+		Selection:    encoded,
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to save page selection", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, pageSelectionResponse{
+		DatasourceID: datasource.DatasourceID,
+		AllPages:     false,
+		Selection:    selection,
+	})
+}
+
+// loadPageSelection returns the stored page selection of a datasource, or nil when every page is extracted
+func loadPageSelection(ctx context.Context, store *db.Store, datasourceID int32) (*scraper.PageSelection, error) {
+	stored, err := store.GetDatasourcePageSelection(ctx, datasourceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var selection scraper.PageSelection
+	if err := json.Unmarshal(stored.Selection, &selection); err != nil {
+		return nil, fmt.Errorf("invalid page selection: %w", err)
+	}
+	return &selection, nil
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/scraper"
+)
+
+// dotContentType is the media type for Graphviz DOT output
+const dotContentType = "text/vnd.graphviz"
+
+// siteTreeResponse represents the crawled site tree of a website datasource
+type siteTreeResponse struct {
+	DatasourceID int32             `json:"datasource_id"`
+	PageCount    int32             `json:"page_count"`
+	UpdatedAt    time.Time         `json:"updated_at,omitempty"`
+	Tree         *scraper.TreeNode `json:"tree"`
+}
+
+// getDatasourceSiteTree handles requests for the site tree of a website datasource.
+// The tree is returned as nested JSON, or as Graphviz DOT with ?format=dot.
+func (server *Server) getDatasourceSiteTree(ctx *gin.Context) {
+	format := strings.ToLower(ctx.DefaultQuery("format", "json"))
+	if format != "json" && format != "dot" {
+		apierror.Write(ctx, apierror.Invalid("Format must be json or dot"))
+		return
+	}
+
+	datasource, ok := server.loadWebsiteDatasource(ctx)
+	if !ok {
+		return
+	}
+
+	siteTree, err := server.store.GetDatasourceSiteTree(ctx.Request.Context(), datasource.DatasourceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("Datasource has not been crawled yet"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch site tree", err))
+		return
+	}
+
+	var tree scraper.TreeNode
+	if err := json.Unmarshal(siteTree.Tree, &tree); err != nil {
+		apierror.Write(ctx, apierror.Internal("Stored site tree is invalid", err))
+		return
+	}
+
+	if format == "dot" {
+		ctx.Data(http.StatusOK, dotContentType+"; charset=utf-8", []byte(scraper.ExportSiteTreeDOT(&tree)))
+		return
+	}
+
+	response := siteTreeResponse{
+		DatasourceID: siteTree.DatasourceID,
+		PageCount:    siteTree.PageCount,
+		Tree:         &tree,
+	}
+	if siteTree.UpdatedAt.Valid {
+		response.UpdatedAt = siteTree.UpdatedAt.Time
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// loadWebsiteDatasource fetches the website datasource from the URL. It writes the error
+// response itself and returns false when the request should stop.
+func (server *Server) loadWebsiteDatasource(ctx *gin.Context) (db.GetDatasourceByIDRow, bool) {
+	datasource, ok := server.loadDatasource(ctx)
+	if !ok {
+		return db.GetDatasourceByIDRow{}, false
+	}
+
+	if datasource.SourceType != db.DatasourceTypeWebsite {
+		apierror.Write(ctx, apierror.UnsupportedSourceType("Only website datasources have crawled pages"))
+		return db.GetDatasourceByIDRow{}, false
+	}
+
+	return datasource, true
+}
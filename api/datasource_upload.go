@@ -0,0 +1,425 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+)
+
+// uploadSourceTypes maps the source_type form field to datasource types
+var uploadSourceTypes = map[string]db.DatasourceType{
+	"website":       db.DatasourceTypeWebsite,
+	"pdf":           db.DatasourceTypePdf,
+	"word_document": db.DatasourceTypeWordDocument,
+	"excel":         db.DatasourceTypeExcel,
+	"powerpoint":    db.DatasourceTypePowerpoint,
+	"mp3":           db.DatasourceTypeMp3,
+	"plain_text":    db.DatasourceTypePlainText,
+	"email":         db.DatasourceTypeEmail,
+}
+
+// uploadLimits is the largest file accepted for each datasource type, in bytes
+type uploadLimits map[db.DatasourceType]int64
+
+// defaultUploadLimits returns the limits used for types without a configured limit
+func defaultUploadLimits() uploadLimits {
+	return uploadLimits{
+		db.DatasourceTypePdf:          50 << 20,
+		db.DatasourceTypeWordDocument: 50 << 20,
+		db.DatasourceTypeExcel:        50 << 20,
+		db.DatasourceTypePowerpoint:   100 << 20,
+		db.DatasourceTypeMp3:          200 << 20,
+		db.DatasourceTypePlainText:    10 << 20,
+		db.DatasourceTypeEmail:        256 << 20,
+	}
+}
+
+// parseUploadLimits reads limits such as "pdf=50MB,mp3=500MB" on top of the defaults
+func parseUploadLimits(spec string) (uploadLimits, error) {
+	limits := defaultUploadLimits()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid upload limit %q, expected type=size", entry)
+		}
+		sourceType, ok := uploadSourceTypes[strings.TrimSpace(name)]
+		if !ok || sourceType == db.DatasourceTypeWebsite {
+			return nil, fmt.Errorf("invalid upload limit %q: unknown file type %q", entry, name)
+		}
+		size, err := parseByteSize(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upload limit %q: %v", entry, err)
+		}
+		limits[sourceType] = size
+	}
+	return limits, nil
+}
+
+// parseByteSize parses sizes like "512KB", "50MB", "1GB" or a plain number of bytes
+func parseByteSize(value string) (int64, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, multiplier = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("size must be a positive number of bytes, KB, MB or GB")
+	}
+	return n * multiplier, nil
+}
+
+// max returns the largest limit of any type, which bounds every upload before its type is known
+func (l uploadLimits) max() int64 {
+	var largest int64
+	for _, limit := range l {
+		if limit > largest {
+			largest = limit
+		}
+	}
+	return largest
+}
+
+// errUploadTooLarge is returned when an upload is larger than any file that would be accepted
+var errUploadTooLarge = errors.New("upload too large")
+
+// uploadForm is a parsed upload request. The file, if any, has been streamed to a temporary
+// file, so a large upload never has to fit in memory before its type and size are checked.
+type uploadForm struct {
+	fields   map[string]string
+	file     *os.File
+	fileName string
+	size     int64
+}
+
+// close removes the temporary file
+func (f *uploadForm) close() {
+	if f.file != nil {
+		f.file.Close()
+		os.Remove(f.file.Name())
+	}
+}
+
+// readUploadForm reads the form fields of an upload and streams the file field to disk,
+// stopping as soon as the file exceeds maxFileSize. Forms without a file may be urlencoded.
+// Errors other than errUploadTooLarge are API errors to return as they are.
+func readUploadForm(ctx *gin.Context, maxFileSize int64) (*uploadForm, error) {
+	form := &uploadForm{fields: make(map[string]string)}
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		if err := ctx.Request.ParseForm(); err != nil {
+			return nil, uploadReadError(err)
+		}
+		for key := range ctx.Request.PostForm {
+			form.fields[key] = ctx.Request.PostForm.Get(key)
+		}
+		return form, nil
+	}
+
+	reader, err := ctx.Request.MultipartReader()
+	if err != nil {
+		return nil, apierror.Invalid("The upload isn't a valid multipart form").WithCause(err)
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return form, nil
+		}
+		if err != nil {
+			form.close()
+			return nil, uploadReadError(err)
+		}
+
+		if part.FormName() == "file" && part.FileName() != "" {
+			if form.file != nil {
+				form.close()
+				return nil, apierror.Invalid("Only one file can be uploaded at a time")
+			}
+			if err := form.streamFile(part, part.FileName(), maxFileSize); err != nil {
+				form.close()
+				return nil, err
+			}
+			continue
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, 64<<10))
+		if err != nil {
+			form.close()
+			return nil, uploadReadError(err)
+		}
+		form.fields[part.FormName()] = string(value)
+	}
+}
+
+// streamFile copies an uploaded file to a temporary file
+func (f *uploadForm) streamFile(r io.Reader, fileName string, maxFileSize int64) error {
+	tmp, err := os.CreateTemp("", "upload-*")
+	if err != nil {
+		return apierror.Internal("Failed to store upload", err)
+	}
+	f.file = tmp
+	f.fileName = fileName
+
+	f.size, err = io.Copy(tmp, io.LimitReader(r, maxFileSize+1))
+	if err != nil {
+		return uploadReadError(err)
+	}
+	if f.size > maxFileSize {
+		return errUploadTooLarge
+	}
+	return nil
+}
+
+// uploadReadError turns the error of a request body cut off by http.MaxBytesReader into
+// errUploadTooLarge; other read errors mean a broken request
+func uploadReadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return errUploadTooLarge
+	}
+	return apierror.Invalid("The upload couldn't be read").WithCause(err)
+}
+
+// supportedUploadTypes lists the datasource types that can be uploaded as files
+func supportedUploadTypes() []string {
+	var types []string
+	for name, sourceType := range uploadSourceTypes {
+		if sourceType != db.DatasourceTypeWebsite {
+			types = append(types, name)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// uploadDatasource handles file and link uploads for companies, contacts and projects.
+// The type of an uploaded file is detected from its content; a declared source_type that doesn't
+// match is rejected, or replaced by the detected type when auto_correct is true.
+func (server *Server) uploadDatasource(ctx *gin.Context) {
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	entityType := ctx.Param("entity_type")
+	entityID, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid ID format"))
+		return
+	}
+
+	// Validate entity exists and user has access
+	if !server.authorizeUploadTarget(ctx, entityType, int32(entityID), cognitoSub.(string)) {
+		return
+	}
+
+	// Leave room for the multipart framing and form fields around the file
+	maxFileSize := server.uploadLimits.max()
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxFileSize+1<<20)
+	form, err := readUploadForm(ctx, maxFileSize)
+	if err != nil {
+		if errors.Is(err, errUploadTooLarge) {
+			apierror.Write(ctx, apierror.TooLarge(fmt.Sprintf("File must be smaller than %d MB", maxFileSize>>20)))
+			return
+		}
+		apierror.Write(ctx, err)
+		return
+	}
+	defer form.close()
+
+	sourceType := form.fields["source_type"]
+	link := form.fields["link"]
+
+	var declaredType db.DatasourceType
+	if sourceType != "" {
+		var ok bool
+		declaredType, ok = uploadSourceTypes[sourceType]
+		if !ok {
+			apierror.Write(ctx, apierror.UnsupportedSourceType("Invalid source type").With("supported_types", supportedUploadTypes()))
+			return
+		}
+	}
+
+	datasourceType := declaredType
+	var blobHash sql.NullString
+	var fileName string
+	response := gin.H{}
+
+	if form.file != nil {
+		fileName = form.fileName
+
+		detectedType, ok := detectDatasourceType(fileName, form.file, form.size)
+		if !ok {
+			apierror.Write(ctx, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedFileFormat, "Unsupported file type").
+				With("supported_types", supportedUploadTypes()))
+			return
+		}
+
+		corrected := false
+		if declaredType == "" {
+			datasourceType = detectedType
+		} else if !compatibleTypes(declaredType, detectedType) {
+			if form.fields["auto_correct"] != "true" {
+				message := fmt.Sprintf("File content is %s, not %s; send auto_correct=true to store it as %s", detectedType, declaredType, detectedType)
+				apierror.Write(ctx, apierror.New(http.StatusUnsupportedMediaType, apierror.CodeUnsupportedFileFormat, message).
+					With("declared_type", string(declaredType)).
+					With("detected_type", string(detectedType)))
+				return
+			}
+			datasourceType = detectedType
+			corrected = true
+		}
+
+		if limit := server.uploadLimits[datasourceType]; form.size > limit {
+			apierror.Write(ctx, apierror.TooLarge(fmt.Sprintf("%s files must be smaller than %d MB", datasourceType, limit>>20)))
+			return
+		}
+
+		// The file goes from disk to the blob store without being read into memory
+		blobHash, err = server.storeDatasourceFile(ctx.Request.Context(), io.NewSectionReader(form.file, 0, form.size))
+		if err != nil {
+			apierror.Write(ctx, apierror.Internal("Failed to store file", err))
+			return
+		}
+
+		response["detected_type"] = string(detectedType)
+		response["type_corrected"] = corrected
+	} else if link == "" {
+		// If no file and no link provided
+		apierror.Write(ctx, apierror.Invalid("Either file or link must be provided"))
+		return
+	} else if declaredType == "" {
+		apierror.Write(ctx, apierror.Invalid("source_type is required when uploading a link"))
+		return
+	}
+
+	// Create datasource
+	datasourceArg := db.CreateDatasourceParams{
+		SourceType: datasourceType,
+		Link:       sql.NullString{String: link, Valid: link != ""},
+		FileName:   sql.NullString{String: fileName, Valid: fileName != ""},
+		BlobHash:   blobHash,
+	}
+
+	datasource, err := server.store.CreateDatasource(ctx.Request.Context(), datasourceArg)
+	if err != nil {
+		if blobHash.Valid {
+			server.releaseBlob(ctx.Request.Context(), blobHash.String)
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to create datasource", err))
+		return
+	}
+
+	// Associate datasource with entity
+	name := uploadTargetName(entityType)
+	if err := server.associateDatasource(ctx.Request.Context(), entityType, int32(entityID), datasource.DatasourceID); err != nil {
+		// Rollback datasource creation if association fails
+		server.discardDatasource(ctx.Request.Context(), datasource)
+		apierror.Write(ctx, apierror.Internal(fmt.Sprintf("Failed to associate datasource with %s", name), err))
+		return
+	}
+
+	response["datasource_id"] = datasource.DatasourceID
+	response[name+"_id"] = entityID
+	response["source_type"] = string(datasource.SourceType)
+	response["link"] = link
+	response["file_name"] = fileName
+	response["message"] = fmt.Sprintf("Datasource created and associated with %s successfully", name)
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// uploadTargetName returns the singular name of an upload target, e.g. "company" for "companies"
+func uploadTargetName(entityType string) string {
+	switch entityType {
+	case "companies":
+		return "company"
+	case "contacts":
+		return "contact"
+	case "projects":
+		return "project"
+	}
+	return ""
+}
+
+// authorizeUploadTarget checks that the company, contact or project exists and the user may edit it,
+// writing the error response when it doesn't
+func (server *Server) authorizeUploadTarget(ctx *gin.Context, entityType string, entityID int32, cognitoSub string) bool {
+	var canEdit bool
+	var err error
+	switch entityType {
+	case "companies":
+		canEdit, err = server.userCanEditCompany(ctx, entityID, cognitoSub)
+	case "contacts":
+		canEdit, err = server.userCanEditContact(ctx, entityID, cognitoSub)
+	case "projects":
+		canEdit, err = server.userCanEditProject(ctx, entityID, cognitoSub)
+	default:
+		apierror.Write(ctx, apierror.Invalid("Invalid entity type, must be 'companies', 'contacts' or 'projects'"))
+		return false
+	}
+
+	name := uploadTargetName(entityType)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound(fmt.Sprintf("%s not found", strings.ToUpper(name[:1])+name[1:])))
+			return false
+		}
+		apierror.Write(ctx, apierror.Internal(fmt.Sprintf("Failed to fetch %s", name), err))
+		return false
+	}
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden(fmt.Sprintf("You don't have permission to upload files to this %s", name)))
+		return false
+	}
+	return true
+}
+
+// associateDatasource links a datasource to a company, contact or project
+func (server *Server) associateDatasource(ctx context.Context, entityType string, entityID, datasourceID int32) error {
+	switch entityType {
+	case "companies":
+		return server.store.AssociateDatasourceWithCompany(ctx, db.AssociateDatasourceWithCompanyParams{
+			CompanyID:    entityID,
+			DatasourceID: datasourceID,
+		})
+	case "contacts":
+		return server.store.AssociateDatasourceWithContact(ctx, db.AssociateDatasourceWithContactParams{
+			ContactID:    entityID,
+			DatasourceID: datasourceID,
+		})
+	case "projects":
+		return server.store.AssociateDatasourceWithProject(ctx, db.AssociateDatasourceWithProjectParams{
+			ProjectID:    entityID,
+			DatasourceID: datasourceID,
+		})
+	}
+	return fmt.Errorf("unknown entity type %q", entityType)
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/identity"
+)
+
+// devTokenTTL is how long tokens from /dev/token are valid
+const devTokenTTL = 12 * time.Hour
+
+// devTokenRequest represents the request body for signing in with the local identity provider
+type devTokenRequest struct {
+	Email  string   `json:"email" binding:"required,email"`
+	Groups []string `json:"groups"` // Application-wide roles carried by the token, e.g. ["admin"]
+}
+
+// issueDevToken returns a handler that signs in as any user without a password. It's only
+// routed when the server runs with the local identity provider, for development and
+// integration tests. The user is created on first sign-in, like with Cognito sign-up.
+func (server *Server) issueDevToken(local *identity.LocalProvider) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var req devTokenRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			apierror.Write(ctx, apierror.InvalidRequest(err))
+			return
+		}
+
+		cognitoSub, err := server.devUser(ctx, req.Email)
+		if err != nil {
+			apierror.Write(ctx, apierror.Internal("Failed to create user", err))
+			return
+		}
+
+		token, err := local.Issue(cognitoSub, req.Email, req.Groups, devTokenTTL)
+		if err != nil {
+			apierror.Write(ctx, apierror.Internal("Failed to sign token", err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"access_token": token,
+			"token_type":   "Bearer",
+			"expires_in":   int(devTokenTTL.Seconds()),
+			"cognito_sub":  cognitoSub,
+		})
+	}
+}
+
+// devUser returns the ID of the user with the given email, creating the user when needed
+func (server *Server) devUser(ctx *gin.Context, email string) (string, error) {
+	user, err := server.store.GetUserByUsername(ctx.Request.Context(), email)
+	if err == nil {
+		return user.CognitoSub, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	created, err := server.store.CreateUser(ctx.Request.Context(), db.CreateUserParams{
+		CognitoSub: "local-" + hex.EncodeToString(id),
+		Username:   email,
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.CognitoSub, nil
+}
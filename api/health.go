@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/audioconverter"
+	"github.com/mbaxamb3/nusli/blobstore"
+	"github.com/mbaxamb3/nusli/db/migration"
+	docscraper "github.com/mbaxamb3/nusli/document_scraper"
+	"github.com/mbaxamb3/nusli/identity"
+	"github.com/mbaxamb3/nusli/logging"
+)
+
+// readinessCheckTimeout bounds each readiness check, so a hanging dependency can't hold up the probe
+const readinessCheckTimeout = 3 * time.Second
+
+// readinessCheck checks one dependency. The server isn't ready while a required check fails;
+// optional checks only report features that are unavailable.
+type readinessCheck struct {
+	name     string
+	required bool
+	run      func(ctx context.Context) (gin.H, error) // Returns details to report
+}
+
+// checkResult represents the outcome of a readiness check in the API response
+type checkResult struct {
+	Status    string  `json:"status"` // "ok" or "failed"
+	Required  bool    `json:"required"`
+	LatencyMS float64 `json:"latency_ms"`
+	Details   gin.H   `json:"details,omitempty"`
+}
+
+// handleLiveness reports that the process is up and serving requests
+func (server *Server) handleLiveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleReadiness reports whether the server should receive traffic: it isn't shutting down
+// and the database, identity provider keys, blob store and external tools all work. Every
+// check's status and latency is included.
+func (server *Server) handleReadiness(ctx *gin.Context) {
+	if !server.lifecycle.Ready() {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+
+	results := server.runReadinessChecks(ctx.Request.Context())
+
+	status, code := "ready", http.StatusOK
+	for _, result := range results {
+		if result.Required && result.Status != "ok" {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+	}
+	ctx.JSON(code, gin.H{"status": status, "checks": results})
+}
+
+// runReadinessChecks runs all checks at the same time and returns their results by name
+func (server *Server) runReadinessChecks(ctx context.Context) map[string]checkResult {
+	checks := server.readinessChecks()
+	results := make(map[string]checkResult, len(checks))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.run(checkCtx)
+			result := checkResult{
+				Status:    "ok",
+				Required:  check.required,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			if err != nil {
+				logging.FromContext(ctx).Warn("Readiness check failed", "check", check.name, "error", err)
+				// The error can name hosts and credentials, so it's only logged
+				result.Status = "failed"
+			}
+
+			mu.Lock()
+			results[check.name] = result
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// readinessChecks returns the checks for the configured dependencies
+func (server *Server) readinessChecks() []readinessCheck {
+	checks := []readinessCheck{
+		{name: "database", required: true, run: server.checkDatabase},
+		{name: "blob_store", required: true, run: func(ctx context.Context) (gin.H, error) {
+			return gin.H{"backend": server.config.BlobStore}, blobstore.Check(ctx, server.blobs)
+		}},
+		{name: "ffmpeg", required: true, run: func(ctx context.Context) (gin.H, error) {
+			return nil, audioconverter.CheckFFmpeg()
+		}},
+		// Only legacy .doc files need LibreOffice
+		{name: "libreoffice", required: false, run: func(ctx context.Context) (gin.H, error) {
+			return nil, docscraper.CheckConverter()
+		}},
+	}
+
+	// Providers with local keys, such as the local provider, have nothing to check
+	if keys, ok := server.identity.(identity.KeyChecker); ok {
+		checks = append(checks, readinessCheck{name: "identity_keys", required: true, run: func(ctx context.Context) (gin.H, error) {
+			return gin.H{"provider": server.identity.Name()}, keys.CheckKeys(ctx)
+		}})
+	}
+	return checks
+}
+
+// checkDatabase pings the database and checks that it has been migrated at least as far as
+// the migrations built into the server. A newer schema is fine during rolling deploys.
+func (server *Server) checkDatabase(ctx context.Context) (gin.H, error) {
+	if err := server.store.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("ping failed: %w", err)
+	}
+
+	expected, err := migration.LatestVersion()
+	if err != nil {
+		return nil, err
+	}
+	version, dirty, err := server.store.SchemaVersion(ctx)
+	if err != nil {
+		return gin.H{"expected_version": expected}, fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	details := gin.H{"schema_version": version, "expected_version": expected}
+	if dirty {
+		return details, fmt.Errorf("migration %d didn't finish; fix it and force the version", version)
+	}
+	if version < expected {
+		return details, fmt.Errorf("database schema is at version %d, expected %d", version, expected)
+	}
+	return details, nil
+}
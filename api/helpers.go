@@ -1,59 +1,182 @@
 package api
 
-// import (
-// 	"database/sql"
-
-// 	"github.com/gin-gonic/gin"
-// )
-
-// // Helper function to check if user has access to a datasource
-// func (server *Server) userHasAccessToDatasource(ctx *gin.Context, datasourceID int32, cognitoSub string) (bool, error) {
-// 	// This is a complex check because datasources can be linked to companies, contacts, or projects
-
-// 	// First, check if the datasource is linked to a company owned by the user
-// 	companyAssociations, err := server.store.ListCompanyDatasourceAssociations(ctx, datasourceID)
-// 	if err != nil && err != sql.ErrNoRows {
-// 		return false, err
-// 	}
-
-// 	// Check each company association
-// 	for _, association := range companyAssociations {
-// 		hasAccess, err := server.userHasAccessToCompany(ctx, association.CompanyID, cognitoSub)
-// 		if err == nil && hasAccess {
-// 			return true, nil
-// 		}
-// 	}
-
-// 	// Next, check if the datasource is linked to a contact in a company owned by the user
-// 	contactAssociations, err := server.store.ListContactDatasourceAssociations(ctx, datasourceID)
-// 	if err != nil && err != sql.ErrNoRows {
-// 		return false, err
-// 	}
-
-// 	// Check each contact association
-// 	for _, association := range contactAssociations {
-// 		contact, err := server.store.GetContactByID(ctx, association.ContactID)
-// 		if err == nil {
-// 			hasAccess, err := server.userHasAccessToCompany(ctx, contact.CompanyID, cognitoSub)
-// 			if err == nil && hasAccess {
-// 				return true, nil
-// 			}
-// 		}
-// 	}
-
-// 	// Finally, check if it's linked to a project owned by the user
-// 	projectAssociations, err := server.store.ListProjectDatasourceAssociations(ctx, datasourceID)
-// 	if err != nil && err != sql.ErrNoRows {
-// 		return false, err
-// 	}
-
-// 	// Check each project association
-// 	for _, association := range projectAssociations {
-// 		project, err := server.store.GetProjectByID(ctx, association.ProjectID)
-// 		if err == nil && project.CognitoSub.Valid && project.CognitoSub.String == cognitoSub {
-// 			return true, nil
-// 		}
-// 	}
-
-// 	return false, nil
-// }
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/authz"
+)
+
+// Access checks go through server.authz so that every handler applies the same workspace
+// membership rules. The userHasAccessTo* helpers check for read access and the userCanEdit*
+// helpers for write access; both keep the (bool, error) shape the handlers were written
+// against: a missing resource is reported as sql.ErrNoRows.
+
+// Helper function to check if a user has access to a company
+func (server *Server) userHasAccessToCompany(ctx *gin.Context, companyID int32, cognitoSub string) (bool, error) {
+	return accessResult(server.authz.CanAccessCompany(ctx.Request.Context(), cognitoSub, companyID))
+}
+
+// Helper function to check if a user has access to a contact through its company's workspace
+func (server *Server) userHasAccessToContact(ctx *gin.Context, contactID int32, cognitoSub string) (bool, error) {
+	return accessResult(server.authz.CanAccessContact(ctx.Request.Context(), cognitoSub, contactID))
+}
+
+// Helper function to check if a user has access to a project
+func (server *Server) userHasAccessToProject(ctx *gin.Context, projectID int32, cognitoSub string) (bool, error) {
+	return accessResult(server.authz.CanAccessProject(ctx.Request.Context(), cognitoSub, projectID))
+}
+
+// Helper function to check if user has access to a datasource.
+// Datasources can be linked to companies, contacts, or projects, and access
+// through any of them is enough.
+func (server *Server) userHasAccessToDatasource(ctx *gin.Context, datasourceID int32, cognitoSub string) (bool, error) {
+	return accessResult(server.authz.CanAccessDatasource(ctx.Request.Context(), cognitoSub, datasourceID))
+}
+
+// Helper function to check if a user may change a company and its contacts and datasources
+func (server *Server) userCanEditCompany(ctx *gin.Context, companyID int32, cognitoSub string) (bool, error) {
+	return accessResult(server.authz.CanEditCompany(ctx.Request.Context(), cognitoSub, companyID))
+}
+
+// Helper function to check if a user may change a contact and its datasources
+func (server *Server) userCanEditContact(ctx *gin.Context, contactID int32, cognitoSub string) (bool, error) {
+	return accessResult(server.authz.CanEditContact(ctx.Request.Context(), cognitoSub, contactID))
+}
+
+// Helper function to check if a user may change a project and its datasources
+func (server *Server) userCanEditProject(ctx *gin.Context, projectID int32, cognitoSub string) (bool, error) {
+	return accessResult(server.authz.CanEditProject(ctx.Request.Context(), cognitoSub, projectID))
+}
+
+// accessResult converts the result of an authz check to (hasAccess, err)
+func accessResult(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, authz.ErrForbidden):
+		return false, nil
+	case errors.Is(err, authz.ErrNotFound):
+		return false, sql.ErrNoRows
+	}
+	return false, err
+}
+
+// respondAccessError writes the response for a failed authz check on a resource such as
+// "datasource" or "paragraph"
+func respondAccessError(ctx *gin.Context, err error, resource string) {
+	switch {
+	case errors.Is(err, authz.ErrNotFound):
+		apierror.Write(ctx, apierror.NotFound(capitalize(resource)+" not found"))
+	case errors.Is(err, authz.ErrForbidden):
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to access this "+resource))
+	default:
+		apierror.Write(ctx, apierror.Internal("Failed to check "+resource+" access", err))
+	}
+}
+
+// respondEditError writes the response for a failed authz check on a change to a resource
+func respondEditError(ctx *gin.Context, err error, resource string) {
+	if errors.Is(err, authz.ErrForbidden) {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to modify this "+resource))
+		return
+	}
+	respondAccessError(ctx, err, resource)
+}
+
+// capitalize upper-cases the first letter of an ASCII word
+func capitalize(s string) string {
+	if s == "" || s[0] < 'a' || s[0] > 'z' {
+		return s
+	}
+	return string(s[0]-'a'+'A') + s[1:]
+}
+
+// requireCompanyAccess is route middleware that stops the request unless the
+// authenticated user can access the company whose ID is in the given URL param
+func (server *Server) requireCompanyAccess(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "company", server.authz.CanAccessCompany, respondAccessError)
+}
+
+// requireContactAccess is route middleware that stops the request unless the
+// authenticated user can access the contact whose ID is in the given URL param
+func (server *Server) requireContactAccess(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "contact", server.authz.CanAccessContact, respondAccessError)
+}
+
+// requireDatasourceAccess is route middleware that stops the request unless the
+// authenticated user can access the datasource whose ID is in the given URL param
+func (server *Server) requireDatasourceAccess(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "datasource", server.authz.CanAccessDatasource, respondAccessError)
+}
+
+// requireDatasourceEdit is route middleware that stops the request unless the
+// authenticated user may change the datasource whose ID is in the given URL param
+func (server *Server) requireDatasourceEdit(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "datasource", server.authz.CanEditDatasource, respondEditError)
+}
+
+// requireParagraphAccess is route middleware that stops the request unless the
+// authenticated user can access the paragraph whose ID is in the given URL param
+func (server *Server) requireParagraphAccess(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "paragraph", server.authz.CanAccessParagraph, respondAccessError)
+}
+
+// requireParagraphEdit is route middleware that stops the request unless the
+// authenticated user may change the paragraph whose ID is in the given URL param
+func (server *Server) requireParagraphEdit(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "paragraph", server.authz.CanEditParagraph, respondEditError)
+}
+
+// requireProjectAccess is route middleware that stops the request unless the
+// authenticated user can access the project whose ID is in the given URL param
+func (server *Server) requireProjectAccess(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "project", server.authz.CanAccessProject, respondAccessError)
+}
+
+// requireProjectEdit is route middleware that stops the request unless the
+// authenticated user may change the project whose ID is in the given URL param
+func (server *Server) requireProjectEdit(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "project", server.authz.CanEditProject, respondEditError)
+}
+
+// requireWorkspaceOwner is route middleware that stops the request unless the
+// authenticated user owns the workspace whose ID is in the given URL param
+func (server *Server) requireWorkspaceOwner(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "workspace", server.authz.CanManageWorkspace, respondManageError)
+}
+
+// requireWorkspaceMember is route middleware that stops the request unless the
+// authenticated user is a member of the workspace whose ID is in the given URL param
+func (server *Server) requireWorkspaceMember(param string) gin.HandlerFunc {
+	return server.requireAccess(param, "workspace", server.authz.CanAccessWorkspace, respondAccessError)
+}
+
+// requireAccess builds the access middleware for a resource identified by a URL param
+func (server *Server) requireAccess(param, resource string, check func(ctx context.Context, cognitoSub string, id int32) error, respond func(ctx *gin.Context, err error, resource string)) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Get authenticated user's cognito_sub from context
+		cognitoSub, exists := ctx.Get("cognito_sub")
+		if !exists {
+			apierror.Abort(ctx, apierror.Unauthorized("Unauthorized access"))
+			return
+		}
+
+		id, err := strconv.ParseInt(ctx.Param(param), 10, 32)
+		if err != nil {
+			apierror.Abort(ctx, apierror.Invalid("Invalid "+resource+" ID format"))
+			return
+		}
+
+		if err := check(ctx.Request.Context(), cognitoSub.(string), int32(id)); err != nil {
+			respond(ctx, err, resource)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
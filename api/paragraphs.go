@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -19,6 +20,18 @@ type paragraphResponse struct {
 	MainIdea     string `json:"main_idea,omitempty"`
 	Content      string `json:"content"`
 	CreatedAt    string `json:"created_at,omitempty"`
+
+	Email *paragraphEmailResponse `json:"email,omitempty"` // Only for paragraphs of email datasources
+}
+
+// paragraphEmailResponse represents who wrote an email paragraph and when
+type paragraphEmailResponse struct {
+	MessageID   string   `json:"message_id,omitempty"`
+	Subject     string   `json:"subject,omitempty"`
+	SenderName  string   `json:"sender_name,omitempty"`
+	SenderEmail string   `json:"sender_email"`
+	Recipients  []string `json:"recipients"`
+	SentAt      string   `json:"sent_at,omitempty"`
 }
 
 // createParagraphRequest represents the request to create a new paragraph
@@ -122,18 +135,20 @@ func (server *Server) createParagraph(ctx *gin.Context) {
 	// Parse request body
 	var req createParagraphRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
-	// Check if datasource exists
-	_, err := server.store.GetDatasourceByID(ctx, req.DatasourceID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Datasource not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasource"})
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	// Check that the datasource exists and the user may edit it
+	if err := server.authz.CanEditDatasource(ctx.Request.Context(), cognitoSub.(string), req.DatasourceID); err != nil {
+		respondEditError(ctx, err, "datasource")
 		return
 	}
 
@@ -145,9 +160,9 @@ func (server *Server) createParagraph(ctx *gin.Context) {
 		Content:      req.Content,
 	}
 
-	paragraph, err := server.store.CreateParagraph(ctx, arg)
+	paragraph, err := server.store.CreateParagraph(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create paragraph"})
+		apierror.Write(ctx, apierror.Internal("Failed to create paragraph", err))
 		return
 	}
 
@@ -161,18 +176,18 @@ func (server *Server) getParagraphByID(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid paragraph ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid paragraph ID format"))
 		return
 	}
 
 	// Get paragraph from database
-	paragraph, err := server.store.GetParagraphByID(ctx, int32(id))
+	paragraph, err := server.store.GetParagraphByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Paragraph not found"})
+			apierror.Write(ctx, apierror.NotFound("Paragraph not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch paragraph"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch paragraph", err))
 		return
 	}
 
@@ -186,25 +201,25 @@ func (server *Server) updateParagraph(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid paragraph ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid paragraph ID format"))
 		return
 	}
 
 	// Check if paragraph exists
-	_, err = server.store.GetParagraphByID(ctx, int32(id))
+	_, err = server.store.GetParagraphByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Paragraph not found"})
+			apierror.Write(ctx, apierror.NotFound("Paragraph not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch paragraph"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch paragraph", err))
 		return
 	}
 
 	// Parse request body
 	var req updateParagraphRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
@@ -216,9 +231,9 @@ func (server *Server) updateParagraph(ctx *gin.Context) {
 		Content:     req.Content,
 	}
 
-	updatedParagraph, err := server.store.UpdateParagraph(ctx, arg)
+	updatedParagraph, err := server.store.UpdateParagraph(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update paragraph"})
+		apierror.Write(ctx, apierror.Internal("Failed to update paragraph", err))
 		return
 	}
 
@@ -232,25 +247,25 @@ func (server *Server) deleteParagraph(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid paragraph ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid paragraph ID format"))
 		return
 	}
 
 	// Check if paragraph exists
-	_, err = server.store.GetParagraphByID(ctx, int32(id))
+	_, err = server.store.GetParagraphByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Paragraph not found"})
+			apierror.Write(ctx, apierror.NotFound("Paragraph not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch paragraph"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch paragraph", err))
 		return
 	}
 
 	// Delete paragraph
-	err = server.store.DeleteParagraph(ctx, int32(id))
+	err = server.store.DeleteParagraph(ctx.Request.Context(), int32(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete paragraph"})
+		apierror.Write(ctx, apierror.Internal("Failed to delete paragraph", err))
 		return
 	}
 
@@ -264,18 +279,18 @@ func (server *Server) listParagraphsByDatasource(ctx *gin.Context) {
 	datasourceIDParam := ctx.Param("datasource_id")
 	datasourceID, err := strconv.Atoi(datasourceIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datasource ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
 		return
 	}
 
 	// Check if datasource exists
-	_, err = server.store.GetDatasourceByID(ctx, int32(datasourceID))
+	datasource, err := server.store.GetDatasourceByID(ctx.Request.Context(), int32(datasourceID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Datasource not found"})
+			apierror.Write(ctx, apierror.NotFound("Datasource not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasource"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasource", err))
 		return
 	}
 
@@ -299,13 +314,13 @@ func (server *Server) listParagraphsByDatasource(ctx *gin.Context) {
 	}
 
 	// Get paragraphs from database
-	paragraphs, err := server.store.ListParagraphsByDatasource(ctx, db.ListParagraphsByDatasourceParams{
+	paragraphs, err := server.store.ListParagraphsByDatasource(ctx.Request.Context(), db.ListParagraphsByDatasourceParams{
 		DatasourceID: int32(datasourceID),
 		Limit:        int32(limit),
 		Offset:       int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch paragraphs"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch paragraphs", err))
 		return
 	}
 
@@ -315,27 +330,62 @@ func (server *Server) listParagraphsByDatasource(ctx *gin.Context) {
 		responses[i] = convertParagraphToResponse(paragraph)
 	}
 
+	if datasource.SourceType == db.DatasourceTypeEmail {
+		if err := server.attachEmailMetadata(ctx, responses); err != nil {
+			apierror.Write(ctx, apierror.Internal("Failed to fetch email metadata", err))
+			return
+		}
+	}
+
 	ctx.JSON(http.StatusOK, responses)
 }
 
+// attachEmailMetadata adds the sender, recipients and date to paragraphs extracted from emails
+func (server *Server) attachEmailMetadata(ctx *gin.Context, responses []paragraphResponse) error {
+	ids := make([]int32, len(responses))
+	for i, response := range responses {
+		ids[i] = response.ParagraphID
+	}
+
+	metadata, err := server.store.ListParagraphEmailMetadata(ctx.Request.Context(), ids)
+	if err != nil {
+		return err
+	}
+
+	byParagraph := make(map[int32]db.ParagraphEmailMetadatum, len(metadata))
+	for _, m := range metadata {
+		byParagraph[m.ParagraphID] = m
+	}
+
+	for i := range responses {
+		m, ok := byParagraph[responses[i].ParagraphID]
+		if !ok {
+			continue
+		}
+
+		sentAt := ""
+		if m.SentAt.Valid {
+			sentAt = m.SentAt.Time.Format("2006-01-02T15:04:05Z")
+		}
+		responses[i].Email = &paragraphEmailResponse{
+			MessageID:   m.MessageID.String,
+			Subject:     m.Subject.String,
+			SenderName:  m.SenderName.String,
+			SenderEmail: m.SenderEmail,
+			Recipients:  m.Recipients,
+			SentAt:      sentAt,
+		}
+	}
+	return nil
+}
+
 // listCompanyParagraphs handles requests to get all paragraphs for a specific company
 func (server *Server) listCompanyParagraphs(ctx *gin.Context) {
-	// Get company ID from URL param
+	// requireCompanyAccess has checked the ID and the user's access
 	companyIDParam := ctx.Param("id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
-		return
-	}
-
-	// Check if company exists
-	_, err = server.store.GetCompanyByID(ctx, int32(companyID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
@@ -359,13 +409,13 @@ func (server *Server) listCompanyParagraphs(ctx *gin.Context) {
 	}
 
 	// Get paragraphs for company
-	paragraphs, err := server.store.GetCompanyParagraphs(ctx, db.GetCompanyParagraphsParams{
+	paragraphs, err := server.store.GetCompanyParagraphs(ctx.Request.Context(), db.GetCompanyParagraphsParams{
 		CompanyID: int32(companyID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch paragraphs"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch paragraphs", err))
 		return
 	}
 
@@ -380,22 +430,11 @@ func (server *Server) listCompanyParagraphs(ctx *gin.Context) {
 
 // listContactParagraphs handles requests to get all paragraphs for a specific contact
 func (server *Server) listContactParagraphs(ctx *gin.Context) {
-	// Get contact ID from URL param
+	// requireContactAccess has checked the ID and the user's access
 	contactIDParam := ctx.Param("id")
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
-		return
-	}
-
-	// Check if contact exists
-	_, err = server.store.GetContactByID(ctx, int32(contactID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
@@ -419,13 +458,13 @@ func (server *Server) listContactParagraphs(ctx *gin.Context) {
 	}
 
 	// Get paragraphs for contact
-	paragraphs, err := server.store.GetContactParagraphs(ctx, db.GetContactParagraphsParams{
+	paragraphs, err := server.store.GetContactParagraphs(ctx.Request.Context(), db.GetContactParagraphsParams{
 		ContactID: int32(contactID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch paragraphs"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch paragraphs", err))
 		return
 	}
 
@@ -440,29 +479,18 @@ func (server *Server) listContactParagraphs(ctx *gin.Context) {
 
 // searchCompanyParagraphs handles requests to search paragraphs for a specific company
 func (server *Server) searchCompanyParagraphs(ctx *gin.Context) {
-	// Get company ID from URL param
+	// requireCompanyAccess has checked the ID and the user's access
 	companyIDParam := ctx.Param("id")
 	companyID, err := strconv.Atoi(companyIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID format"})
-		return
-	}
-
-	// Check if company exists
-	_, err = server.store.GetCompanyByID(ctx, int32(companyID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch company"})
+		apierror.Write(ctx, apierror.Invalid("Invalid company ID format"))
 		return
 	}
 
 	// Get search query from URL param
 	query := ctx.Query("q")
 	if query == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		apierror.Write(ctx, apierror.Invalid("Search query is required"))
 		return
 	}
 
@@ -486,14 +514,14 @@ func (server *Server) searchCompanyParagraphs(ctx *gin.Context) {
 	}
 
 	// Search paragraphs for company
-	paragraphs, err := server.store.SearchCompanyParagraphs(ctx, db.SearchCompanyParagraphsParams{
+	paragraphs, err := server.store.SearchCompanyParagraphs(ctx.Request.Context(), db.SearchCompanyParagraphsParams{
 		CompanyID: int32(companyID),
 		Column2:   sql.NullString{String: query, Valid: true},
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search paragraphs"})
+		apierror.Write(ctx, apierror.Internal("Failed to search paragraphs", err))
 		return
 	}
 
@@ -539,29 +567,18 @@ func (server *Server) searchCompanyParagraphs(ctx *gin.Context) {
 
 // searchContactParagraphs handles requests to search paragraphs for a specific contact
 func (server *Server) searchContactParagraphs(ctx *gin.Context) {
-	// Get contact ID from URL param
+	// requireContactAccess has checked the ID and the user's access
 	contactIDParam := ctx.Param("id")
 	contactID, err := strconv.Atoi(contactIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
-		return
-	}
-
-	// Check if contact exists
-	_, err = server.store.GetContactByID(ctx, int32(contactID))
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch contact"})
+		apierror.Write(ctx, apierror.Invalid("Invalid contact ID format"))
 		return
 	}
 
 	// Get search query from URL param
 	query := ctx.Query("q")
 	if query == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Search query is required"})
+		apierror.Write(ctx, apierror.Invalid("Search query is required"))
 		return
 	}
 
@@ -585,14 +602,14 @@ func (server *Server) searchContactParagraphs(ctx *gin.Context) {
 	}
 
 	// Search paragraphs for contact
-	paragraphs, err := server.store.SearchContactParagraphs(ctx, db.SearchContactParagraphsParams{
+	paragraphs, err := server.store.SearchContactParagraphs(ctx.Request.Context(), db.SearchContactParagraphsParams{
 		ContactID: int32(contactID),
 		Column2:   sql.NullString{String: query, Valid: true},
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search paragraphs"})
+		apierror.Write(ctx, apierror.Internal("Failed to search paragraphs", err))
 		return
 	}
 
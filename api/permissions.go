@@ -0,0 +1,70 @@
+package api
+
+import (
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/authz"
+)
+
+// requirePermission is route middleware that stops the request unless the authenticated
+// user's application-wide roles grant every one of the permissions. Routes declare what
+// they need, e.g. server.requirePermission(authz.PermUsersRead).
+func (server *Server) requirePermission(perms ...authz.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		roles, ok := server.userRoles(ctx)
+		if !ok {
+			ctx.Abort()
+			return
+		}
+
+		if !authz.Allows(roles, perms...) {
+			apierror.Abort(ctx, apierror.Forbidden("You don't have permission to perform this action"))
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// userRoles returns the authenticated user's application-wide roles: the Cognito groups in
+// their token together with the roles stored in the user_roles table. The result is cached
+// in the context under "roles". It writes the error response and returns false when the
+// roles can't be determined.
+func (server *Server) userRoles(ctx *gin.Context) ([]string, bool) {
+	if roles, exists := ctx.Get("roles"); exists {
+		return roles.([]string), true
+	}
+
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return nil, false
+	}
+
+	stored, err := server.store.ListUserRoles(ctx.Request.Context(), cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to load user roles", err))
+		return nil, false
+	}
+
+	roles := mergeRoles(ctx.GetStringSlice("token_roles"), stored)
+	ctx.Set("roles", roles)
+	return roles, true
+}
+
+// mergeRoles returns the sorted union of the given role lists
+func mergeRoles(lists ...[]string) []string {
+	seen := map[string]bool{}
+	roles := []string{}
+	for _, list := range lists {
+		for _, role := range list {
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
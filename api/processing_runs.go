@@ -0,0 +1,249 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/logging"
+	"github.com/mbaxamb3/nusli/progress"
+)
+
+const (
+	// runRetention is how long a finished run's events stay available to late subscribers
+	runRetention = 10 * time.Minute
+	// runEventBuffer is the buffer size of channels between scrapers, runs and subscribers
+	runEventBuffer = 256
+	// runHistoryLimit is how many events a run keeps for late subscribers, besides the final one
+	runHistoryLimit = 500
+	// sseKeepAliveInterval is how often an idle event stream sends a comment to keep proxies from closing it
+	sseKeepAliveInterval = 15 * time.Second
+)
+
+// errShuttingDown fails runs that couldn't start because the server is shutting down
+var errShuttingDown = errors.New("server is shutting down; process the datasource again")
+
+// processingRun records the progress events of one datasource processing run
+type processingRun struct {
+	ID           string
+	DatasourceID int32
+	CognitoSub   string
+
+	mu          sync.Mutex
+	events      []progress.Event // The latest events, up to runHistoryLimit
+	final       progress.Event   // The completed or failed event, once done
+	subscribers map[chan progress.Event]struct{}
+	done        bool
+}
+
+// runRegistry keeps track of recent processing runs so clients can follow them
+type runRegistry struct {
+	mu   sync.Mutex
+	runs map[string]*processingRun
+}
+
+// newRunRegistry creates an empty run registry
+func newRunRegistry() *runRegistry {
+	return &runRegistry{runs: make(map[string]*processingRun)}
+}
+
+// start registers a new run for a datasource
+func (r *runRegistry) start(datasourceID int32, cognitoSub string) *processingRun {
+	run := &processingRun{
+		ID:           uuid.NewString(),
+		DatasourceID: datasourceID,
+		CognitoSub:   cognitoSub,
+		subscribers:  make(map[chan progress.Event]struct{}),
+	}
+
+	r.mu.Lock()
+	r.runs[run.ID] = run
+	r.mu.Unlock()
+
+	run.publish(progress.Event{Type: progress.EventStarted})
+	return run
+}
+
+// get looks up a run by ID
+func (r *runRegistry) get(id string) (*processingRun, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[id]
+	return run, ok
+}
+
+// finish publishes the final event of a run and forgets the run after the retention period
+func (r *runRegistry) finish(run *processingRun, final progress.Event) {
+	run.finish(final)
+	time.AfterFunc(runRetention, func() {
+		r.mu.Lock()
+		delete(r.runs, run.ID)
+		r.mu.Unlock()
+	})
+}
+
+// publish records an event and forwards it to current subscribers
+func (run *processingRun) publish(event progress.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.done {
+		return
+	}
+
+	// Large crawls emit an event per page, so only the latest ones are kept
+	if len(run.events) == runHistoryLimit {
+		copy(run.events, run.events[1:])
+		run.events = run.events[:runHistoryLimit-1]
+	}
+	run.events = append(run.events, event)
+	for ch := range run.subscribers {
+		progress.Emit(ch, event)
+	}
+}
+
+// finish publishes the final event and closes all subscriber channels. Subscribers whose
+// buffer is full miss the event on their channel; they get it from result instead.
+func (run *processingRun) finish(final progress.Event) {
+	if final.Time.IsZero() {
+		final.Time = time.Now()
+	}
+
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.done {
+		return
+	}
+
+	run.final = final
+	run.done = true
+	for ch := range run.subscribers {
+		progress.Emit(ch, final)
+		close(ch)
+		delete(run.subscribers, ch)
+	}
+}
+
+// result returns the final event of a finished run
+func (run *processingRun) result() (progress.Event, bool) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return run.final, run.done
+}
+
+// relay publishes every event received from a scraper until the channel is closed
+func (run *processingRun) relay(events <-chan progress.Event) {
+	for event := range events {
+		run.publish(event)
+	}
+}
+
+// subscribe returns the events so far and, for a run still in progress, a channel
+// for the ones that follow. The returned function must be called to unsubscribe.
+func (run *processingRun) subscribe() ([]progress.Event, <-chan progress.Event, func()) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	history := append([]progress.Event(nil), run.events...)
+	if run.done {
+		return append(history, run.final), nil, func() {}
+	}
+
+	ch := make(chan progress.Event, runEventBuffer)
+	run.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		run.mu.Lock()
+		defer run.mu.Unlock()
+		if _, ok := run.subscribers[ch]; ok {
+			delete(run.subscribers, ch)
+			close(ch)
+		}
+	}
+	return history, ch, unsubscribe
+}
+
+// streamProcessingRun handles requests to follow a processing run over Server-Sent Events
+func (server *Server) streamProcessingRun(ctx *gin.Context) {
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	// Get datasource ID from URL param
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
+		return
+	}
+
+	// Runs are only visible to the user who started them
+	run, ok := server.runs.get(ctx.Param("run_id"))
+	if !ok || run.DatasourceID != int32(id) || run.CognitoSub != cognitoSub.(string) {
+		apierror.Write(ctx, apierror.NotFound("Processing run not found"))
+		return
+	}
+
+	history, events, unsubscribe := run.subscribe()
+	defer unsubscribe()
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	for _, event := range history {
+		ctx.SSEvent(string(event.Type), event)
+	}
+	ctx.Writer.Flush()
+	if events == nil {
+		return
+	}
+
+	// Streams last as long as the run, so they aren't bound by the server's write timeout
+	if err := http.NewResponseController(ctx.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logging.FromContext(ctx.Request.Context()).Warn("Failed to lift write deadline of event stream", "error", err)
+	}
+
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	finalSent := false
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case event, open := <-events:
+			if !open {
+				// A client that fell behind may have missed the final event on its channel
+				if final, done := run.result(); done && !finalSent {
+					ctx.SSEvent(string(final.Type), final)
+				}
+				return false
+			}
+			finalSent = finalSent || isFinalEvent(event.Type)
+			ctx.SSEvent(string(event.Type), event)
+			return true
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+			return true
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-server.lifecycle.Stopping():
+			// Don't hold up the drain; the run itself may still finish
+			return false
+		}
+	})
+}
+
+// isFinalEvent reports whether an event ends a run
+func isFinalEvent(eventType progress.EventType) bool {
+	return eventType == progress.EventCompleted || eventType == progress.EventFailed
+}
@@ -0,0 +1,46 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/mbaxamb3/nusli/progress"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessingRunFinalEventWhenSubscriberFallsBehind(t *testing.T) {
+	run := newRunRegistry().start(1, "alice")
+	_, events, unsubscribe := run.subscribe()
+	defer unsubscribe()
+
+	// The subscriber reads nothing until its buffer is full
+	for i := 0; i < runEventBuffer+10; i++ {
+		run.publish(progress.Event{Type: progress.EventPageFetched})
+	}
+	run.finish(progress.Event{Type: progress.EventCompleted, Count: 3})
+
+	received := 0
+	for event := range events {
+		require.Equal(t, progress.EventPageFetched, event.Type)
+		received++
+	}
+	require.Equal(t, runEventBuffer, received)
+
+	final, done := run.result()
+	require.True(t, done)
+	require.Equal(t, progress.EventCompleted, final.Type)
+	require.Equal(t, 3, final.Count)
+}
+
+func TestProcessingRunHistoryLimit(t *testing.T) {
+	run := newRunRegistry().start(1, "alice")
+	for i := 0; i < runHistoryLimit*2; i++ {
+		run.publish(progress.Event{Type: progress.EventPageFetched, Count: i})
+	}
+	run.finish(progress.Event{Type: progress.EventFailed, Message: "Processing failed because of a server error"})
+
+	history, events, _ := run.subscribe()
+	require.Nil(t, events)
+	require.Len(t, history, runHistoryLimit+1)
+	require.Equal(t, runHistoryLimit, history[0].Count) // The oldest events were dropped
+	require.Equal(t, progress.EventFailed, history[len(history)-1].Type)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -22,25 +23,25 @@ func (server *Server) createAndAssociateProjectDatasource(ctx *gin.Context) {
 	projectIDParam := ctx.Param("id")
 	projectID, err := strconv.Atoi(projectIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid project ID format"))
 		return
 	}
 
 	// Check if project exists
-	_, err = server.store.GetProjectByID(ctx, int32(projectID))
+	_, err = server.store.GetProjectByID(ctx.Request.Context(), int32(projectID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			apierror.Write(ctx, apierror.NotFound("Project not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch project", err))
 		return
 	}
 
 	// Parse request body
 	var req createProjectDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
@@ -52,9 +53,9 @@ func (server *Server) createAndAssociateProjectDatasource(ctx *gin.Context) {
 		FileName:   sql.NullString{String: req.FileName, Valid: req.FileName != ""},
 	}
 
-	datasource, err := server.store.CreateDatasource(ctx, datasourceArg)
+	datasource, err := server.store.CreateDatasource(ctx.Request.Context(), datasourceArg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create datasource"})
+		apierror.Write(ctx, apierror.Internal("Failed to create datasource", err))
 		return
 	}
 
@@ -64,11 +65,11 @@ func (server *Server) createAndAssociateProjectDatasource(ctx *gin.Context) {
 		DatasourceID: datasource.DatasourceID,
 	}
 
-	err = server.store.AssociateDatasourceWithProject(ctx, associateArg)
+	err = server.store.AssociateDatasourceWithProject(ctx.Request.Context(), associateArg)
 	if err != nil {
 		// Rollback datasource creation if association fails
-		_ = server.store.DeleteDatasource(ctx, datasource.DatasourceID)
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with project"})
+		_ = server.store.DeleteDatasource(ctx.Request.Context(), datasource.DatasourceID)
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with project", err))
 		return
 	}
 
@@ -82,63 +83,66 @@ func (server *Server) createAndAssociateProjectDatasource(ctx *gin.Context) {
 
 // associateDatasourceWithProject handles requests to associate an existing datasource with a project
 func (server *Server) associateDatasourceWithProject(ctx *gin.Context) {
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
 	// Get project ID from URL param
 	projectIDParam := ctx.Param("id")
 	projectID, err := strconv.Atoi(projectIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid project ID format"))
 		return
 	}
 
 	// Check if project exists
-	_, err = server.store.GetProjectByID(ctx, int32(projectID))
+	_, err = server.store.GetProjectByID(ctx.Request.Context(), int32(projectID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			apierror.Write(ctx, apierror.NotFound("Project not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch project", err))
 		return
 	}
 
 	// Parse request body
 	var req associateDatasourceRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
-	// Check if datasource exists
-	_, err = server.store.GetDatasourceByID(ctx, req.DatasourceID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Datasource not found"})
-			return
-		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasource"})
+	// Check that the datasource exists and the user may edit it already; otherwise
+	// associating it would hand another workspace's datasource over
+	if err := server.authz.CanEditDatasource(ctx.Request.Context(), cognitoSub.(string), req.DatasourceID); err != nil {
+		respondEditError(ctx, err, "datasource")
 		return
 	}
 
 	// Check if association already exists
-	_, err = server.store.GetProjectDatasourceAssociation(ctx, db.GetProjectDatasourceAssociationParams{
+	_, err = server.store.GetProjectDatasourceAssociation(ctx.Request.Context(), db.GetProjectDatasourceAssociationParams{
 		ProjectID:    int32(projectID),
 		DatasourceID: req.DatasourceID,
 	})
 	if err == nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datasource is already associated with this project"})
+		apierror.Write(ctx, apierror.Invalid("Datasource is already associated with this project"))
 		return
 	} else if err != sql.ErrNoRows {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Associate datasource with project
-	err = server.store.AssociateDatasourceWithProject(ctx, db.AssociateDatasourceWithProjectParams{
+	err = server.store.AssociateDatasourceWithProject(ctx.Request.Context(), db.AssociateDatasourceWithProjectParams{
 		ProjectID:    int32(projectID),
 		DatasourceID: req.DatasourceID,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to associate datasource with project"})
+		apierror.Write(ctx, apierror.Internal("Failed to associate datasource with project", err))
 		return
 	}
 
@@ -158,37 +162,37 @@ func (server *Server) removeDatasourceFromProject(ctx *gin.Context) {
 
 	projectID, err := strconv.Atoi(projectIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid project ID format"))
 		return
 	}
 
 	datasourceID, err := strconv.Atoi(datasourceIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid datasource ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid datasource ID format"))
 		return
 	}
 
 	// Check if association exists
-	_, err = server.store.GetProjectDatasourceAssociation(ctx, db.GetProjectDatasourceAssociationParams{
+	_, err = server.store.GetProjectDatasourceAssociation(ctx.Request.Context(), db.GetProjectDatasourceAssociationParams{
 		ProjectID:    int32(projectID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Association between project and datasource not found"})
+			apierror.Write(ctx, apierror.NotFound("Association between project and datasource not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing association"})
+		apierror.Write(ctx, apierror.Internal("Failed to check existing association", err))
 		return
 	}
 
 	// Remove association
-	err = server.store.RemoveDatasourceFromProject(ctx, db.RemoveDatasourceFromProjectParams{
+	err = server.store.RemoveDatasourceFromProject(ctx.Request.Context(), db.RemoveDatasourceFromProjectParams{
 		ProjectID:    int32(projectID),
 		DatasourceID: int32(datasourceID),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove datasource from project"})
+		apierror.Write(ctx, apierror.Internal("Failed to remove datasource from project", err))
 		return
 	}
 
@@ -202,18 +206,18 @@ func (server *Server) listDatasourcesByProject(ctx *gin.Context) {
 	projectIDParam := ctx.Param("id")
 	projectID, err := strconv.Atoi(projectIDParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid project ID format"))
 		return
 	}
 
 	// Check if project exists
-	_, err = server.store.GetProjectByID(ctx, int32(projectID))
+	_, err = server.store.GetProjectByID(ctx.Request.Context(), int32(projectID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			apierror.Write(ctx, apierror.NotFound("Project not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch project", err))
 		return
 	}
 
@@ -237,13 +241,13 @@ func (server *Server) listDatasourcesByProject(ctx *gin.Context) {
 	}
 
 	// Get datasources for project
-	datasources, err := server.store.ListDatasourcesByProject(ctx, db.ListDatasourcesByProjectParams{
+	datasources, err := server.store.ListDatasourcesByProject(ctx.Request.Context(), db.ListDatasourcesByProjectParams{
 		ProjectID: int32(projectID),
 		Limit:     int32(limit),
 		Offset:    int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch datasources"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch datasources", err))
 		return
 	}
 
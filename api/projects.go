@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -13,12 +14,14 @@ import (
 type createProjectRequest struct {
 	ProjectName string `json:"project_name" binding:"required"`
 	MainIdea    string `json:"main_idea" binding:"omitempty"`
+	WorkspaceID *int32 `json:"workspace_id" binding:"omitempty"` // Defaults to the user's own workspace
 }
 
 // projectResponse represents the API response structure for project data
 type projectResponse struct {
 	ProjectID   int32  `json:"project_id"`
 	CognitoSub  string `json:"cognito_sub,omitempty"`
+	WorkspaceID int32  `json:"workspace_id,omitempty"`
 	ProjectName string `json:"project_name"`
 	MainIdea    string `json:"main_idea,omitempty"`
 	CreatedAt   string `json:"created_at,omitempty"`
@@ -50,6 +53,7 @@ func convertProjectToResponse(project db.GetProjectByIDRow) projectResponse {
 	return projectResponse{
 		ProjectID:   project.ProjectID,
 		CognitoSub:  cognitoSub,
+		WorkspaceID: project.WorkspaceID.Int32,
 		ProjectName: project.ProjectName,
 		MainIdea:    mainIdea,
 		CreatedAt:   createdAt,
@@ -57,8 +61,8 @@ func convertProjectToResponse(project db.GetProjectByIDRow) projectResponse {
 	}
 }
 
-// convertProjectCognitoToResponse handles the ListProjectsByCognitoSub results
-func convertProjectCognitoToResponse(project db.ListProjectsByCognitoSubRow) projectResponse {
+// convertProjectMemberToResponse handles the ListProjectsByMember results
+func convertProjectMemberToResponse(project db.ListProjectsByMemberRow) projectResponse {
 	createdAt := ""
 	if project.CreatedAt.Valid {
 		createdAt = project.CreatedAt.Time.Format("2006-01-02T15:04:05Z")
@@ -82,6 +86,7 @@ func convertProjectCognitoToResponse(project db.ListProjectsByCognitoSubRow) pro
 	return projectResponse{
 		ProjectID:   project.ProjectID,
 		CognitoSub:  cognitoSub,
+		WorkspaceID: project.WorkspaceID.Int32,
 		ProjectName: project.ProjectName,
 		MainIdea:    mainIdea,
 		CreatedAt:   createdAt,
@@ -93,28 +98,35 @@ func convertProjectCognitoToResponse(project db.ListProjectsByCognitoSubRow) pro
 func (server *Server) createProject(ctx *gin.Context) {
 	var req createProjectRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	// New projects go into the requested workspace or the user's own
+	workspaceID, ok := server.resolveWorkspace(ctx, cognitoSub.(string), req.WorkspaceID)
+	if !ok {
 		return
 	}
 
 	// Convert request to database params
 	arg := db.CreateProjectParams{
 		CognitoSub:  sql.NullString{String: cognitoSub.(string), Valid: true},
+		WorkspaceID: workspaceID,
 		ProjectName: req.ProjectName,
 		MainIdea:    sql.NullString{String: req.MainIdea, Valid: req.MainIdea != ""},
 	}
 
 	// Create project in database
-	project, err := server.store.CreateProject(ctx, arg)
+	project, err := server.store.CreateProject(ctx.Request.Context(), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create project"})
+		apierror.Write(ctx, apierror.Internal("Failed to create project", err))
 		return
 	}
 
@@ -122,6 +134,7 @@ func (server *Server) createProject(ctx *gin.Context) {
 	response := projectResponse{
 		ProjectID:   project.ProjectID,
 		CognitoSub:  cognitoSub.(string),
+		WorkspaceID: workspaceID.Int32,
 		ProjectName: project.ProjectName,
 		MainIdea:    req.MainIdea,
 		CreatedAt:   project.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
@@ -135,7 +148,7 @@ func (server *Server) getProjectByID(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -143,24 +156,29 @@ func (server *Server) getProjectByID(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid project ID format"))
 		return
 	}
 
 	// Get project from database
-	project, err := server.store.GetProjectByID(ctx, int32(id))
+	project, err := server.store.GetProjectByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			apierror.Write(ctx, apierror.NotFound("Project not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch project", err))
 		return
 	}
 
-	// Ensure the user owns this project
-	if !project.CognitoSub.Valid || project.CognitoSub.String != cognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this project"})
+	// Ensure the user is a member of the project's workspace
+	hasAccess, err := server.userHasAccessToProject(ctx, project.ProjectID, cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to check project access", err))
+		return
+	}
+	if !hasAccess {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to access this project"))
 		return
 	}
 
@@ -168,12 +186,12 @@ func (server *Server) getProjectByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, convertProjectToResponse(project))
 }
 
-// listProjects handles requests to get projects for a user with pagination
+// listProjects handles requests to get the projects in a user's workspaces with pagination
 func (server *Server) listProjects(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -197,21 +215,28 @@ func (server *Server) listProjects(ctx *gin.Context) {
 		offset = 0
 	}
 
-	// Get projects from database
-	projects, err := server.store.ListProjectsByCognitoSub(ctx, db.ListProjectsByCognitoSubParams{
-		CognitoSub: sql.NullString{String: cognitoSub.(string), Valid: true},
-		Limit:      int32(limit),
-		Offset:     int32(offset),
+	// Optionally only list one of the user's workspaces
+	workspaceID, ok := workspaceFilter(ctx)
+	if !ok {
+		return
+	}
+
+	// Get projects from database in the workspaces of the authenticated user
+	projects, err := server.store.ListProjectsByMember(ctx.Request.Context(), db.ListProjectsByMemberParams{
+		CognitoSub:  cognitoSub.(string),
+		WorkspaceID: workspaceID,
+		RowLimit:    int32(limit),
+		RowOffset:   int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch projects"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch projects", err))
 		return
 	}
 
 	// Convert projects to response format
 	projectResponses := make([]projectResponse, len(projects))
 	for i, project := range projects {
-		projectResponses[i] = convertProjectCognitoToResponse(project)
+		projectResponses[i] = convertProjectMemberToResponse(project)
 	}
 
 	ctx.JSON(http.StatusOK, projectResponses)
@@ -222,7 +247,7 @@ func (server *Server) deleteProject(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -230,31 +255,36 @@ func (server *Server) deleteProject(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project ID format"})
+		apierror.Write(ctx, apierror.Invalid("Invalid project ID format"))
 		return
 	}
 
-	// Check if project exists and belongs to the user
-	project, err := server.store.GetProjectByID(ctx, int32(id))
+	// Check if project exists
+	project, err := server.store.GetProjectByID(ctx.Request.Context(), int32(id))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+			apierror.Write(ctx, apierror.NotFound("Project not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch project"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch project", err))
 		return
 	}
 
-	// Ensure the user owns this project
-	if !project.CognitoSub.Valid || project.CognitoSub.String != cognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this project"})
+	// Ensure the user may edit in the project's workspace
+	canEdit, err := server.userCanEditProject(ctx, project.ProjectID, cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to check project access", err))
+		return
+	}
+	if !canEdit {
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to delete this project"))
 		return
 	}
 
 	// Delete project from database
-	err = server.store.DeleteProject(ctx, int32(id))
+	err = server.store.DeleteProject(ctx.Request.Context(), int32(id))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete project"})
+		apierror.Write(ctx, apierror.Internal("Failed to delete project", err))
 		return
 	}
 
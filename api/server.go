@@ -4,129 +4,179 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
-	"github.com/coreos/go-oidc"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/authz"
+	"github.com/mbaxamb3/nusli/blobstore"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/identity"
+	"github.com/mbaxamb3/nusli/lifecycle"
 	"github.com/mbaxamb3/nusli/middleware"
-	"golang.org/x/oauth2"
-)
-
-// Hardcoded AWS Cognito configuration
-const (
-	cognitoRegion       = "us-east-1"
-	cognitoUserPoolID   = "us-east-1_177Be0rjJ"
-	cognitoClientID     = "6bo0q3c938g1oa0hjggqbdv0b"
-	cognitoClientSecret = "rhlqr4vp21s2v5rfp7fijltlhe8ha3aj4i5oar561h8hgsvslam"
-	cognitoRedirectURL  = "http://localhost:8080/callback"
-	cognitoIssuerURL    = "https://cognito-idp.us-east-1.amazonaws.com/us-east-1_177Be0rjJ"
+	"github.com/mbaxamb3/nusli/tracing"
+	"github.com/mbaxamb3/nusli/util"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 // Server struct represents the API server
 type Server struct {
-	store  *db.Store
-	router *gin.Engine
-}
-
-func (server *Server) Start(address string) error {
-	// Start the HTTP server
-	return server.router.Run(address)
+	config    util.Config
+	store     *db.Store
+	authz     *authz.Authorizer         // Who may access which companies, contacts, projects and datasources
+	blobs     blobstore.BlobStore       // Datasource file contents
+	identity  identity.IdentityProvider // Verifies the access tokens of API requests
+	router    *gin.Engine
+	runs      *runRegistry       // Recent datasource processing runs and their progress events
+	lifecycle *lifecycle.Manager // Background jobs, readiness and graceful shutdown
+	logger    *slog.Logger       // For messages outside requests; requests log through their context
+
+	uploadLimits uploadLimits // Largest accepted upload per datasource type
 }
 
-// initializeAuth initializes the authentication components
-func initializeAuth() {
-	// Load AWS SDK Config with region
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(cognitoRegion))
-	if err != nil {
-		fmt.Println("Failed to load AWS SDK config:", err)
-		return
-	}
-	cognitoClient = cognitoidentityprovider.NewFromConfig(awsCfg)
-
-	// Initialize OIDC provider
-	providerCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	provider, err = oidc.NewProvider(providerCtx, cognitoIssuerURL)
-	if err != nil {
-		fmt.Println("Failed to initialize OIDC provider:", err)
-		return
+// Run serves the API until ctx is done, then drains in-flight requests and background
+// jobs for up to SHUTDOWN_TIMEOUT
+func (server *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              server.config.ServerAddress,
+		Handler:           server.router,
+		ReadHeaderTimeout: server.config.ServerReadHeaderTimeout,
+		ReadTimeout:       server.config.ServerReadTimeout,
+		WriteTimeout:      server.config.ServerWriteTimeout,
+		IdleTimeout:       server.config.ServerIdleTimeout,
 	}
+	return server.lifecycle.Run(ctx, srv, server.config.ShutdownTimeout)
+}
 
-	oauth2Config = oauth2.Config{
-		ClientID:     cognitoClientID,
-		ClientSecret: cognitoClientSecret,
-		RedirectURL:  cognitoRedirectURL,
-		Endpoint:     provider.Endpoint(),
-		Scopes:       []string{"openid", "email", "profile", "aws.cognito.signin.user.admin"},
-	}
+// tracedRequest reports whether a request gets a trace span. Probes come every few seconds
+// and would drown out the traces worth looking at.
+func tracedRequest(r *http.Request) bool {
+	return !strings.HasPrefix(r.URL.Path, "/health")
+}
 
-	fmt.Println("Auth initialization completed successfully")
+// recoverPanic answers a request whose handler panicked. Gin has already logged the panic
+// with its stack trace.
+func recoverPanic(ctx *gin.Context, recovered any) {
+	apierror.Abort(ctx, apierror.Internal("Internal server error", fmt.Errorf("panic: %v", recovered)))
 }
 
-func NewServer(store *db.Store) *Server {
+// NewServer creates the API server from validated configuration. It fails when a setting
+// only the API understands, such as UPLOAD_LIMITS, is invalid.
+func NewServer(config util.Config, store *db.Store, blobs blobstore.BlobStore, idp identity.IdentityProvider, logger *slog.Logger) (*Server, error) {
 	server := &Server{
-		store: store,
+		config:    config,
+		store:     store,
+		authz:     authz.New(store),
+		blobs:     blobs,
+		identity:  idp,
+		runs:      newRunRegistry(),
+		lifecycle: lifecycle.New(),
+		logger:    logger,
 	}
 
-	// Initialize authentication systems with hardcoded values
-	initializeAuth()
+	limits, err := parseUploadLimits(config.UploadLimits)
+	if err != nil {
+		return nil, fmt.Errorf("UPLOAD_LIMITS: %w", err)
+	}
+	server.uploadLimits = limits
 
-	// Initialize JWKS with hardcoded Cognito user pool region and ID
-	middleware.InitJWKS(cognitoRegion, cognitoUserPoolID)
+	// Sign-up and login go through Cognito's hosted UI; other providers issue tokens themselves
+	if idp.Name() == identity.ProviderCognito {
+		initializeAuth(config, logger)
+	}
 
-	// Set up Gin router
-	router := gin.Default()
+	// Set up Gin router. Every request gets a trace span, an ID and a logger in its request
+	// context, which handlers pass on to the store and the scrapers.
+	router := gin.New()
+	router.Use(
+		otelgin.Middleware(tracing.ServiceName, otelgin.WithFilter(tracedRequest)),
+		middleware.RequestLogger(logger),
+		middleware.Metrics(),
+		gin.CustomRecovery(recoverPanic),
+	)
+	router.NoRoute(func(ctx *gin.Context) {
+		apierror.Write(ctx, apierror.NotFound("No API route matches "+ctx.Request.URL.Path))
+	})
 
-	// Add CORS middleware
-	router.Use(cors.New(cors.Config{
-		AllowAllOrigins:  true, // For development only - more permissive
+	// Add CORS middleware; "*" in CORS_ALLOWED_ORIGINS allows any origin, for development
+	corsConfig := cors.Config{
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "traceparent", "tracestate"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
-	}))
-
-	// Add this log
-	fmt.Println("CORS middleware configured with AllowAllOrigins: true")
+	}
+	if slices.Contains(config.CORSAllowedOrigins, "*") {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = config.CORSAllowedOrigins
+	}
+	router.Use(cors.New(corsConfig))
 
 	// Public Authentication Routes - accessible without authentication
-	router.POST("/signup", server.handleSignUp)
-	router.POST("/confirm-signup", server.handleConfirmSignUp)
-	router.GET("/login", server.handleLogin)
-	router.GET("/callback", server.handleCallback)
-	router.POST("/refresh-token", server.handleRefreshToken)
+	router.POST("/signup", requireHostedAuth, server.handleSignUp)
+	router.POST("/confirm-signup", requireHostedAuth, server.handleConfirmSignUp)
+	router.GET("/login", requireHostedAuth, server.handleLogin)
+	router.GET("/callback", requireHostedAuth, server.handleCallback)
+	router.POST("/refresh-token", requireHostedAuth, server.handleRefreshToken)
 	router.GET("/logout", server.handleLogout)
 
-	// Health check endpoint
+	// Tokens for local development and integration tests, only with the local provider
+	if local, ok := idp.(*identity.LocalProvider); ok {
+		router.POST("/dev/token", server.issueDevToken(local))
+	}
+
+	// Health check endpoints. Liveness only says the process serves requests; readiness ends
+	// when shutdown begins, so load balancers stop routing here while requests drain.
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"status": "ok",
 		})
 	})
+	router.GET("/healthz/live", server.handleLiveness)
+	router.GET("/healthz/ready", server.handleReadiness)
 
-	// Protected API routes - require authentication
+	// Protected API routes - require authentication with an access token or API token.
+	// API tokens only reach the routes their scopes cover.
 	apiRoutes := router.Group("/api/v1")
-	apiRoutes.Use(middleware.AuthMiddleware()) // Apply auth middleware to all /api/v1 routes
+	apiRoutes.Use(middleware.AuthMiddleware(apiTokenProvider{store: store, next: idp})) // Apply auth middleware to all /api/v1 routes
+	apiRoutes.Use(requireTokenScope)
 
-	// User API routes - Updated to use cognito_sub instead of id
+	// User API routes. Users can only see and change their own account here; acting on
+	// other users goes through the admin routes.
 	userRoutes := apiRoutes.Group("/users")
 	{
-		userRoutes.GET("/", server.getUsers)
-		userRoutes.GET("/:cognito_sub", server.getUserByID) // Changed from :id to :cognito_sub
-		userRoutes.POST("/", server.createUser)
-		userRoutes.PUT("/:cognito_sub", server.updateUser)    // Changed from :id to :cognito_sub
-		userRoutes.DELETE("/:cognito_sub", server.deleteUser) // Changed from :id to :cognito_sub
-		userRoutes.GET("/me", server.getCurrentUser)          // Enabled endpoint to get current user
+		userRoutes.GET("/:cognito_sub", server.getUserByID)
+		userRoutes.PUT("/:cognito_sub", server.updateUser)
+		userRoutes.DELETE("/:cognito_sub", server.deleteUser)
+		userRoutes.GET("/me", server.getCurrentUser)
+	}
+
+	// API tokens of the authenticated user. Tokens can't be used to manage tokens.
+	tokenRoutes := apiRoutes.Group("/tokens")
+	{
+		tokenRoutes.GET("/", server.listAPITokens)
+		tokenRoutes.POST("/", server.createAPIToken)
+		tokenRoutes.DELETE("/:id", server.revokeAPIToken)
+	}
+
+	// Admin API routes. Each route declares the permission it needs; roles come from Cognito
+	// groups and the user_roles table.
+	adminRoutes := apiRoutes.Group("/admin")
+	{
+		adminRoutes.GET("/users", server.requirePermission(authz.PermUsersRead), server.getUsers)
+		adminRoutes.GET("/users/:cognito_sub", server.requirePermission(authz.PermUsersRead), server.adminGetUser)
+		adminRoutes.POST("/users", server.requirePermission(authz.PermUsersWrite), server.createUser)
+		adminRoutes.DELETE("/users/:cognito_sub", server.requirePermission(authz.PermUsersWrite), server.adminDeleteUser)
+
+		adminRoutes.GET("/users/:cognito_sub/roles", server.requirePermission(authz.PermUsersRead), server.listUserRoles)
+		adminRoutes.PUT("/users/:cognito_sub/roles/:role", server.requirePermission(authz.PermRolesManage), server.grantUserRole)
+		adminRoutes.DELETE("/users/:cognito_sub/roles/:role", server.requirePermission(authz.PermRolesManage), server.revokeUserRole)
 	}
 
 	// Company API routes
@@ -147,8 +197,13 @@ func NewServer(store *db.Store) *Server {
 		companyRoutes.DELETE("/:id/datasources/:datasource_id", server.deleteCompanyDatasource)
 
 		// Company paragraphs routes
-		companyRoutes.GET("/:id/paragraphs", server.listCompanyParagraphs)
-		companyRoutes.GET("/:id/paragraphs/search", server.searchCompanyParagraphs)
+		companyRoutes.GET("/:id/paragraphs", server.requireCompanyAccess("id"), server.listCompanyParagraphs)
+		companyRoutes.GET("/:id/paragraphs/search", server.requireCompanyAccess("id"), server.searchCompanyParagraphs)
+
+		// Company facts and enrichment from scraped websites
+		companyRoutes.GET("/:id/facts", server.listCompanyFacts)
+		companyRoutes.GET("/:id/enrichment", server.getCompanyEnrichment)
+		companyRoutes.POST("/:id/enrichment", server.applyCompanyEnrichment)
 	}
 
 	// Contact API routes
@@ -172,23 +227,25 @@ func NewServer(store *db.Store) *Server {
 		contactRoutes.DELETE("/:id/datasources/:datasource_id", server.deleteContactDatasource)
 
 		// Contact paragraphs routes
-		contactRoutes.GET("/:id/paragraphs", server.listContactParagraphs)
-		contactRoutes.GET("/:id/paragraphs/search", server.searchContactParagraphs)
+		contactRoutes.GET("/:id/paragraphs", server.requireContactAccess("id"), server.listContactParagraphs)
+		contactRoutes.GET("/:id/paragraphs/search", server.requireContactAccess("id"), server.searchContactParagraphs)
 	}
 
-	// Shared file upload endpoint for both companies and contacts
+	// Shared file upload endpoints for companies, contacts and projects
 	apiRoutes.POST("/:entity_type/:id/datasources/upload", server.uploadDatasource)
+	apiRoutes.POST("/:entity_type/:id/datasources/archive", server.uploadDatasourceArchive)
 
-	// Paragraphs API routes
+	// Paragraphs API routes. Paragraphs in the URL are only reachable by users who can
+	// access their datasource, and only editors of the datasource can change them.
 	paragraphRoutes := apiRoutes.Group("/paragraphs")
 	{
-		paragraphRoutes.GET("/:id", server.getParagraphByID)
+		paragraphRoutes.GET("/:id", server.requireParagraphAccess("id"), server.getParagraphByID)
 		paragraphRoutes.POST("/", server.createParagraph)
-		paragraphRoutes.PUT("/:id", server.updateParagraph)
-		paragraphRoutes.DELETE("/:id", server.deleteParagraph)
+		paragraphRoutes.PUT("/:id", server.requireParagraphEdit("id"), server.updateParagraph)
+		paragraphRoutes.DELETE("/:id", server.requireParagraphEdit("id"), server.deleteParagraph)
 
 		// Get paragraphs by datasource ID
-		paragraphRoutes.GET("/datasource/:datasource_id", server.listParagraphsByDatasource)
+		paragraphRoutes.GET("/datasource/:datasource_id", server.requireDatasourceAccess("datasource_id"), server.listParagraphsByDatasource)
 	}
 
 	// Project API routes
@@ -199,18 +256,59 @@ func NewServer(store *db.Store) *Server {
 		projectRoutes.POST("/", server.createProject) // This will use cognito_sub from authentication
 		projectRoutes.DELETE("/:id", server.deleteProject)
 		// Project datasources routes
-		projectRoutes.GET("/:id/datasources", server.listDatasourcesByProject)
-		projectRoutes.POST("/:id/datasources", server.createAndAssociateProjectDatasource)
-		projectRoutes.POST("/:id/datasources/associate", server.associateDatasourceWithProject)
-		projectRoutes.DELETE("/:id/datasources/:datasource_id", server.removeDatasourceFromProject)
+		projectRoutes.GET("/:id/datasources", server.requireProjectAccess("id"), server.listDatasourcesByProject)
+		projectRoutes.POST("/:id/datasources", server.requireProjectEdit("id"), server.createAndAssociateProjectDatasource)
+		projectRoutes.POST("/:id/datasources/associate", server.requireProjectEdit("id"), server.associateDatasourceWithProject)
+		projectRoutes.DELETE("/:id/datasources/:datasource_id", server.requireProjectEdit("id"), server.removeDatasourceFromProject)
 	}
 
-	// Datasource processing route
-	apiRoutes.POST("/datasources/:id/process", server.processDatasourceByID)
+	// Datasource routes, only reachable by users who can access the datasource. Routes that
+	// change the datasource or its paragraphs also need edit access.
+	datasourceRoutes := apiRoutes.Group("/datasources/:id")
+	datasourceRoutes.Use(server.requireDatasourceAccess("id"))
+	{
+		// Datasource processing route
+		datasourceRoutes.POST("/process", server.requireDatasourceEdit("id"), server.processDatasourceByID)
+		datasourceRoutes.GET("/runs/:run_id/events", server.streamProcessingRun)
+
+		// Uploaded file of a datasource and a preview of what was extracted from it
+		datasourceRoutes.GET("/file", server.downloadDatasourceFile)
+		datasourceRoutes.GET("/preview", server.previewDatasource)
+
+		// Crawled site tree of a website datasource, as JSON or DOT
+		datasourceRoutes.GET("/sitetree", server.getDatasourceSiteTree)
+
+		// Page discovery and selection for website datasources
+		datasourceRoutes.POST("/discover", server.requireDatasourceEdit("id"), server.discoverDatasourcePages)
+		datasourceRoutes.GET("/selection", server.getDatasourcePageSelection)
+		datasourceRoutes.PUT("/selection", server.requireDatasourceEdit("id"), server.updateDatasourcePageSelection)
+	}
+
+	// Workspace API routes. Members can read a workspace; only owners can change it or
+	// manage its members and invitations.
+	workspaceRoutes := apiRoutes.Group("/workspaces")
+	{
+		workspaceRoutes.GET("/", server.listWorkspaces)
+		workspaceRoutes.POST("/", server.createWorkspace)
+		workspaceRoutes.GET("/:id", server.requireWorkspaceMember("id"), server.getWorkspace)
+		workspaceRoutes.PUT("/:id", server.requireWorkspaceOwner("id"), server.updateWorkspace)
+		workspaceRoutes.DELETE("/:id", server.requireWorkspaceOwner("id"), server.deleteWorkspace)
+
+		// Members; any member may remove themselves
+		workspaceRoutes.GET("/:id/members", server.requireWorkspaceMember("id"), server.listWorkspaceMembers)
+		workspaceRoutes.PUT("/:id/members/:cognito_sub", server.requireWorkspaceOwner("id"), server.updateWorkspaceMember)
+		workspaceRoutes.DELETE("/:id/members/:cognito_sub", server.requireWorkspaceMember("id"), server.removeWorkspaceMember)
+
+		// Invitations by email, accepted by the invited user with the token they were sent
+		workspaceRoutes.GET("/:id/invitations", server.requireWorkspaceOwner("id"), server.listWorkspaceInvitations)
+		workspaceRoutes.POST("/:id/invitations", server.requireWorkspaceOwner("id"), server.createWorkspaceInvitation)
+		workspaceRoutes.DELETE("/:id/invitations/:invitation_id", server.requireWorkspaceOwner("id"), server.deleteWorkspaceInvitation)
+		workspaceRoutes.POST("/invitations/accept", server.acceptWorkspaceInvitation)
+	}
 
 	// Assign configured router to server
 	server.router = router
-	return server
+	return server, nil
 }
 
 // getCurrentUser returns the authenticated user's details
@@ -218,21 +316,29 @@ func (server *Server) getCurrentUser(ctx *gin.Context) {
 	// Get the cognito_sub from the context (added by middleware)
 	cognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Not authenticated"})
+		apierror.Write(ctx, apierror.Unauthorized("Not authenticated"))
 		return
 	}
 
 	// Fetch user information from the database
-	user, err := server.store.GetUserByID(ctx, cognitoSub.(string))
+	user, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub.(string))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apierror.Write(ctx, apierror.NotFound("User not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user data"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user data", err))
+		return
+	}
+
+	// Include the user's roles so clients can tell which admin features to show
+	roles, ok := server.userRoles(ctx)
+	if !ok {
 		return
 	}
 
 	// Return user data using the appropriate conversion function
-	ctx.JSON(http.StatusOK, convertGetUserRowToResponse(user))
+	response := convertGetUserRowToResponse(user)
+	response.Roles = roles
+	ctx.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+
+	"github.com/mbaxamb3/nusli/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/mbaxamb3/nusli/api")
+
+// endSpan ends span, recording err if the operation failed
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// backgroundContext prepares the context of a background job started by a request. The job
+// outlives the request, so it keeps the job's cancellation but logs with the request's ID and
+// continues the request's trace.
+func backgroundContext(jobCtx, requestCtx context.Context) context.Context {
+	ctx := logging.WithLogger(jobCtx, logging.FromContext(requestCtx))
+	return trace.ContextWithSpanContext(ctx, trace.SpanContextFromContext(requestCtx))
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
 	db "github.com/mbaxamb3/nusli/db/sqlc"
 )
 
@@ -14,6 +15,7 @@ import (
 type userResponse struct {
 	CognitoSub string    `json:"cognito_sub"`
 	Username   string    `json:"username"`
+	Roles      []string  `json:"roles,omitempty"` // Application-wide roles, when requested
 	CreatedAt  time.Time `json:"created_at,omitempty"`
 }
 
@@ -101,12 +103,12 @@ func convertUserToResponse(user db.User) userResponse {
 	}
 }
 
-// getUsers handles requests to get all users
+// getUsers handles requests to get all users. It's only routed for admins.
 func (server *Server) getUsers(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	_, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
@@ -131,12 +133,12 @@ func (server *Server) getUsers(ctx *gin.Context) {
 	}
 
 	// Get users from the database using the store
-	users, err := server.store.ListUsers(ctx, db.ListUsersParams{
+	users, err := server.store.ListUsers(ctx.Request.Context(), db.ListUsersParams{
 		Limit:  int32(limit),
 		Offset: int32(offset),
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch users", err))
 		return
 	}
 
@@ -154,28 +156,27 @@ func (server *Server) getUserByID(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	authedCognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
 	// Get cognito_sub from URL param
 	cognitoSub := ctx.Param("cognito_sub")
 
-	// Only allow users to view their own information unless they're admins
-	// TODO: Add admin check if admin functionality is needed
+	// Users can only view their own information; admins use /admin/users
 	if cognitoSub != authedCognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to view this user's information"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to view this user's information"))
 		return
 	}
 
 	// Get user from database using the store
-	user, err := server.store.GetUserByID(ctx, cognitoSub)
+	user, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apierror.Write(ctx, apierror.NotFound("User not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user", err))
 		return
 	}
 
@@ -183,43 +184,44 @@ func (server *Server) getUserByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, convertGetUserRowToResponse(user))
 }
 
-// createUser handles requests to create a new user
+// createUser handles requests to create a new user. It's only routed for admins; users
+// normally get created on signup.
 func (server *Server) createUser(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	_, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
 	// Parse request body
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Check if username already exists
-	_, err := server.store.GetUserByUsername(ctx, req.Username)
+	_, err := server.store.GetUserByUsername(ctx.Request.Context(), req.Username)
 	if err == nil {
 		// User already exists
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Username already exists"})
+		apierror.Write(ctx, apierror.Invalid("Username already exists"))
 		return
 	} else if err != sql.ErrNoRows {
 		// Database error
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check username"})
+		apierror.Write(ctx, apierror.Internal("Failed to check username", err))
 		return
 	}
 
 	// Create user in database
 	// Note: In a real application, you would hash the password here
-	user, err := server.store.CreateUser(ctx, db.CreateUserParams{
+	user, err := server.store.CreateUser(ctx.Request.Context(), db.CreateUserParams{
 		CognitoSub: req.CognitoSub,
 		Username:   req.Username,
 		Password:   req.Password, // This should be hashed in a real application
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		apierror.Write(ctx, apierror.Internal("Failed to create user", err))
 		return
 	}
 
@@ -232,46 +234,45 @@ func (server *Server) updateUser(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	authedCognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
 	// Get cognito_sub from URL param
 	cognitoSub := ctx.Param("cognito_sub")
 
-	// Only allow users to update their own information unless they're admins
-	// TODO: Add admin check if admin functionality is needed
+	// Users can only update their own information
 	if cognitoSub != authedCognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to update this user's information"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to update this user's information"))
 		return
 	}
 
 	// Parse request body
 	var req updateUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Write(ctx, apierror.InvalidRequest(err))
 		return
 	}
 
 	// Check if user exists
-	_, err := server.store.GetUserByID(ctx, cognitoSub)
+	_, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apierror.Write(ctx, apierror.NotFound("User not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user", err))
 		return
 	}
 
 	// Update user password
 	// Note: In a real application, you would hash the password here
-	user, err := server.store.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+	user, err := server.store.UpdateUserPassword(ctx.Request.Context(), db.UpdateUserPasswordParams{
 		CognitoSub: cognitoSub,
 		Password:   req.Password, // This should be hashed in a real application
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		apierror.Write(ctx, apierror.Internal("Failed to update user", err))
 		return
 	}
 
@@ -284,35 +285,34 @@ func (server *Server) deleteUser(ctx *gin.Context) {
 	// Get authenticated user's cognito_sub from context
 	authedCognitoSub, exists := ctx.Get("cognito_sub")
 	if !exists {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized access"})
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
 		return
 	}
 
 	// Get cognito_sub from URL param
 	cognitoSub := ctx.Param("cognito_sub")
 
-	// Only allow users to delete their own account unless they're admins
-	// TODO: Add admin check if admin functionality is needed
+	// Users can only delete their own account; admins use /admin/users
 	if cognitoSub != authedCognitoSub.(string) {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to delete this user"})
+		apierror.Write(ctx, apierror.Forbidden("You don't have permission to delete this user"))
 		return
 	}
 
 	// Check if user exists
-	_, err := server.store.GetUserByID(ctx, cognitoSub)
+	_, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apierror.Write(ctx, apierror.NotFound("User not found"))
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user", err))
 		return
 	}
 
 	// Delete user
-	err = server.store.DeleteUser(ctx, cognitoSub)
+	err = server.store.DeleteUser(ctx.Request.Context(), cognitoSub)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+		apierror.Write(ctx, apierror.Internal("Failed to delete user", err))
 		return
 	}
 
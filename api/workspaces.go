@@ -0,0 +1,583 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/apierror"
+	"github.com/mbaxamb3/nusli/authz"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+)
+
+// invitationTTL is how long a workspace invitation can be accepted
+const invitationTTL = 7 * 24 * time.Hour
+
+// defaultWorkspaceName is used for the workspace created for users who have none
+const defaultWorkspaceName = "Personal workspace"
+
+// workspaceRequest represents the request body for creating or renaming a workspace
+type workspaceRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+}
+
+// workspaceResponse represents the API response structure for workspace data
+type workspaceResponse struct {
+	WorkspaceID int32     `json:"workspace_id"`
+	Name        string    `json:"name"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	Role        string    `json:"role,omitempty"` // The authenticated user's role
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// workspaceMemberRequest represents the request body for changing a member's role
+type workspaceMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+// workspaceMemberResponse represents the API response structure for a workspace member
+type workspaceMemberResponse struct {
+	CognitoSub string    `json:"cognito_sub"`
+	Username   string    `json:"username,omitempty"`
+	Role       string    `json:"role"`
+	CreatedAt  time.Time `json:"created_at,omitempty"`
+}
+
+// createInvitationRequest represents the request body for inviting someone to a workspace
+type createInvitationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner editor viewer"`
+}
+
+// acceptInvitationRequest represents the request body for accepting an invitation
+type acceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// invitationResponse represents the API response structure for an invitation. The token is
+// only set when the invitation is created; it can't be recovered later.
+type invitationResponse struct {
+	InvitationID int32     `json:"invitation_id"`
+	WorkspaceID  int32     `json:"workspace_id"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	InvitedBy    string    `json:"invited_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Token        string    `json:"token,omitempty"`
+}
+
+// convertWorkspaceToResponse converts a database workspace model to an API response
+func convertWorkspaceToResponse(workspace db.Workspace, role db.WorkspaceRole) workspaceResponse {
+	return workspaceResponse{
+		WorkspaceID: workspace.WorkspaceID,
+		Name:        workspace.Name,
+		CreatedBy:   workspace.CreatedBy.String,
+		Role:        string(role),
+		CreatedAt:   workspace.CreatedAt.Time,
+	}
+}
+
+// convertInvitationToResponse converts a database invitation model to an API response
+func convertInvitationToResponse(invitation db.WorkspaceInvitation) invitationResponse {
+	return invitationResponse{
+		InvitationID: invitation.InvitationID,
+		WorkspaceID:  invitation.WorkspaceID,
+		Email:        invitation.Email,
+		Role:         string(invitation.Role),
+		InvitedBy:    invitation.InvitedBy.String,
+		CreatedAt:    invitation.CreatedAt.Time,
+		ExpiresAt:    invitation.ExpiresAt,
+	}
+}
+
+// createWorkspace handles requests to create a workspace owned by the authenticated user
+func (server *Server) createWorkspace(ctx *gin.Context) {
+	var req workspaceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	workspace, err := server.store.CreateWorkspaceTx(ctx.Request.Context(), db.CreateWorkspaceTxParams{
+		Name:       req.Name,
+		CognitoSub: cognitoSub.(string),
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to create workspace", err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, convertWorkspaceToResponse(workspace, db.WorkspaceRoleOwner))
+}
+
+// listWorkspaces handles requests to list the workspaces the authenticated user belongs to
+func (server *Server) listWorkspaces(ctx *gin.Context) {
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	workspaces, err := server.store.ListWorkspacesByMember(ctx.Request.Context(), cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch workspaces", err))
+		return
+	}
+
+	responses := make([]workspaceResponse, len(workspaces))
+	for i, workspace := range workspaces {
+		responses[i] = convertWorkspaceToResponse(db.Workspace{
+			WorkspaceID: workspace.WorkspaceID,
+			Name:        workspace.Name,
+			CreatedBy:   workspace.CreatedBy,
+			CreatedAt:   workspace.CreatedAt,
+		}, workspace.Role)
+	}
+
+	ctx.JSON(http.StatusOK, responses)
+}
+
+// getWorkspace handles requests to get a workspace the authenticated user belongs to
+func (server *Server) getWorkspace(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	workspace, err := server.store.GetWorkspaceByID(ctx.Request.Context(), workspaceID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("Workspace not found"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch workspace", err))
+		return
+	}
+
+	role, err := server.authz.WorkspaceRole(ctx.Request.Context(), ctx.GetString("cognito_sub"), workspaceID)
+	if err != nil {
+		respondAccessError(ctx, err, "workspace")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convertWorkspaceToResponse(workspace, role))
+}
+
+// updateWorkspace handles requests to rename a workspace
+func (server *Server) updateWorkspace(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req workspaceRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+
+	workspace, err := server.store.UpdateWorkspace(ctx.Request.Context(), db.UpdateWorkspaceParams{
+		WorkspaceID: workspaceID,
+		Name:        req.Name,
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to update workspace", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, convertWorkspaceToResponse(workspace, db.WorkspaceRoleOwner))
+}
+
+// deleteWorkspace handles requests to delete an empty workspace
+func (server *Server) deleteWorkspace(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	// Companies and projects would lose every member with access, so they have to be
+	// deleted or moved first
+	items, err := server.store.CountWorkspaceItems(ctx.Request.Context(), sql.NullInt32{Int32: workspaceID, Valid: true})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to check workspace contents", err))
+		return
+	}
+	if items > 0 {
+		apierror.Write(ctx, apierror.Conflict("Workspace still contains companies, projects, briefs or sales processes"))
+		return
+	}
+
+	if err := server.store.DeleteWorkspace(ctx.Request.Context(), workspaceID); err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to delete workspace", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Workspace deleted successfully"})
+}
+
+// listWorkspaceMembers handles requests to list the members of a workspace
+func (server *Server) listWorkspaceMembers(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	members, err := server.store.ListWorkspaceMembers(ctx.Request.Context(), workspaceID)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch workspace members", err))
+		return
+	}
+
+	responses := make([]workspaceMemberResponse, len(members))
+	for i, member := range members {
+		responses[i] = workspaceMemberResponse{
+			CognitoSub: member.CognitoSub,
+			Username:   member.Username,
+			Role:       string(member.Role),
+			CreatedAt:  member.CreatedAt.Time,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, responses)
+}
+
+// updateWorkspaceMember handles requests to change the role of a workspace member
+func (server *Server) updateWorkspaceMember(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+	memberSub := ctx.Param("cognito_sub")
+
+	var req workspaceMemberRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+	role := db.WorkspaceRole(req.Role)
+
+	if role != db.WorkspaceRoleOwner && !server.keepsAnOwner(ctx, workspaceID, memberSub) {
+		return
+	}
+
+	member, err := server.store.UpdateWorkspaceMemberRole(ctx.Request.Context(), db.UpdateWorkspaceMemberRoleParams{
+		WorkspaceID: workspaceID,
+		CognitoSub:  memberSub,
+		Role:        role,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("Workspace member not found"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to update workspace member", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, workspaceMemberResponse{
+		CognitoSub: member.CognitoSub,
+		Role:       string(member.Role),
+		CreatedAt:  member.CreatedAt.Time,
+	})
+}
+
+// removeWorkspaceMember handles requests to remove a member from a workspace. Owners can
+// remove anyone and every member can remove themselves.
+func (server *Server) removeWorkspaceMember(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+	cognitoSub := ctx.GetString("cognito_sub")
+	memberSub := ctx.Param("cognito_sub")
+
+	if memberSub != cognitoSub {
+		if err := server.authz.CanManageWorkspace(ctx.Request.Context(), cognitoSub, workspaceID); err != nil {
+			respondManageError(ctx, err, "workspace")
+			return
+		}
+	}
+
+	if !server.keepsAnOwner(ctx, workspaceID, memberSub) {
+		return
+	}
+
+	err := server.store.RemoveWorkspaceMember(ctx.Request.Context(), db.RemoveWorkspaceMemberParams{
+		WorkspaceID: workspaceID,
+		CognitoSub:  memberSub,
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to remove workspace member", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Workspace member removed successfully"})
+}
+
+// createWorkspaceInvitation handles requests to invite someone to a workspace by email
+func (server *Server) createWorkspaceInvitation(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	var req createInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to create invitation", err))
+		return
+	}
+
+	invitation, err := server.store.CreateWorkspaceInvitation(ctx.Request.Context(), db.CreateWorkspaceInvitationParams{
+		WorkspaceID: workspaceID,
+		Email:       strings.ToLower(req.Email),
+		Role:        db.WorkspaceRole(req.Role),
+		TokenHash:   hashInvitationToken(token),
+		InvitedBy:   sql.NullString{String: ctx.GetString("cognito_sub"), Valid: true},
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to create invitation", err))
+		return
+	}
+
+	response := convertInvitationToResponse(invitation)
+	response.Token = token
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// listWorkspaceInvitations handles requests to list the pending invitations of a workspace
+func (server *Server) listWorkspaceInvitations(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	invitations, err := server.store.ListPendingWorkspaceInvitations(ctx.Request.Context(), workspaceID)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch invitations", err))
+		return
+	}
+
+	responses := make([]invitationResponse, len(invitations))
+	for i, invitation := range invitations {
+		responses[i] = convertInvitationToResponse(invitation)
+	}
+
+	ctx.JSON(http.StatusOK, responses)
+}
+
+// deleteWorkspaceInvitation handles requests to revoke an invitation
+func (server *Server) deleteWorkspaceInvitation(ctx *gin.Context) {
+	workspaceID, ok := workspaceIDParam(ctx)
+	if !ok {
+		return
+	}
+
+	invitationID, err := strconv.ParseInt(ctx.Param("invitation_id"), 10, 32)
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid invitation ID format"))
+		return
+	}
+
+	err = server.store.DeleteWorkspaceInvitation(ctx.Request.Context(), db.DeleteWorkspaceInvitationParams{
+		InvitationID: int32(invitationID),
+		WorkspaceID:  workspaceID,
+	})
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to delete invitation", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invitation deleted successfully"})
+}
+
+// acceptWorkspaceInvitation handles requests to join a workspace with an invitation token.
+// The invitation must have been sent to the authenticated user's email address.
+func (server *Server) acceptWorkspaceInvitation(ctx *gin.Context) {
+	var req acceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		apierror.Write(ctx, apierror.InvalidRequest(err))
+		return
+	}
+
+	// Get authenticated user's cognito_sub from context
+	cognitoSub, exists := ctx.Get("cognito_sub")
+	if !exists {
+		apierror.Write(ctx, apierror.Unauthorized("Unauthorized access"))
+		return
+	}
+
+	invitation, err := server.store.GetWorkspaceInvitationByTokenHash(ctx.Request.Context(), hashInvitationToken(req.Token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("Invitation not found"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch invitation", err))
+		return
+	}
+	if invitation.AcceptedAt.Valid {
+		apierror.Write(ctx, apierror.Conflict("Invitation has already been used"))
+		return
+	}
+	if time.Now().After(invitation.ExpiresAt) {
+		apierror.Write(ctx, apierror.New(http.StatusGone, apierror.CodeGone, "Invitation has expired"))
+		return
+	}
+
+	// Usernames are the email addresses users signed up with
+	user, err := server.store.GetUserByID(ctx.Request.Context(), cognitoSub.(string))
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to fetch user", err))
+		return
+	}
+	if !strings.EqualFold(user.Username, invitation.Email) {
+		apierror.Write(ctx, apierror.Forbidden("This invitation was sent to a different email address"))
+		return
+	}
+
+	member, err := server.store.AcceptWorkspaceInvitationTx(ctx.Request.Context(), invitation.InvitationID, cognitoSub.(string))
+	if err != nil {
+		if errors.Is(err, db.ErrInvitationUsed) {
+			apierror.Write(ctx, apierror.Conflict("Invitation has already been used"))
+			return
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to accept invitation", err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"workspace_id": member.WorkspaceID,
+		"role":         member.Role,
+	})
+}
+
+// resolveWorkspace picks the workspace a new company or project goes into: the requested one
+// if the user may edit it, otherwise the user's default workspace, which is created for
+// users who don't own one yet. It writes the error response and returns false when the
+// request should stop.
+func (server *Server) resolveWorkspace(ctx *gin.Context, cognitoSub string, requested *int32) (sql.NullInt32, bool) {
+	if requested != nil {
+		if err := server.authz.CanEditWorkspace(ctx.Request.Context(), cognitoSub, *requested); err != nil {
+			respondEditError(ctx, err, "workspace")
+			return sql.NullInt32{}, false
+		}
+		return sql.NullInt32{Int32: *requested, Valid: true}, true
+	}
+
+	workspace, err := server.store.GetDefaultWorkspace(ctx.Request.Context(), cognitoSub)
+	if err == sql.ErrNoRows {
+		workspace, err = server.store.CreateWorkspaceTx(ctx.Request.Context(), db.CreateWorkspaceTxParams{
+			Name:       defaultWorkspaceName,
+			CognitoSub: cognitoSub,
+		})
+	}
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to resolve workspace", err))
+		return sql.NullInt32{}, false
+	}
+	return sql.NullInt32{Int32: workspace.WorkspaceID, Valid: true}, true
+}
+
+// workspaceFilter reads the optional workspace_id query parameter of list endpoints. It
+// writes the error response and returns false when the parameter is malformed.
+func workspaceFilter(ctx *gin.Context) (sql.NullInt32, bool) {
+	param := ctx.Query("workspace_id")
+	if param == "" {
+		return sql.NullInt32{}, true
+	}
+	id, err := strconv.ParseInt(param, 10, 32)
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid workspace ID format"))
+		return sql.NullInt32{}, false
+	}
+	return sql.NullInt32{Int32: int32(id), Valid: true}, true
+}
+
+// workspaceIDParam parses the workspace ID from the URL. Access has already been checked by
+// the route middleware.
+func workspaceIDParam(ctx *gin.Context) (int32, bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 32)
+	if err != nil {
+		apierror.Write(ctx, apierror.Invalid("Invalid workspace ID format"))
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// keepsAnOwner checks that demoting or removing a member doesn't leave the workspace without
+// an owner. It writes the error response and returns false when it would.
+func (server *Server) keepsAnOwner(ctx *gin.Context, workspaceID int32, memberSub string) bool {
+	member, err := server.store.GetWorkspaceMember(ctx.Request.Context(), db.GetWorkspaceMemberParams{
+		WorkspaceID: workspaceID,
+		CognitoSub:  memberSub,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			apierror.Write(ctx, apierror.NotFound("Workspace member not found"))
+			return false
+		}
+		apierror.Write(ctx, apierror.Internal("Failed to fetch workspace member", err))
+		return false
+	}
+	if member.Role != db.WorkspaceRoleOwner {
+		return true
+	}
+
+	owners, err := server.store.CountWorkspaceOwners(ctx.Request.Context(), workspaceID)
+	if err != nil {
+		apierror.Write(ctx, apierror.Internal("Failed to count workspace owners", err))
+		return false
+	}
+	if owners <= 1 {
+		apierror.Write(ctx, apierror.Conflict("A workspace must keep at least one owner"))
+		return false
+	}
+	return true
+}
+
+// respondManageError writes the response for a failed check on managing a workspace
+func respondManageError(ctx *gin.Context, err error, resource string) {
+	if errors.Is(err, authz.ErrForbidden) {
+		apierror.Write(ctx, apierror.Forbidden("Only owners can manage this "+resource))
+		return
+	}
+	respondAccessError(ctx, err, resource)
+}
+
+// newInvitationToken generates the secret a user needs to accept an invitation
+func newInvitationToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashInvitationToken returns the form of an invitation token that is stored
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
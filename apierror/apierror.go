@@ -0,0 +1,125 @@
+// Package apierror defines the errors the API returns to clients. Every error has an HTTP
+// status, a stable machine-readable code and a message meant for users, and is sent as an
+// RFC 7807 problem document. The internal cause of an error is logged but never sent.
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Code identifies the kind of an error. Codes are part of the API and don't change, unlike
+// the messages that come with them.
+type Code string
+
+const (
+	CodeValidationFailed      Code = "VALIDATION_FAILED"       // The request is malformed or has invalid fields
+	CodeUnauthorized          Code = "UNAUTHORIZED"            // No valid credentials came with the request
+	CodeForbidden             Code = "FORBIDDEN"               // The user or token may not do this
+	CodeNotFound              Code = "NOT_FOUND"               // The resource doesn't exist or isn't visible to the user
+	CodeConflict              Code = "CONFLICT"                // The request conflicts with the current state of the resource
+	CodeGone                  Code = "GONE"                    // The resource existed but can no longer be used
+	CodePayloadTooLarge       Code = "PAYLOAD_TOO_LARGE"       // An upload exceeds a size or count limit
+	CodeUnsupportedFileFormat Code = "UNSUPPORTED_FILE_FORMAT" // An uploaded file is in a format the server can't process
+	CodeUnsupportedSourceType Code = "UNSUPPORTED_SOURCE_TYPE" // The datasource type doesn't support the operation
+	CodeUpstreamFailed        Code = "UPSTREAM_FAILED"         // A website or service the server relies on failed
+	CodeNotImplemented        Code = "NOT_IMPLEMENTED"         // The operation isn't available on this server
+	CodeUnavailable           Code = "SERVICE_UNAVAILABLE"     // The server can't take the request right now, e.g. during shutdown
+	CodeInternal              Code = "INTERNAL"                // Something failed on the server
+)
+
+// Error is an error to return to an API client
+type Error struct {
+	Status     int            // HTTP status code
+	Code       Code           // Stable code for clients to act on
+	Message    string         // Explanation for users; sent as the problem detail
+	Fields     []FieldError   // Invalid request fields, for validation errors
+	Extensions map[string]any // Additional members of the problem document, e.g. the supported values
+	Cause      error          // What went wrong internally; logged, never sent
+}
+
+// FieldError describes an invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`   // JSON name of the field; nested fields are joined with dots, e.g. "scopes[0]"
+	Reason  string `json:"reason"`  // Rule the value broke, e.g. "required", "max" or "type"
+	Message string `json:"message"` // Explanation for users
+}
+
+// New creates an error with any status and code
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Invalid creates a validation error, optionally naming the invalid fields
+func Invalid(message string, fields ...FieldError) *Error {
+	return &Error{Status: http.StatusBadRequest, Code: CodeValidationFailed, Message: message, Fields: fields}
+}
+
+// Unauthorized creates an error for a request without valid credentials
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden creates an error for a request the user isn't allowed to make
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound creates an error for a missing resource
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict creates an error for a request that conflicts with the state of a resource
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// TooLarge creates an error for an upload over a limit
+func TooLarge(message string) *Error {
+	return New(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, message)
+}
+
+// UnsupportedSourceType creates an error for an operation the datasource type doesn't support
+func UnsupportedSourceType(message string) *Error {
+	return New(http.StatusBadRequest, CodeUnsupportedSourceType, message)
+}
+
+// Unavailable creates an error for a request the server can't take right now
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// Internal creates an error for a failure on the server. cause is logged; clients only get
+// the message.
+func Internal(message string, cause error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: message, Cause: cause}
+}
+
+// WithCause records the internal cause of the error
+func (e *Error) WithCause(cause error) *Error {
+	e.Cause = cause
+	return e
+}
+
+// With adds a member to the problem document
+func (e *Error) With(key string, value any) *Error {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any)
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+// Error describes the error for logs, including its cause
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Unwrap returns the cause of the error
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
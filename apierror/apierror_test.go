@@ -0,0 +1,105 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs handler for one request and returns the response
+func serve(t *testing.T, handler gin.HandlerFunc, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/things", handler)
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func decode(t *testing.T, recorder *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	require.Equal(t, ContentType, recorder.Header().Get("Content-Type"))
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	return doc
+}
+
+func TestWrite(t *testing.T) {
+	recorder := serve(t, func(ctx *gin.Context) {
+		Write(ctx, NotFound("Company not found").With("company_id", 7))
+	}, "")
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+	require.Equal(t, map[string]any{
+		"type":       "about:blank",
+		"title":      "Not Found",
+		"status":     float64(404),
+		"detail":     "Company not found",
+		"instance":   "/things",
+		"code":       "NOT_FOUND",
+		"company_id": float64(7),
+	}, decode(t, recorder))
+}
+
+func TestWriteHidesCauses(t *testing.T) {
+	secret := errors.New("pq: password authentication failed for user \"nusli\"")
+
+	recorder := serve(t, func(ctx *gin.Context) {
+		Write(ctx, Internal("Failed to fetch company", secret))
+	}, "")
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), "password")
+	require.Equal(t, "INTERNAL", decode(t, recorder)["code"])
+
+	// Plain errors are internal errors
+	recorder = serve(t, func(ctx *gin.Context) {
+		Write(ctx, secret)
+	}, "")
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	require.NotContains(t, recorder.Body.String(), "password")
+}
+
+func TestInvalidRequest(t *testing.T) {
+	type request struct {
+		Email  string   `json:"email" binding:"required,email"`
+		Name   string   `json:"full_name" binding:"required,max=5"`
+		Scopes []string `json:"scopes" binding:"required,min=1"`
+		Age    int      `json:"age"`
+	}
+	bind := func(ctx *gin.Context) {
+		var req request
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			Write(ctx, InvalidRequest(err))
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	}
+
+	recorder := serve(t, bind, `{"email": "nope", "full_name": "Jane Doe", "scopes": []}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	doc := decode(t, recorder)
+	require.Equal(t, "VALIDATION_FAILED", doc["code"])
+	require.Equal(t, []any{
+		map[string]any{"field": "email", "reason": "email", "message": "must be an email address"},
+		map[string]any{"field": "full_name", "reason": "max", "message": "must be at most 5 characters long"},
+		map[string]any{"field": "scopes", "reason": "min", "message": "must have at least 1 items"},
+	}, doc["errors"])
+
+	recorder = serve(t, bind, `{"email": "jane@example.com", "full_name": "Jane", "scopes": ["read"], "age": "old"}`)
+	doc = decode(t, recorder)
+	require.Equal(t, []any{
+		map[string]any{"field": "age", "reason": "type", "message": "must be an integer"},
+	}, doc["errors"])
+
+	recorder = serve(t, bind, `{"email": `)
+	require.Equal(t, "The request body isn't valid JSON", decode(t, recorder)["detail"])
+}
@@ -0,0 +1,105 @@
+package apierror
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mbaxamb3/nusli/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContentType is the media type of problem documents
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem document. The type is always "about:blank", so the title
+// is the status text; Code tells errors with the same status apart.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"` // Path of the request
+	Code      Code         `json:"code"`
+	RequestID string       `json:"request_id,omitempty"` // For matching the error to the server's logs
+	Errors    []FieldError `json:"errors,omitempty"`     // Invalid fields
+}
+
+// problem returns the problem document of e for the request to path
+func (e *Error) problem(path, requestID string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Message,
+		Instance:  path,
+		Code:      e.Code,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	}
+}
+
+// document returns the problem document with its extension members
+func (e *Error) document(path, requestID string) any {
+	p := e.problem(path, requestID)
+	if len(e.Extensions) == 0 {
+		return p
+	}
+
+	doc := gin.H{}
+	for key, value := range e.Extensions {
+		doc[key] = value
+	}
+	// The standard members can't be overridden
+	doc["type"], doc["title"], doc["status"], doc["code"] = p.Type, p.Title, p.Status, p.Code
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	}
+	if p.RequestID != "" {
+		doc["request_id"] = p.RequestID
+	}
+	if len(p.Errors) > 0 {
+		doc["errors"] = p.Errors
+	}
+	return doc
+}
+
+// Write sends err as a problem document. Errors that aren't an *Error become internal errors
+// without details. Server errors and the causes of client errors are logged, and the causes
+// of server errors are recorded on the request's span.
+func Write(ctx *gin.Context, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		apiErr = Internal("Internal server error", err)
+	}
+
+	requestCtx := ctx.Request.Context()
+	serverError := apiErr.Status >= http.StatusInternalServerError
+	if apiErr.Cause != nil || serverError {
+		level := slog.LevelInfo
+		if serverError {
+			level = slog.LevelError
+		}
+		args := []any{"code", apiErr.Code, "status", apiErr.Status}
+		if apiErr.Cause != nil {
+			args = append(args, "error", apiErr.Cause)
+			if serverError {
+				trace.SpanFromContext(requestCtx).RecordError(apiErr.Cause)
+			}
+		}
+		logging.FromContext(requestCtx).Log(requestCtx, level, apiErr.Message, args...)
+	}
+
+	ctx.Header("Content-Type", ContentType)
+	ctx.JSON(apiErr.Status, apiErr.document(ctx.Request.URL.Path, logging.RequestID(requestCtx)))
+}
+
+// Abort sends err like Write and stops the remaining handlers of the request
+func Abort(ctx *gin.Context, err error) {
+	ctx.Abort()
+	Write(ctx, err)
+}
@@ -38,7 +38,7 @@ func ConvertToWAV(inputPath string, params WhisperCompatibleWAVParams) (string,
 	}
 
 	// Check if ffmpeg is available
-	if err := checkFFmpeg(); err != nil {
+	if err := CheckFFmpeg(); err != nil {
 		return "", err
 	}
 
@@ -77,8 +77,8 @@ func ConvertToWAVWithDefaultParams(inputPath string) (string, error) {
 	return ConvertToWAV(inputPath, DefaultWhisperParams())
 }
 
-// checkFFmpeg verifies that ffmpeg is installed and available
-func checkFFmpeg() error {
+// CheckFFmpeg verifies that ffmpeg is installed and available
+func CheckFFmpeg() error {
 	cmd := exec.Command("ffmpeg", "-version")
 	if err := cmd.Run(); err != nil {
 		return errors.New("ffmpeg is not installed or not found in PATH")
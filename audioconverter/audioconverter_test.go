@@ -95,7 +95,7 @@ func TestConvertToWAV(t *testing.T) {
 
 	// Test FFmpeg check
 	t.Run("FFmpegCheck", func(t *testing.T) {
-		err := checkFFmpeg()
+		err := CheckFFmpeg()
 		if err != nil {
 			t.Logf("FFmpeg check failed: %v", err)
 		} else {
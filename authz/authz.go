@@ -0,0 +1,252 @@
+// Package authz decides which users may access companies, contacts, projects, datasources
+// and paragraphs. Companies and projects belong to a workspace, and users get access
+// through their membership role in it: viewers can read, editors can also change data and
+// owners can also manage the workspace itself. Contacts belong to the workspace of their
+// company. Datasources have no workspace of their own: a user's role on a datasource is the
+// highest role they hold in any workspace whose companies, contacts or projects the
+// datasource is associated with, and a paragraph inherits the role of its datasource.
+package authz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+)
+
+var (
+	// ErrNotFound is returned when the resource doesn't exist
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when the resource exists but the user may not access it
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Store is the part of the database the authorizer reads workspaces and memberships from
+type Store interface {
+	GetCompanyByID(ctx context.Context, companyID int32) (db.GetCompanyByIDRow, error)
+	GetContactByID(ctx context.Context, contactID int32) (db.Contact, error)
+	GetProjectByID(ctx context.Context, projectID int32) (db.GetProjectByIDRow, error)
+	GetDatasourceByID(ctx context.Context, datasourceID int32) (db.GetDatasourceByIDRow, error)
+	GetParagraphByID(ctx context.Context, paragraphID int32) (db.Paragraph, error)
+	GetWorkspaceByID(ctx context.Context, workspaceID int32) (db.Workspace, error)
+	GetWorkspaceMember(ctx context.Context, arg db.GetWorkspaceMemberParams) (db.WorkspaceMember, error)
+	ListDatasourceMembers(ctx context.Context, datasourceID int32) ([]db.ListDatasourceMembersRow, error)
+}
+
+// Authorizer answers access questions for the API handlers. Every check returns nil when
+// access is allowed, ErrNotFound or ErrForbidden when it isn't, and any other error when
+// memberships couldn't be looked up.
+type Authorizer struct {
+	store Store
+}
+
+// New creates an authorizer reading memberships from store
+func New(store Store) *Authorizer {
+	return &Authorizer{store: store}
+}
+
+// rank orders roles so that a higher role includes everything a lower one may do. Users
+// without a role rank below viewers.
+func rank(role db.WorkspaceRole) int {
+	switch role {
+	case db.WorkspaceRoleOwner:
+		return 3
+	case db.WorkspaceRoleEditor:
+		return 2
+	case db.WorkspaceRoleViewer:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports whether role includes the permissions of min
+func AtLeast(role, min db.WorkspaceRole) bool {
+	return rank(role) > 0 && rank(role) >= rank(min)
+}
+
+// WorkspaceRole returns the role of a user in a workspace, or "" when they aren't a member
+func (a *Authorizer) WorkspaceRole(ctx context.Context, cognitoSub string, workspaceID int32) (db.WorkspaceRole, error) {
+	if _, err := a.store.GetWorkspaceByID(ctx, workspaceID); err != nil {
+		return "", lookupError("workspace", workspaceID, err)
+	}
+	return a.memberRole(ctx, cognitoSub, sql.NullInt32{Int32: workspaceID, Valid: true})
+}
+
+// CompanyRole returns the role of a user in the workspace of a company
+func (a *Authorizer) CompanyRole(ctx context.Context, cognitoSub string, companyID int32) (db.WorkspaceRole, error) {
+	company, err := a.store.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return "", lookupError("company", companyID, err)
+	}
+	return a.memberRole(ctx, cognitoSub, company.WorkspaceID)
+}
+
+// ContactRole returns the role of a user in the workspace of a contact's company
+func (a *Authorizer) ContactRole(ctx context.Context, cognitoSub string, contactID int32) (db.WorkspaceRole, error) {
+	contact, err := a.store.GetContactByID(ctx, contactID)
+	if err != nil {
+		return "", lookupError("contact", contactID, err)
+	}
+	return a.CompanyRole(ctx, cognitoSub, contact.CompanyID)
+}
+
+// ProjectRole returns the role of a user in the workspace of a project
+func (a *Authorizer) ProjectRole(ctx context.Context, cognitoSub string, projectID int32) (db.WorkspaceRole, error) {
+	project, err := a.store.GetProjectByID(ctx, projectID)
+	if err != nil {
+		return "", lookupError("project", projectID, err)
+	}
+	return a.memberRole(ctx, cognitoSub, project.WorkspaceID)
+}
+
+// DatasourceRole returns the highest role a user holds in the workspaces a datasource is
+// associated with. A datasource that isn't associated with anything can't be accessed by
+// anyone.
+func (a *Authorizer) DatasourceRole(ctx context.Context, cognitoSub string, datasourceID int32) (db.WorkspaceRole, error) {
+	members, err := a.DatasourceMembers(ctx, datasourceID)
+	if err != nil {
+		return "", err
+	}
+
+	var role db.WorkspaceRole
+	for _, member := range members {
+		if cognitoSub != "" && member.CognitoSub == cognitoSub && rank(member.Role) > rank(role) {
+			role = member.Role
+		}
+	}
+	return role, nil
+}
+
+// DatasourceMembers returns every user who can access a datasource with their roles. It
+// tells a missing datasource apart from one nobody can access.
+func (a *Authorizer) DatasourceMembers(ctx context.Context, datasourceID int32) ([]db.ListDatasourceMembersRow, error) {
+	members, err := a.store.ListDatasourceMembers(ctx, datasourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members of datasource %d: %w", datasourceID, err)
+	}
+	if len(members) > 0 {
+		return members, nil
+	}
+
+	// No members; tell a missing datasource apart from an orphaned one
+	if _, err := a.store.GetDatasourceByID(ctx, datasourceID); err != nil {
+		return nil, lookupError("datasource", datasourceID, err)
+	}
+	return nil, nil
+}
+
+// ParagraphRole returns the role of a user on the datasource of a paragraph
+func (a *Authorizer) ParagraphRole(ctx context.Context, cognitoSub string, paragraphID int32) (db.WorkspaceRole, error) {
+	paragraph, err := a.store.GetParagraphByID(ctx, paragraphID)
+	if err != nil {
+		return "", lookupError("paragraph", paragraphID, err)
+	}
+	return a.DatasourceRole(ctx, cognitoSub, paragraph.DatasourceID)
+}
+
+// CanAccessWorkspace checks that a user is a member of a workspace
+func (a *Authorizer) CanAccessWorkspace(ctx context.Context, cognitoSub string, workspaceID int32) error {
+	return require(a.WorkspaceRole(ctx, cognitoSub, workspaceID))(db.WorkspaceRoleViewer)
+}
+
+// CanEditWorkspace checks that a user may add and change data in a workspace
+func (a *Authorizer) CanEditWorkspace(ctx context.Context, cognitoSub string, workspaceID int32) error {
+	return require(a.WorkspaceRole(ctx, cognitoSub, workspaceID))(db.WorkspaceRoleEditor)
+}
+
+// CanManageWorkspace checks that a user owns a workspace and may manage its members
+func (a *Authorizer) CanManageWorkspace(ctx context.Context, cognitoSub string, workspaceID int32) error {
+	return require(a.WorkspaceRole(ctx, cognitoSub, workspaceID))(db.WorkspaceRoleOwner)
+}
+
+// CanAccessCompany checks that a user may read a company
+func (a *Authorizer) CanAccessCompany(ctx context.Context, cognitoSub string, companyID int32) error {
+	return require(a.CompanyRole(ctx, cognitoSub, companyID))(db.WorkspaceRoleViewer)
+}
+
+// CanEditCompany checks that a user may change a company
+func (a *Authorizer) CanEditCompany(ctx context.Context, cognitoSub string, companyID int32) error {
+	return require(a.CompanyRole(ctx, cognitoSub, companyID))(db.WorkspaceRoleEditor)
+}
+
+// CanAccessContact checks that a user may read a contact
+func (a *Authorizer) CanAccessContact(ctx context.Context, cognitoSub string, contactID int32) error {
+	return require(a.ContactRole(ctx, cognitoSub, contactID))(db.WorkspaceRoleViewer)
+}
+
+// CanEditContact checks that a user may change a contact
+func (a *Authorizer) CanEditContact(ctx context.Context, cognitoSub string, contactID int32) error {
+	return require(a.ContactRole(ctx, cognitoSub, contactID))(db.WorkspaceRoleEditor)
+}
+
+// CanAccessProject checks that a user may read a project
+func (a *Authorizer) CanAccessProject(ctx context.Context, cognitoSub string, projectID int32) error {
+	return require(a.ProjectRole(ctx, cognitoSub, projectID))(db.WorkspaceRoleViewer)
+}
+
+// CanEditProject checks that a user may change a project
+func (a *Authorizer) CanEditProject(ctx context.Context, cognitoSub string, projectID int32) error {
+	return require(a.ProjectRole(ctx, cognitoSub, projectID))(db.WorkspaceRoleEditor)
+}
+
+// CanAccessDatasource checks that a user may read a datasource
+func (a *Authorizer) CanAccessDatasource(ctx context.Context, cognitoSub string, datasourceID int32) error {
+	return require(a.DatasourceRole(ctx, cognitoSub, datasourceID))(db.WorkspaceRoleViewer)
+}
+
+// CanEditDatasource checks that a user may process, change or associate a datasource
+func (a *Authorizer) CanEditDatasource(ctx context.Context, cognitoSub string, datasourceID int32) error {
+	return require(a.DatasourceRole(ctx, cognitoSub, datasourceID))(db.WorkspaceRoleEditor)
+}
+
+// CanAccessParagraph checks that a user may read a paragraph
+func (a *Authorizer) CanAccessParagraph(ctx context.Context, cognitoSub string, paragraphID int32) error {
+	return require(a.ParagraphRole(ctx, cognitoSub, paragraphID))(db.WorkspaceRoleViewer)
+}
+
+// CanEditParagraph checks that a user may change a paragraph
+func (a *Authorizer) CanEditParagraph(ctx context.Context, cognitoSub string, paragraphID int32) error {
+	return require(a.ParagraphRole(ctx, cognitoSub, paragraphID))(db.WorkspaceRoleEditor)
+}
+
+// memberRole looks up the role of a user in a workspace. Resources outside any workspace and
+// empty users have no role, so they stay inaccessible.
+func (a *Authorizer) memberRole(ctx context.Context, cognitoSub string, workspaceID sql.NullInt32) (db.WorkspaceRole, error) {
+	if cognitoSub == "" || !workspaceID.Valid {
+		return "", nil
+	}
+	member, err := a.store.GetWorkspaceMember(ctx, db.GetWorkspaceMemberParams{
+		WorkspaceID: workspaceID.Int32,
+		CognitoSub:  cognitoSub,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch membership in workspace %d: %w", workspaceID.Int32, err)
+	}
+	return member.Role, nil
+}
+
+// require turns the result of a role lookup into a check for a minimum role
+func require(role db.WorkspaceRole, err error) func(min db.WorkspaceRole) error {
+	return func(min db.WorkspaceRole) error {
+		if err != nil {
+			return err
+		}
+		if !AtLeast(role, min) {
+			return ErrForbidden
+		}
+		return nil
+	}
+}
+
+// lookupError turns a missing row into ErrNotFound and wraps anything else
+func lookupError(resource string, id int32, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s %d: %w", resource, id, ErrNotFound)
+	}
+	return fmt.Errorf("failed to fetch %s %d: %w", resource, id, err)
+}
@@ -0,0 +1,335 @@
+package authz
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"testing"
+
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+)
+
+// fakeStore holds the workspaces of a few tenants in memory
+type fakeStore struct {
+	companies   map[int32]int32 // company ID -> workspace ID, 0 for none
+	contacts    map[int32]int32 // contact ID -> company ID
+	projects    map[int32]int32 // project ID -> workspace ID, 0 for none
+	datasources map[int32]bool
+	paragraphs  map[int32]int32                       // paragraph ID -> datasource ID
+	members     map[int32]map[string]db.WorkspaceRole // workspace ID -> user -> role
+
+	companyDatasources map[int32][]int32 // datasource ID -> company IDs
+	contactDatasources map[int32][]int32 // datasource ID -> contact IDs
+	projectDatasources map[int32][]int32 // datasource ID -> project IDs
+
+	err error // Returned by every lookup when set
+}
+
+func nullWorkspace(id int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: id, Valid: id != 0}
+}
+
+func (f *fakeStore) GetCompanyByID(ctx context.Context, companyID int32) (db.GetCompanyByIDRow, error) {
+	if f.err != nil {
+		return db.GetCompanyByIDRow{}, f.err
+	}
+	workspaceID, ok := f.companies[companyID]
+	if !ok {
+		return db.GetCompanyByIDRow{}, sql.ErrNoRows
+	}
+	return db.GetCompanyByIDRow{CompanyID: companyID, WorkspaceID: nullWorkspace(workspaceID)}, nil
+}
+
+func (f *fakeStore) GetContactByID(ctx context.Context, contactID int32) (db.Contact, error) {
+	if f.err != nil {
+		return db.Contact{}, f.err
+	}
+	companyID, ok := f.contacts[contactID]
+	if !ok {
+		return db.Contact{}, sql.ErrNoRows
+	}
+	return db.Contact{ContactID: contactID, CompanyID: companyID}, nil
+}
+
+func (f *fakeStore) GetProjectByID(ctx context.Context, projectID int32) (db.GetProjectByIDRow, error) {
+	if f.err != nil {
+		return db.GetProjectByIDRow{}, f.err
+	}
+	workspaceID, ok := f.projects[projectID]
+	if !ok {
+		return db.GetProjectByIDRow{}, sql.ErrNoRows
+	}
+	return db.GetProjectByIDRow{ProjectID: projectID, WorkspaceID: nullWorkspace(workspaceID)}, nil
+}
+
+func (f *fakeStore) GetDatasourceByID(ctx context.Context, datasourceID int32) (db.GetDatasourceByIDRow, error) {
+	if f.err != nil {
+		return db.GetDatasourceByIDRow{}, f.err
+	}
+	if !f.datasources[datasourceID] {
+		return db.GetDatasourceByIDRow{}, sql.ErrNoRows
+	}
+	return db.GetDatasourceByIDRow{DatasourceID: datasourceID}, nil
+}
+
+func (f *fakeStore) GetParagraphByID(ctx context.Context, paragraphID int32) (db.Paragraph, error) {
+	if f.err != nil {
+		return db.Paragraph{}, f.err
+	}
+	datasourceID, ok := f.paragraphs[paragraphID]
+	if !ok {
+		return db.Paragraph{}, sql.ErrNoRows
+	}
+	return db.Paragraph{ParagraphID: paragraphID, DatasourceID: datasourceID}, nil
+}
+
+func (f *fakeStore) GetWorkspaceByID(ctx context.Context, workspaceID int32) (db.Workspace, error) {
+	if f.err != nil {
+		return db.Workspace{}, f.err
+	}
+	if _, ok := f.members[workspaceID]; !ok {
+		return db.Workspace{}, sql.ErrNoRows
+	}
+	return db.Workspace{WorkspaceID: workspaceID}, nil
+}
+
+func (f *fakeStore) GetWorkspaceMember(ctx context.Context, arg db.GetWorkspaceMemberParams) (db.WorkspaceMember, error) {
+	if f.err != nil {
+		return db.WorkspaceMember{}, f.err
+	}
+	role, ok := f.members[arg.WorkspaceID][arg.CognitoSub]
+	if !ok {
+		return db.WorkspaceMember{}, sql.ErrNoRows
+	}
+	return db.WorkspaceMember{WorkspaceID: arg.WorkspaceID, CognitoSub: arg.CognitoSub, Role: role}, nil
+}
+
+// ListDatasourceMembers mirrors the UNION in the query: distinct members and roles of every
+// workspace the datasource is associated with, ordered by user and role
+func (f *fakeStore) ListDatasourceMembers(ctx context.Context, datasourceID int32) ([]db.ListDatasourceMembersRow, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	seen := map[db.ListDatasourceMembersRow]bool{}
+	var members []db.ListDatasourceMembersRow
+	add := func(workspaceID int32) {
+		for user, role := range f.members[workspaceID] {
+			member := db.ListDatasourceMembersRow{CognitoSub: user, Role: role}
+			if !seen[member] {
+				seen[member] = true
+				members = append(members, member)
+			}
+		}
+	}
+	for _, companyID := range f.companyDatasources[datasourceID] {
+		add(f.companies[companyID])
+	}
+	for _, contactID := range f.contactDatasources[datasourceID] {
+		add(f.companies[f.contacts[contactID]])
+	}
+	for _, projectID := range f.projectDatasources[datasourceID] {
+		add(f.projects[projectID])
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].CognitoSub != members[j].CognitoSub {
+			return members[i].CognitoSub < members[j].CognitoSub
+		}
+		return members[i].Role < members[j].Role
+	})
+	return members, nil
+}
+
+// Two tenants. Workspace 1 is owned by alice, with dave as an editor and carol as a viewer;
+// workspace 2 is owned by bob, with carol as an editor. Company 1 and project 1 are in
+// workspace 1, company 2 and project 2 in workspace 2 and company 3 in none. Datasource 10
+// is in workspace 1 through a company, 11 through a contact, 12 through a project and 13 is
+// in workspace 2. Datasource 14 is shared by both and 15 isn't associated with anything.
+func newTwoTenantStore() *fakeStore {
+	return &fakeStore{
+		companies:   map[int32]int32{1: 1, 2: 2, 3: 0},
+		contacts:    map[int32]int32{1: 1, 2: 2},
+		projects:    map[int32]int32{1: 1, 2: 2},
+		datasources: map[int32]bool{10: true, 11: true, 12: true, 13: true, 14: true, 15: true},
+		paragraphs:  map[int32]int32{100: 10, 101: 11, 102: 12, 103: 13, 104: 14, 105: 15},
+		members: map[int32]map[string]db.WorkspaceRole{
+			1: {"alice": db.WorkspaceRoleOwner, "dave": db.WorkspaceRoleEditor, "carol": db.WorkspaceRoleViewer},
+			2: {"bob": db.WorkspaceRoleOwner, "carol": db.WorkspaceRoleEditor},
+		},
+
+		companyDatasources: map[int32][]int32{10: {1}, 13: {2}, 14: {2}},
+		contactDatasources: map[int32][]int32{11: {1}},
+		projectDatasources: map[int32][]int32{12: {1}, 14: {1}},
+	}
+}
+
+func TestAuthorizerCrossTenant(t *testing.T) {
+	a := New(newTwoTenantStore())
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		check func(cognitoSub string) error
+		want  map[string]error // Expected result per user
+	}{
+		{"own company", func(s string) error { return a.CanAccessCompany(ctx, s, 1) },
+			map[string]error{"alice": nil, "bob": ErrForbidden, "": ErrForbidden}},
+		{"company of a viewer's workspace", func(s string) error { return a.CanAccessCompany(ctx, s, 1) },
+			map[string]error{"carol": nil, "dave": nil, "mallory": ErrForbidden}},
+		{"company outside any workspace", func(s string) error { return a.CanAccessCompany(ctx, s, 3) },
+			map[string]error{"alice": ErrForbidden, "bob": ErrForbidden, "": ErrForbidden}},
+		{"missing company", func(s string) error { return a.CanAccessCompany(ctx, s, 99) },
+			map[string]error{"alice": ErrNotFound, "bob": ErrNotFound}},
+		{"contact of own company", func(s string) error { return a.CanAccessContact(ctx, s, 2) },
+			map[string]error{"alice": ErrForbidden, "bob": nil}},
+		// Checked by /companies/:id/paragraphs and /contacts/:id/paragraphs and their search routes
+		{"other tenant's company paragraphs", func(s string) error { return a.CanAccessCompany(ctx, s, 2) },
+			map[string]error{"alice": ErrForbidden, "dave": ErrForbidden, "bob": nil, "carol": nil}},
+		{"other tenant's contact paragraphs", func(s string) error { return a.CanAccessContact(ctx, s, 1) },
+			map[string]error{"bob": ErrForbidden, "mallory": ErrForbidden, "alice": nil, "carol": nil}},
+		{"missing contact", func(s string) error { return a.CanAccessContact(ctx, s, 99) },
+			map[string]error{"alice": ErrNotFound, "bob": ErrNotFound}},
+		{"own project", func(s string) error { return a.CanAccessProject(ctx, s, 1) },
+			map[string]error{"alice": nil, "bob": ErrForbidden}},
+		{"missing project", func(s string) error { return a.CanAccessProject(ctx, s, 99) },
+			map[string]error{"alice": ErrNotFound}},
+		{"datasource through company", func(s string) error { return a.CanAccessDatasource(ctx, s, 10) },
+			map[string]error{"alice": nil, "bob": ErrForbidden, "": ErrForbidden}},
+		{"datasource through contact", func(s string) error { return a.CanAccessDatasource(ctx, s, 11) },
+			map[string]error{"alice": nil, "bob": ErrForbidden}},
+		{"datasource through project", func(s string) error { return a.CanAccessDatasource(ctx, s, 12) },
+			map[string]error{"alice": nil, "bob": ErrForbidden}},
+		{"other tenant's datasource", func(s string) error { return a.CanAccessDatasource(ctx, s, 13) },
+			map[string]error{"alice": ErrForbidden, "bob": nil}},
+		{"shared datasource", func(s string) error { return a.CanAccessDatasource(ctx, s, 14) },
+			map[string]error{"alice": nil, "bob": nil, "carol": nil, "mallory": ErrForbidden}},
+		{"orphaned datasource", func(s string) error { return a.CanAccessDatasource(ctx, s, 15) },
+			map[string]error{"alice": ErrForbidden, "bob": ErrForbidden}},
+		{"missing datasource", func(s string) error { return a.CanAccessDatasource(ctx, s, 99) },
+			map[string]error{"alice": ErrNotFound, "bob": ErrNotFound}},
+		{"paragraph through company", func(s string) error { return a.CanAccessParagraph(ctx, s, 100) },
+			map[string]error{"alice": nil, "bob": ErrForbidden}},
+		{"paragraph through contact", func(s string) error { return a.CanAccessParagraph(ctx, s, 101) },
+			map[string]error{"alice": nil, "bob": ErrForbidden}},
+		{"paragraph through project", func(s string) error { return a.CanAccessParagraph(ctx, s, 102) },
+			map[string]error{"alice": nil, "bob": ErrForbidden}},
+		{"other tenant's paragraph", func(s string) error { return a.CanAccessParagraph(ctx, s, 103) },
+			map[string]error{"alice": ErrForbidden, "bob": nil}},
+		{"paragraph of shared datasource", func(s string) error { return a.CanAccessParagraph(ctx, s, 104) },
+			map[string]error{"alice": nil, "bob": nil}},
+		{"paragraph of orphaned datasource", func(s string) error { return a.CanAccessParagraph(ctx, s, 105) },
+			map[string]error{"alice": ErrForbidden}},
+		{"missing paragraph", func(s string) error { return a.CanAccessParagraph(ctx, s, 999) },
+			map[string]error{"alice": ErrNotFound}},
+	}
+
+	for _, tt := range tests {
+		for user, want := range tt.want {
+			err := tt.check(user)
+			if want == nil && err != nil {
+				t.Errorf("%s: user %q got %v, want access", tt.name, user, err)
+			}
+			if want != nil && !errors.Is(err, want) {
+				t.Errorf("%s: user %q got %v, want %v", tt.name, user, err, want)
+			}
+		}
+	}
+}
+
+func TestAuthorizerRoles(t *testing.T) {
+	a := New(newTwoTenantStore())
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		check func(cognitoSub string) error
+		want  map[string]error // Expected result per user
+	}{
+		{"edit company", func(s string) error { return a.CanEditCompany(ctx, s, 1) },
+			map[string]error{"alice": nil, "dave": nil, "carol": ErrForbidden, "bob": ErrForbidden}},
+		{"edit contact", func(s string) error { return a.CanEditContact(ctx, s, 1) },
+			map[string]error{"alice": nil, "dave": nil, "carol": ErrForbidden}},
+		{"edit project", func(s string) error { return a.CanEditProject(ctx, s, 1) },
+			map[string]error{"dave": nil, "carol": ErrForbidden, "bob": ErrForbidden}},
+		{"edit datasource", func(s string) error { return a.CanEditDatasource(ctx, s, 10) },
+			map[string]error{"alice": nil, "dave": nil, "carol": ErrForbidden}},
+		{"edit shared datasource", func(s string) error { return a.CanEditDatasource(ctx, s, 14) },
+			map[string]error{"carol": nil, "dave": nil, "mallory": ErrForbidden}},
+		{"edit paragraph", func(s string) error { return a.CanEditParagraph(ctx, s, 100) },
+			map[string]error{"dave": nil, "carol": ErrForbidden}},
+		{"edit missing paragraph", func(s string) error { return a.CanEditParagraph(ctx, s, 999) },
+			map[string]error{"dave": ErrNotFound}},
+		{"access workspace", func(s string) error { return a.CanAccessWorkspace(ctx, s, 1) },
+			map[string]error{"alice": nil, "carol": nil, "bob": ErrForbidden, "": ErrForbidden}},
+		{"edit workspace", func(s string) error { return a.CanEditWorkspace(ctx, s, 2) },
+			map[string]error{"bob": nil, "carol": nil, "alice": ErrForbidden}},
+		{"manage workspace", func(s string) error { return a.CanManageWorkspace(ctx, s, 1) },
+			map[string]error{"alice": nil, "dave": ErrForbidden, "carol": ErrForbidden}},
+		{"missing workspace", func(s string) error { return a.CanAccessWorkspace(ctx, s, 99) },
+			map[string]error{"alice": ErrNotFound}},
+	}
+
+	for _, tt := range tests {
+		for user, want := range tt.want {
+			err := tt.check(user)
+			if want == nil && err != nil {
+				t.Errorf("%s: user %q got %v, want access", tt.name, user, err)
+			}
+			if want != nil && !errors.Is(err, want) {
+				t.Errorf("%s: user %q got %v, want %v", tt.name, user, err, want)
+			}
+		}
+	}
+}
+
+func TestAuthorizerDatasourceRole(t *testing.T) {
+	a := New(newTwoTenantStore())
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		datasourceID int32
+		user         string
+		want         db.WorkspaceRole
+	}{
+		{"owner", 10, "alice", db.WorkspaceRoleOwner},
+		{"viewer", 10, "carol", db.WorkspaceRoleViewer},
+		{"highest role across workspaces", 14, "carol", db.WorkspaceRoleEditor},
+		{"non-member", 13, "alice", ""},
+		{"orphaned", 15, "alice", ""},
+	}
+	for _, tt := range tests {
+		role, err := a.DatasourceRole(ctx, tt.user, tt.datasourceID)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if role != tt.want {
+			t.Errorf("%s: got role %q, want %q", tt.name, role, tt.want)
+		}
+	}
+}
+
+func TestAuthorizerStoreErrors(t *testing.T) {
+	store := newTwoTenantStore()
+	store.err = errors.New("connection refused")
+	a := New(store)
+	ctx := context.Background()
+
+	checks := map[string]error{
+		"company":    a.CanAccessCompany(ctx, "alice", 1),
+		"contact":    a.CanAccessContact(ctx, "alice", 1),
+		"project":    a.CanAccessProject(ctx, "alice", 1),
+		"datasource": a.CanAccessDatasource(ctx, "alice", 10),
+		"paragraph":  a.CanAccessParagraph(ctx, "alice", 100),
+		"workspace":  a.CanManageWorkspace(ctx, "alice", 1),
+	}
+	for name, err := range checks {
+		// A failed lookup must never read as allowed, nor as a definite answer
+		if err == nil || errors.Is(err, ErrForbidden) || errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: got %v, want the store error", name, err)
+		}
+		if !errors.Is(err, store.err) {
+			t.Errorf("%s: got %v, want it to wrap %v", name, err, store.err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package authz
+
+// Application-wide roles decide what a user may do outside of workspaces, such as managing
+// other users. They are read from the Cognito groups in the access token and from the
+// user_roles table, and a user holds the union of both. Routes declare the permission they
+// need rather than a role, so that new roles only have to be added here.
+
+// Role names, shared with the Cognito group names
+const (
+	RoleAdmin   = "admin"
+	RoleSupport = "support"
+)
+
+// Permission is something a route can require
+type Permission string
+
+const (
+	// PermUsersRead allows listing and viewing any user
+	PermUsersRead Permission = "users:read"
+	// PermUsersWrite allows creating and deleting any user
+	PermUsersWrite Permission = "users:write"
+	// PermRolesManage allows granting and revoking application-wide roles
+	PermRolesManage Permission = "roles:manage"
+)
+
+// rolePermissions lists the permissions each role grants
+var rolePermissions = map[string][]Permission{
+	RoleAdmin:   {PermUsersRead, PermUsersWrite, PermRolesManage},
+	RoleSupport: {PermUsersRead},
+}
+
+// IsRole reports whether name is a known role
+func IsRole(name string) bool {
+	_, ok := rolePermissions[name]
+	return ok
+}
+
+// Roles returns the names of all known roles
+func Roles() []string {
+	return []string{RoleAdmin, RoleSupport}
+}
+
+// Allows reports whether any of the roles grants every one of the permissions. Unknown
+// roles grant nothing.
+func Allows(roles []string, perms ...Permission) bool {
+	granted := map[Permission]bool{}
+	for _, role := range roles {
+		for _, perm := range rolePermissions[role] {
+			granted[perm] = true
+		}
+	}
+	for _, perm := range perms {
+		if !granted[perm] {
+			return false
+		}
+	}
+	return true
+}
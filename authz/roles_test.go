@@ -0,0 +1,38 @@
+package authz
+
+import "testing"
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []string
+		perms []Permission
+		want  bool
+	}{
+		{"admin reads users", []string{RoleAdmin}, []Permission{PermUsersRead}, true},
+		{"admin manages roles", []string{RoleAdmin}, []Permission{PermRolesManage, PermUsersWrite}, true},
+		{"support reads users", []string{RoleSupport}, []Permission{PermUsersRead}, true},
+		{"support can't delete users", []string{RoleSupport}, []Permission{PermUsersWrite}, false},
+		{"all permissions required", []string{RoleSupport}, []Permission{PermUsersRead, PermUsersWrite}, false},
+		{"roles combine", []string{RoleSupport, RoleAdmin}, []Permission{PermUsersWrite}, true},
+		{"no roles", nil, []Permission{PermUsersRead}, false},
+		{"unknown role", []string{"superuser"}, []Permission{PermUsersRead}, false},
+		{"nothing required", nil, nil, true},
+	}
+	for _, tt := range tests {
+		if got := Allows(tt.roles, tt.perms...); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsRole(t *testing.T) {
+	for _, role := range Roles() {
+		if !IsRole(role) {
+			t.Errorf("IsRole(%q) = false", role)
+		}
+	}
+	if IsRole("superuser") {
+		t.Error("IsRole(\"superuser\") = true")
+	}
+}
@@ -0,0 +1,66 @@
+package authz
+
+import "strings"
+
+// Scopes narrow what an API token may do on behalf of the user who minted it. A scope names
+// a resource and an access level, e.g. "companies:read" or "datasources:write"; "read" and
+// "write" on their own cover every resource except admin, which has to be named. Write
+// access includes read access. A token never gets more access than its owner has: scopes
+// are checked in addition to workspace roles and permissions, not instead of them.
+
+// Access levels of a scope
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+// ScopeResources are the resources a scope can name. They match the first segment of the
+// /api/v1 routes. Tokens can't be scoped to manage tokens, so a leaked token can't mint
+// more.
+var ScopeResources = []string{
+	"admin", "companies", "contacts", "datasources", "paragraphs", "projects", "users", "workspaces",
+}
+
+// IsScope reports whether scope is a well-formed scope
+func IsScope(scope string) bool {
+	resource, access, ok := strings.Cut(scope, ":")
+	if !ok {
+		return scope == ScopeRead || scope == ScopeWrite
+	}
+	return (access == ScopeRead || access == ScopeWrite) && isScopeResource(resource)
+}
+
+// isScopeResource reports whether resource is one of ScopeResources
+func isScopeResource(resource string) bool {
+	for _, known := range ScopeResources {
+		if resource == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesAllow reports whether any of the scopes allows reading, or writing when write is
+// true, the resource. Resources that aren't in ScopeResources are never allowed.
+func ScopesAllow(scopes []string, resource string, write bool) bool {
+	if !isScopeResource(resource) {
+		return false
+	}
+	for _, scope := range scopes {
+		scopeResource, access, ok := strings.Cut(scope, ":")
+		if !ok {
+			// Unqualified scopes cover everything but admin
+			scopeResource, access = resource, scope
+			if resource == "admin" {
+				continue
+			}
+		}
+		if scopeResource != resource {
+			continue
+		}
+		if access == ScopeWrite || (access == ScopeRead && !write) {
+			return true
+		}
+	}
+	return false
+}
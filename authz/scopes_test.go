@@ -0,0 +1,47 @@
+package authz
+
+import "testing"
+
+func TestScopesAllow(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		resource string
+		write    bool
+		want     bool
+	}{
+		{"read reads anything", []string{"read"}, "companies", false, true},
+		{"read can't write", []string{"read"}, "companies", true, false},
+		{"write writes anything", []string{"write"}, "datasources", true, true},
+		{"unqualified scopes skip admin", []string{"write"}, "admin", false, false},
+		{"admin has to be named", []string{"admin:read"}, "admin", false, true},
+		{"resource read", []string{"contacts:read"}, "contacts", false, true},
+		{"resource read can't write", []string{"contacts:read"}, "contacts", true, false},
+		{"resource write includes read", []string{"contacts:write"}, "contacts", false, true},
+		{"other resource", []string{"contacts:write"}, "companies", false, false},
+		{"scopes combine", []string{"contacts:read", "companies:write"}, "companies", true, true},
+		{"no scopes", nil, "companies", false, false},
+		{"tokens are never in scope", []string{"write"}, "tokens", false, false},
+	}
+	for _, tt := range tests {
+		if got := ScopesAllow(tt.scopes, tt.resource, tt.write); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsScope(t *testing.T) {
+	valid := []string{"read", "write", "companies:read", "datasources:write", "admin:read"}
+	for _, scope := range valid {
+		if !IsScope(scope) {
+			t.Errorf("IsScope(%q) = false", scope)
+		}
+	}
+
+	invalid := []string{"", "admin", "tokens:write", "companies:delete", "companies", ":read", "*:read"}
+	for _, scope := range invalid {
+		if IsScope(scope) {
+			t.Errorf("IsScope(%q) = true", scope)
+		}
+	}
+}
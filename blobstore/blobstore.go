@@ -0,0 +1,151 @@
+// Package blobstore keeps file contents outside the database. Blobs are addressed by the
+// SHA-256 of their content, so identical uploads are stored only once.
+package blobstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ErrNotFound is returned when a blob doesn't exist
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore stores blobs under keys made by Key
+type BlobStore interface {
+	// Put stores size bytes read from r under key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get opens the blob stored under key; the caller must close it. Seeking is cheap,
+	// so ranges of large blobs can be read without reading what comes before them.
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Exists reports whether a blob is stored under key
+	Exists(ctx context.Context, key string) (bool, error)
+	// Delete removes the blob stored under key; deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Checker is implemented by stores that can check their backend directly
+type Checker interface {
+	// Check reports whether the backend can be reached
+	Check(ctx context.Context) error
+}
+
+// Check reports whether store can be reached, for readiness checks. Stores that aren't a
+// Checker are probed by looking up a blob that doesn't exist.
+func Check(ctx context.Context, store BlobStore) error {
+	if checker, ok := store.(Checker); ok {
+		return checker.Check(ctx)
+	}
+	_, err := store.Exists(ctx, Key(strings.Repeat("0", sha256.Size*2)))
+	return err
+}
+
+// Blob identifies stored content
+type Blob struct {
+	Hash string // Hex SHA-256 of the content
+	Size int64
+}
+
+// hashPattern matches a hex SHA-256 digest
+var hashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ValidHash reports whether hash is a hex SHA-256 digest
+func ValidHash(hash string) bool {
+	return hashPattern.MatchString(hash)
+}
+
+// Key returns the key a blob with the given content hash is stored under.
+// The first two characters fan blobs out over directories or prefixes.
+func Key(hash string) string {
+	return "sha256/" + hash[:2] + "/" + hash
+}
+
+// PutContent stores the content read from r and returns its hash and size. Content that is
+// already stored isn't uploaded again. Readers that can't seek are spooled to a temporary
+// file, since the hash has to be known before the blob is written.
+func PutContent(ctx context.Context, store BlobStore, r io.Reader) (Blob, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		tmp, err := os.CreateTemp("", "blob-*")
+		if err != nil {
+			return Blob{}, fmt.Errorf("failed to spool blob: %w", err)
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if _, err := io.Copy(tmp, r); err != nil {
+			return Blob{}, fmt.Errorf("failed to spool blob: %w", err)
+		}
+		rs = tmp
+	}
+
+	blob, err := HashContent(rs)
+	if err != nil {
+		return Blob{}, err
+	}
+	if err := PutBlob(ctx, store, blob, rs); err != nil {
+		return Blob{}, err
+	}
+	return blob, nil
+}
+
+// HashContent returns the hash and size of the content read from rs, reading it from the start
+func HashContent(rs io.ReadSeeker) (Blob, error) {
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return Blob{}, err
+	}
+	h := sha256.New()
+	size, err := io.Copy(h, rs)
+	if err != nil {
+		return Blob{}, fmt.Errorf("failed to hash blob: %w", err)
+	}
+	return Blob{Hash: hex.EncodeToString(h.Sum(nil)), Size: size}, nil
+}
+
+// PutBlob stores the content read from rs as blob, unless it's already stored. The hash
+// must come from HashContent.
+func PutBlob(ctx context.Context, store BlobStore, blob Blob, rs io.ReadSeeker) error {
+	key := Key(blob.Hash)
+	exists, err := store.Exists(ctx, key)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	if _, err := rs.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return store.Put(ctx, key, rs, blob.Size)
+}
+
+// Backends that can be configured
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Config selects and configures a blob store backend
+type Config struct {
+	Backend string // BackendLocal or BackendS3
+	Dir     string // Root directory of the local backend
+	S3      S3Config
+}
+
+// Open creates the blob store described by cfg
+func Open(ctx context.Context, cfg Config) (BlobStore, error) {
+	switch strings.ToLower(cfg.Backend) {
+	case "", BackendLocal:
+		return NewLocalStore(cfg.Dir)
+	case BackendS3:
+		return NewS3Store(ctx, cfg.S3)
+	}
+	return nil, fmt.Errorf("unknown blob store backend %q, expected %q or %q", cfg.Backend, BackendLocal, BackendS3)
+}
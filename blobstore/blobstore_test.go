@@ -0,0 +1,195 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a minimal S3-compatible server for path-style requests, standing in for MinIO
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    int
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/")
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = data
+		f.puts++
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		if r.Method == http.MethodHead && !strings.Contains(key, "/") {
+			// HeadBucket
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			}
+			return
+		}
+		status := http.StatusOK
+		if start, ok := strings.CutPrefix(r.Header.Get("Range"), "bytes="); ok {
+			from, err := strconv.Atoi(strings.TrimSuffix(start, "-"))
+			if err != nil || from >= len(data) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+			w.Header().Set("Content-Range", "bytes "+strconv.Itoa(from)+"-"+strconv.Itoa(len(data)-1)+"/"+strconv.Itoa(len(data)))
+			data, status = data[from:], http.StatusPartialContent
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestBlobStores(t *testing.T) {
+	ctx := context.Background()
+
+	local, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeS3{objects: make(map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	remote, err := NewS3Store(ctx, S3Config{
+		Endpoint:        server.URL,
+		Region:          "us-east-1",
+		Bucket:          "blobs",
+		Prefix:          "test/",
+		AccessKeyID:     "minio",
+		SecretAccessKey: "minio123",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := []struct {
+		name  string
+		store BlobStore
+	}{
+		{"local", local},
+		{"s3", remote},
+	}
+
+	for _, tc := range stores {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Check(ctx, tc.store); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+
+			content := []byte("The quarterly report covers three regions.")
+
+			// A reader that can't seek is spooled before it is stored
+			blob, err := PutContent(ctx, tc.store, io.MultiReader(bytes.NewReader(content)))
+			if err != nil {
+				t.Fatalf("PutContent: %v", err)
+			}
+			if !ValidHash(blob.Hash) || blob.Size != int64(len(content)) {
+				t.Fatalf("unexpected blob %+v", blob)
+			}
+
+			again, err := PutContent(ctx, tc.store, bytes.NewReader(content))
+			if err != nil {
+				t.Fatalf("PutContent again: %v", err)
+			}
+			if again != blob {
+				t.Errorf("identical content got blob %+v, want %+v", again, blob)
+			}
+
+			rc, err := tc.store.Get(ctx, Key(blob.Hash))
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			got, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil || !bytes.Equal(got, content) {
+				t.Errorf("Get returned %q, %v; want %q", got, err, content)
+			}
+
+			// Reading a range after seeking
+			rc, err = tc.store.Get(ctx, Key(blob.Hash))
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if size, err := rc.Seek(0, io.SeekEnd); err != nil || size != int64(len(content)) {
+				t.Errorf("Seek to end = %d, %v; want %d", size, err, len(content))
+			}
+			if _, err := rc.Seek(4, io.SeekStart); err != nil {
+				t.Fatalf("Seek: %v", err)
+			}
+			got, err = io.ReadAll(io.LimitReader(rc, 9))
+			rc.Close()
+			if err != nil || string(got) != "quarterly" {
+				t.Errorf("range read returned %q, %v; want %q", got, err, "quarterly")
+			}
+
+			if err := tc.store.Delete(ctx, Key(blob.Hash)); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if exists, err := tc.store.Exists(ctx, Key(blob.Hash)); err != nil || exists {
+				t.Errorf("Exists after delete = %v, %v", exists, err)
+			}
+			if _, err := tc.store.Get(ctx, Key(blob.Hash)); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get after delete returned %v, want ErrNotFound", err)
+			}
+			if err := tc.store.Delete(ctx, Key(blob.Hash)); err != nil {
+				t.Errorf("deleting a missing blob: %v", err)
+			}
+		})
+	}
+
+	// The second identical upload must not have reached the server
+	if fake.puts != 1 {
+		t.Errorf("S3 received %d uploads, want 1", fake.puts)
+	}
+	for key := range fake.objects {
+		t.Errorf("object %s left behind", key)
+	}
+}
+
+func TestLocalStoreRejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"../outside", "/etc/passwd", "a/../../b", `sha256\..\x`, ""} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Command migrateblobs moves datasource files stored in the datasources table into the
+// configured blob store. It can be stopped and run again; datasources already moved are skipped.
+//
+// It reads the same configuration as the server. Configuration flags go after the tool's own
+// flags and a "--", e.g. migrateblobs -batch 100 -- -blob-store s3 -s3-bucket files.
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+
+	"github.com/mbaxamb3/nusli/blobstore"
+	db "github.com/mbaxamb3/nusli/db/sqlc"
+	"github.com/mbaxamb3/nusli/util"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+func main() {
+	batchSize := flag.Int("batch", 50, "number of datasources to look up at a time")
+	dryRun := flag.Bool("dry-run", false, "list the datasources that would be moved without changing anything")
+	flag.Parse()
+
+	config, err := util.LoadConfig(".", flag.Args())
+	if err != nil {
+		log.Fatalf("Cannot load config: %v", err)
+	}
+
+	conn, err := util.SetupDBConnection(config)
+	if err != nil {
+		log.Fatalf("Cannot connect to database: %v", err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	blobs, err := blobstore.Open(ctx, config.BlobStoreConfig())
+	if err != nil {
+		log.Fatalf("Cannot open blob store: %v", err)
+	}
+
+	store := db.NewStore(conn)
+	var moved, failed int
+	var movedBytes int64
+	var lastID int32
+
+	for {
+		ids, err := store.ListDatasourceIDsWithFileData(ctx, db.ListDatasourceIDsWithFileDataParams{
+			DatasourceID: lastID,
+			Limit:        int32(*batchSize),
+		})
+		if err != nil {
+			log.Fatalf("Cannot list datasources: %v", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		// One file at a time, so memory use is bounded by the largest file
+		for _, id := range ids {
+			lastID = id
+			size, err := migrateDatasource(ctx, store, blobs, id, *dryRun)
+			if err != nil {
+				log.Printf("Datasource %d: %v", id, err)
+				failed++
+				continue
+			}
+			moved++
+			movedBytes += size
+		}
+	}
+
+	verb := "Moved"
+	if *dryRun {
+		verb = "Would move"
+	}
+	log.Printf("%s %d datasource files (%d bytes) to the blob store, %d failed", verb, moved, movedBytes, failed)
+	if failed > 0 {
+		log.Fatalf("Some datasources were not moved; run again to retry them")
+	}
+}
+
+// migrateDatasource moves the file of one datasource and returns its size
+func migrateDatasource(ctx context.Context, store *db.Store, blobs blobstore.BlobStore, id int32, dryRun bool) (int64, error) {
+	datasource, err := store.GetFullDatasourceByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	if dryRun {
+		log.Printf("Datasource %d: %s (%d bytes)", id, datasource.FileName.String, len(datasource.FileData))
+		return int64(len(datasource.FileData)), nil
+	}
+
+	content := bytes.NewReader(datasource.FileData)
+	blob, err := blobstore.HashContent(content)
+	if err != nil {
+		return 0, err
+	}
+	// The blob is held while its content is stored, so a concurrent release can't remove it
+	err = store.CreateBlobTx(ctx, db.CreateBlobParams{Hash: blob.Hash, Size: blob.Size}, func() error {
+		return blobstore.PutBlob(ctx, blobs, blob, content)
+	})
+	if err != nil {
+		return 0, err
+	}
+	// The database copy is only cleared once the blob is safely stored
+	err = store.SetDatasourceBlob(ctx, db.SetDatasourceBlobParams{
+		DatasourceID: id,
+		BlobHash:     sql.NullString{String: blob.Hash, Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return blob.Size, nil
+}
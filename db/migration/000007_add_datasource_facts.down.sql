@@ -0,0 +1,7 @@
+-- Migration Down: Drop the datasource facts table and its enum
+
+DROP INDEX IF EXISTS idx_datasource_facts_fact_type;
+DROP INDEX IF EXISTS idx_datasource_facts_datasource_id;
+
+DROP TABLE IF EXISTS datasource_facts;
+DROP TYPE IF EXISTS fact_type;
@@ -0,0 +1,25 @@
+-- Migration to store structured facts extracted from scraped websites
+
+-- Step 1: Create enum for the kinds of facts the scraper extracts
+CREATE TYPE fact_type AS ENUM (
+    'organization', 'person', 'product', 'open_graph',
+    'email', 'phone', 'address', 'social_profile'
+);
+
+-- Step 2: Create facts table, one row per unique fact per datasource
+CREATE TABLE datasource_facts (
+    fact_id SERIAL PRIMARY KEY,
+    datasource_id INTEGER NOT NULL REFERENCES datasources(datasource_id) ON DELETE CASCADE,
+    fact_type fact_type NOT NULL,
+    fact_key VARCHAR(100) NOT NULL DEFAULT '', -- e.g. "industry" for organization facts, network name for social profiles
+    value TEXT NOT NULL,
+    attributes JSONB, -- Extra typed details such as a person's job title or email
+    source VARCHAR(50), -- Extraction method (json-ld, microdata, meta, link, text, team-page)
+    source_url TEXT, -- Page the fact was found on
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (datasource_id, fact_type, fact_key, value)
+);
+
+-- Step 3: Create indexes for better performance
+CREATE INDEX idx_datasource_facts_datasource_id ON datasource_facts(datasource_id);
+CREATE INDEX idx_datasource_facts_fact_type ON datasource_facts(fact_type);
@@ -0,0 +1,3 @@
+-- Migration Down: Drop the datasource site tree table
+
+DROP TABLE IF EXISTS datasource_site_trees;
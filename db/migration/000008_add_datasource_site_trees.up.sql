@@ -0,0 +1,10 @@
+-- Migration to persist the crawled site tree of website datasources
+
+-- Step 1: Create site tree table, one tree per website datasource
+CREATE TABLE datasource_site_trees (
+    datasource_id INTEGER PRIMARY KEY REFERENCES datasources(datasource_id) ON DELETE CASCADE,
+    tree JSONB NOT NULL, -- Nested pages with titles and paragraph counts
+    page_count INTEGER NOT NULL DEFAULT 0, -- Number of pages crawled
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- Migration Down: Drop the datasource page selection table
+
+DROP TABLE IF EXISTS datasource_page_selections;
@@ -0,0 +1,9 @@
+-- Migration to remember which pages of a website datasource should be extracted
+
+-- Step 1: Create page selection table, one selection per website datasource
+CREATE TABLE datasource_page_selections (
+    datasource_id INTEGER PRIMARY KEY REFERENCES datasources(datasource_id) ON DELETE CASCADE,
+    selection JSONB NOT NULL, -- Selected page URLs and path subtrees
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
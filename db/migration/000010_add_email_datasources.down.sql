@@ -0,0 +1,9 @@
+-- Migration Down: Drop the email metadata table and remove email datasources
+-- Postgres can't drop a value from an enum, so 'email' stays in datasource_type
+
+DROP INDEX IF EXISTS idx_contacts_email_lower;
+DROP INDEX IF EXISTS idx_paragraph_email_metadata_sender_email;
+
+DROP TABLE IF EXISTS paragraph_email_metadata;
+
+DELETE FROM datasources WHERE source_type = 'email';
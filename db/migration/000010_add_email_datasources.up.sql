@@ -0,0 +1,20 @@
+-- Migration to support email datasources (.eml files and mbox archives)
+
+-- Step 1: Add the email datasource type
+ALTER TYPE datasource_type ADD VALUE IF NOT EXISTS 'email';
+
+-- Step 2: Create email metadata table, one row per paragraph extracted from a message
+CREATE TABLE paragraph_email_metadata (
+    paragraph_id INTEGER PRIMARY KEY REFERENCES paragraphs(paragraph_id) ON DELETE CASCADE,
+    message_id TEXT, -- Message-ID header, used to group paragraphs of the same message
+    subject TEXT,
+    sender_name VARCHAR(255),
+    sender_email VARCHAR(255) NOT NULL,
+    recipients TEXT[] NOT NULL DEFAULT '{}', -- To and Cc addresses
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Step 3: Create indexes for better performance
+CREATE INDEX idx_paragraph_email_metadata_sender_email ON paragraph_email_metadata(LOWER(sender_email));
+CREATE INDEX idx_contacts_email_lower ON contacts(LOWER(email));
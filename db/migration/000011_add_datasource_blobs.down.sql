@@ -0,0 +1,9 @@
+-- Migration Down: Remove blob references from datasources
+-- Files that only exist in the blob store are not copied back; datasources moved out of
+-- the database lose their file, so run this only before any data has been migrated
+
+DROP INDEX IF EXISTS idx_datasources_blob_hash;
+
+ALTER TABLE datasources DROP COLUMN IF EXISTS blob_hash;
+
+DROP TABLE IF EXISTS blobs;
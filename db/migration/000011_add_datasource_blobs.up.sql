@@ -0,0 +1,16 @@
+-- Migration to keep datasource files in a blob store instead of the datasources table
+
+-- Step 1: Create blobs table, one row per distinct file content
+-- The content itself lives in the blob store under a key derived from its SHA-256 hash
+CREATE TABLE blobs (
+    hash VARCHAR(64) PRIMARY KEY, -- Hex SHA-256 of the content
+    size BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Step 2: Reference the blob from datasources; identical uploads share one blob
+-- file_data stays for rows that haven't been moved to the blob store yet
+ALTER TABLE datasources ADD COLUMN blob_hash VARCHAR(64) REFERENCES blobs(hash);
+
+-- Step 3: Create index to find the datasources using a blob
+CREATE INDEX idx_datasources_blob_hash ON datasources(blob_hash);
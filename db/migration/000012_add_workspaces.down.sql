@@ -0,0 +1,18 @@
+-- Migration Down: Remove workspaces
+-- Entities keep their cognito_sub, so ownership falls back to the user who created them
+
+DROP INDEX IF EXISTS idx_sales_processes_workspace_id;
+DROP INDEX IF EXISTS idx_master_briefs_workspace_id;
+DROP INDEX IF EXISTS idx_projects_workspace_id;
+DROP INDEX IF EXISTS idx_companies_workspace_id;
+
+ALTER TABLE sales_processes DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE master_briefs DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE projects DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE companies DROP COLUMN IF EXISTS workspace_id;
+
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
+
+DROP TYPE IF EXISTS workspace_role;
@@ -0,0 +1,76 @@
+-- Migration to let teams share companies, contacts, projects, master briefs and sales
+-- processes through workspaces instead of a single owning cognito_sub
+
+-- Step 1: Create workspace role enum
+-- owner: manages members and invitations; editor: changes data; viewer: reads data
+CREATE TYPE workspace_role AS ENUM ('owner', 'editor', 'viewer');
+
+-- Step 2: Create workspaces table
+CREATE TABLE workspaces (
+    workspace_id SERIAL PRIMARY KEY,
+    name VARCHAR(200) NOT NULL,
+    created_by VARCHAR REFERENCES users(cognito_sub) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Step 3: Create workspace members table
+CREATE TABLE workspace_members (
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(workspace_id) ON DELETE CASCADE,
+    cognito_sub VARCHAR NOT NULL REFERENCES users(cognito_sub) ON DELETE CASCADE,
+    role workspace_role NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (workspace_id, cognito_sub)
+);
+
+CREATE INDEX idx_workspace_members_cognito_sub ON workspace_members(cognito_sub);
+
+-- Step 4: Create workspace invitations table
+-- Only the SHA-256 of the invitation token is stored; the token itself is shown once
+CREATE TABLE workspace_invitations (
+    invitation_id SERIAL PRIMARY KEY,
+    workspace_id INTEGER NOT NULL REFERENCES workspaces(workspace_id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL, -- Matched case-insensitively against the username on acceptance
+    role workspace_role NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by VARCHAR REFERENCES users(cognito_sub) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_by VARCHAR REFERENCES users(cognito_sub) ON DELETE SET NULL,
+    accepted_at TIMESTAMP
+);
+
+CREATE INDEX idx_workspace_invitations_workspace_id ON workspace_invitations(workspace_id);
+
+-- Step 5: Let shared entities belong to a workspace
+-- Contacts belong to the workspace of their company; cognito_sub stays as the creator
+ALTER TABLE companies ADD COLUMN workspace_id INTEGER REFERENCES workspaces(workspace_id);
+ALTER TABLE projects ADD COLUMN workspace_id INTEGER REFERENCES workspaces(workspace_id);
+ALTER TABLE master_briefs ADD COLUMN workspace_id INTEGER REFERENCES workspaces(workspace_id);
+ALTER TABLE sales_processes ADD COLUMN workspace_id INTEGER REFERENCES workspaces(workspace_id);
+
+CREATE INDEX idx_companies_workspace_id ON companies(workspace_id);
+CREATE INDEX idx_projects_workspace_id ON projects(workspace_id);
+CREATE INDEX idx_master_briefs_workspace_id ON master_briefs(workspace_id);
+CREATE INDEX idx_sales_processes_workspace_id ON sales_processes(workspace_id);
+
+-- Step 6: Give every existing user a personal workspace they own
+INSERT INTO workspaces (name, created_by)
+SELECT 'Personal workspace', cognito_sub
+FROM users;
+
+INSERT INTO workspace_members (workspace_id, cognito_sub, role)
+SELECT workspace_id, created_by, 'owner'
+FROM workspaces;
+
+-- Step 7: Move existing entities into the personal workspace of their owner
+UPDATE companies c SET workspace_id = w.workspace_id
+FROM workspaces w WHERE w.created_by = c.cognito_sub;
+
+UPDATE projects p SET workspace_id = w.workspace_id
+FROM workspaces w WHERE w.created_by = p.cognito_sub;
+
+UPDATE master_briefs mb SET workspace_id = w.workspace_id
+FROM workspaces w WHERE w.created_by = mb.cognito_sub;
+
+UPDATE sales_processes sp SET workspace_id = w.workspace_id
+FROM workspaces w WHERE w.created_by = sp.cognito_sub;
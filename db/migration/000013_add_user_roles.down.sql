@@ -0,0 +1,5 @@
+-- Migration Down: Remove application-wide user roles
+
+DROP INDEX IF EXISTS idx_user_roles_role;
+
+DROP TABLE IF EXISTS user_roles;
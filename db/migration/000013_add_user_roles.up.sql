@@ -0,0 +1,15 @@
+-- Migration to give users application-wide roles such as admin, on top of the roles they
+-- hold in workspaces
+
+-- Step 1: Create user roles table
+-- Roles can also come from Cognito groups in the access token; a user holds the union of both
+CREATE TABLE user_roles (
+    cognito_sub VARCHAR NOT NULL REFERENCES users(cognito_sub) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL,
+    granted_by VARCHAR REFERENCES users(cognito_sub) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (cognito_sub, role)
+);
+
+-- Step 2: Create index to list the users holding a role
+CREATE INDEX idx_user_roles_role ON user_roles(role);
@@ -0,0 +1,5 @@
+-- Migration Down: Remove API tokens
+
+DROP INDEX IF EXISTS idx_api_tokens_cognito_sub;
+
+DROP TABLE IF EXISTS api_tokens;
@@ -0,0 +1,20 @@
+-- Migration to let users mint personal API tokens for scripts and tools that can't go
+-- through the browser login flow
+
+-- Step 1: Create API tokens table
+-- Only a SHA-256 hash of each token is stored; the token itself is shown once when created
+CREATE TABLE api_tokens (
+    token_id SERIAL PRIMARY KEY,
+    cognito_sub VARCHAR NOT NULL REFERENCES users(cognito_sub) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    token_prefix VARCHAR(16) NOT NULL, -- Start of the token, so users can tell their tokens apart
+    scopes TEXT[] NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Step 2: Create index to list a user's tokens
+CREATE INDEX idx_api_tokens_cognito_sub ON api_tokens(cognito_sub);
@@ -0,0 +1,7 @@
+-- Migration Down: Index fact values directly again
+
+DROP INDEX IF EXISTS idx_datasource_facts_unique_value;
+
+ALTER TABLE datasource_facts
+    ADD CONSTRAINT datasource_facts_datasource_id_fact_type_fact_key_value_key
+    UNIQUE (datasource_id, fact_type, fact_key, value);
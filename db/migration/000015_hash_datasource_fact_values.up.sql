@@ -0,0 +1,11 @@
+-- Migration to make fact values of any length unique. A unique constraint on the TEXT value
+-- fails once a value outgrows a btree index entry (about 2.7 kB), so the index covers an MD5
+-- hash of the value instead.
+
+-- Step 1: Drop the unique constraint on the raw value
+ALTER TABLE datasource_facts
+    DROP CONSTRAINT IF EXISTS datasource_facts_datasource_id_fact_type_fact_key_value_key;
+
+-- Step 2: Create a unique index on the hashed value
+CREATE UNIQUE INDEX idx_datasource_facts_unique_value
+    ON datasource_facts(datasource_id, fact_type, fact_key, md5(value));
@@ -0,0 +1,33 @@
+// Package migration embeds the schema migrations, so the server can tell whether the
+// database has been migrated as far as the code expects. golang-migrate ignores this file.
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// LatestVersion returns the version of the newest migration
+func LatestVersion() (int64, error) {
+	names, err := fs.Glob(files, "*.up.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s has no version: %w", name, err)
+		}
+		latest = max(latest, version)
+	}
+	return latest, nil
+}
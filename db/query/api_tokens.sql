@@ -0,0 +1,30 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (
+    cognito_sub, name, token_hash, token_prefix, scopes, expires_at
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING token_id, cognito_sub, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at;
+
+-- name: GetAPITokenByHash :one
+SELECT token_id, cognito_sub, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+FROM api_tokens
+WHERE token_hash = $1;
+
+-- name: ListAPITokensByUser :many
+SELECT token_id, cognito_sub, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at
+FROM api_tokens
+WHERE cognito_sub = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: RevokeAPIToken :one
+-- Revokes one of the user's tokens; no row is returned if it doesn't exist or was already revoked
+UPDATE api_tokens
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE token_id = $1 AND cognito_sub = $2 AND revoked_at IS NULL
+RETURNING token_id, cognito_sub, name, token_hash, token_prefix, scopes, expires_at, last_used_at, revoked_at, created_at;
+
+-- name: TouchAPIToken :exec
+-- Records that a token was used, at most once a minute to keep writes down
+UPDATE api_tokens
+SET last_used_at = CURRENT_TIMESTAMP
+WHERE token_id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute');
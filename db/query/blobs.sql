@@ -0,0 +1,27 @@
+-- name: CreateBlob :exec
+INSERT INTO blobs (
+    hash, size
+)
+VALUES ($1, $2)
+ON CONFLICT (hash) DO NOTHING;
+
+-- name: GetBlob :one
+SELECT hash, size, created_at
+FROM blobs
+WHERE hash = $1;
+
+-- name: LockBlob :one
+-- Holds the blob until the transaction ends, so it can't be deleted or re-created meanwhile
+SELECT hash
+FROM blobs
+WHERE hash = $1
+FOR UPDATE;
+
+-- name: CountBlobReferences :one
+SELECT COUNT(*)
+FROM datasources
+WHERE blob_hash = $1;
+
+-- name: DeleteBlob :exec
+DELETE FROM blobs
+WHERE hash = $1;
@@ -1,12 +1,12 @@
 -- name: CreateCompany :one
 INSERT INTO companies (
-    cognito_sub, company_name, industry, website, address, description
+    cognito_sub, company_name, industry, website, address, description, workspace_id
 )
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING company_id, cognito_sub, company_name, industry, website, address, description, created_at;
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING company_id, cognito_sub, company_name, industry, website, address, description, created_at, workspace_id;
 
 -- name: GetCompanyByID :one
-SELECT company_id, cognito_sub, company_name, industry, website, address, description, created_at
+SELECT company_id, cognito_sub, company_name, industry, website, address, description, created_at, workspace_id
 FROM companies
 WHERE company_id = $1;
 
@@ -17,6 +17,16 @@ WHERE cognito_sub = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListCompaniesByMember :many
+-- Companies in the workspaces a user belongs to, optionally in one workspace only
+SELECT c.company_id, c.cognito_sub, c.company_name, c.industry, c.website, c.address, c.description, c.created_at, c.workspace_id
+FROM companies c
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE wm.cognito_sub = sqlc.arg(cognito_sub)
+  AND (sqlc.narg(workspace_id)::int IS NULL OR c.workspace_id = sqlc.narg(workspace_id))
+ORDER BY c.created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
 -- name: GetCompanyByName :one
 SELECT company_id, cognito_sub, company_name, industry, website, address, description, created_at
 FROM companies
@@ -44,4 +44,14 @@ RETURNING contact_id, company_id, first_name, last_name, position, email, phone,
 
 -- name: DeleteContact :exec
 DELETE FROM contacts
-WHERE contact_id = $1;
\ No newline at end of file
+WHERE contact_id = $1;
+
+-- name: ListUserContactsByEmails :many
+-- Contacts with one of the given addresses in workspaces where the user may edit
+SELECT ct.contact_id, ct.company_id, ct.first_name, ct.last_name, ct.position, ct.email, ct.phone, ct.notes, ct.created_at
+FROM contacts ct
+JOIN companies c ON c.company_id = ct.company_id
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE wm.cognito_sub = sqlc.arg(cognito_sub) AND wm.role IN ('owner', 'editor')
+  AND LOWER(ct.email) = ANY(sqlc.arg(emails)::text[])
+ORDER BY ct.contact_id ASC;
@@ -0,0 +1,32 @@
+-- name: CreateDatasourceFact :one
+INSERT INTO datasource_facts (
+    datasource_id, fact_type, fact_key, value, attributes, source, source_url
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (datasource_id, fact_type, fact_key, md5(value))
+DO UPDATE SET attributes = EXCLUDED.attributes,
+    source = EXCLUDED.source,
+    source_url = EXCLUDED.source_url
+RETURNING fact_id, datasource_id, fact_type, fact_key, value, attributes, source, source_url, created_at;
+
+-- name: GetDatasourceFactByID :one
+SELECT fact_id, datasource_id, fact_type, fact_key, value, attributes, source, source_url, created_at
+FROM datasource_facts
+WHERE fact_id = $1;
+
+-- name: ListFactsByDatasource :many
+SELECT fact_id, datasource_id, fact_type, fact_key, value, attributes, source, source_url, created_at
+FROM datasource_facts
+WHERE datasource_id = $1
+ORDER BY fact_type, fact_id ASC;
+
+-- name: ListFactsByCompany :many
+SELECT f.fact_id, f.datasource_id, f.fact_type, f.fact_key, f.value, f.attributes, f.source, f.source_url, f.created_at
+FROM datasource_facts f
+JOIN company_datasources cd ON f.datasource_id = cd.datasource_id
+WHERE cd.company_id = $1
+ORDER BY f.fact_type, f.fact_id ASC;
+
+-- name: DeleteFactsByDatasource :exec
+DELETE FROM datasource_facts
+WHERE datasource_id = $1;
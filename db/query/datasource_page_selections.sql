@@ -0,0 +1,18 @@
+-- name: UpsertDatasourcePageSelection :one
+INSERT INTO datasource_page_selections (
+    datasource_id, selection
+)
+VALUES ($1, $2)
+ON CONFLICT (datasource_id)
+DO UPDATE SET selection = EXCLUDED.selection,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING datasource_id, selection, created_at, updated_at;
+
+-- name: GetDatasourcePageSelection :one
+SELECT datasource_id, selection, created_at, updated_at
+FROM datasource_page_selections
+WHERE datasource_id = $1;
+
+-- name: DeleteDatasourcePageSelection :exec
+DELETE FROM datasource_page_selections
+WHERE datasource_id = $1;
@@ -0,0 +1,15 @@
+-- name: UpsertDatasourceSiteTree :one
+INSERT INTO datasource_site_trees (
+    datasource_id, tree, page_count
+)
+VALUES ($1, $2, $3)
+ON CONFLICT (datasource_id)
+DO UPDATE SET tree = EXCLUDED.tree,
+    page_count = EXCLUDED.page_count,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING datasource_id, tree, page_count, created_at, updated_at;
+
+-- name: GetDatasourceSiteTree :one
+SELECT datasource_id, tree, page_count, created_at, updated_at
+FROM datasource_site_trees
+WHERE datasource_id = $1;
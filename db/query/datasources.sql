@@ -1,12 +1,12 @@
 -- name: CreateDatasource :one
 INSERT INTO datasources (
-    source_type, link, file_data, file_name
+    source_type, link, file_data, file_name, blob_hash
 )
-VALUES ($1, $2, $3, $4)
-RETURNING datasource_id, source_type, link, file_data, file_name, created_at;
+VALUES ($1, $2, $3, $4, $5)
+RETURNING datasource_id, source_type, link, file_data, file_name, created_at, blob_hash;
 
 -- name: GetDatasourceByID :one
-SELECT datasource_id, source_type, link, file_name, created_at
+SELECT datasource_id, source_type, link, file_name, created_at, blob_hash
 FROM datasources
 WHERE datasource_id = $1;
 
@@ -28,6 +28,43 @@ DELETE FROM datasources
 WHERE datasource_id = $1;
 
 -- name: GetFullDatasourceByID :one
-SELECT datasource_id, source_type, link, file_data, file_name, created_at
+SELECT datasource_id, source_type, link, file_data, file_name, created_at, blob_hash
+FROM datasources
+WHERE datasource_id = $1;
+
+-- name: ListDatasourceIDsWithFileData :many
+-- Datasources whose file is still stored in the database, in batches after a datasource ID
+SELECT datasource_id
 FROM datasources
-WHERE datasource_id = $1;
\ No newline at end of file
+WHERE datasource_id > $1 AND blob_hash IS NULL AND file_data IS NOT NULL AND length(file_data) > 0
+ORDER BY datasource_id
+LIMIT $2;
+
+-- name: SetDatasourceBlob :exec
+UPDATE datasources
+SET blob_hash = $2, file_data = NULL
+WHERE datasource_id = $1;
+
+-- name: ListDatasourceMembers :many
+-- Users who can access a datasource through the companies, contacts or projects it is
+-- associated with, and their role in the workspace those belong to. A user in several of
+-- those workspaces appears once per distinct role.
+SELECT wm.cognito_sub, wm.role
+FROM company_datasources cd
+JOIN companies c ON c.company_id = cd.company_id
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE cd.datasource_id = $1
+UNION
+SELECT wm.cognito_sub, wm.role
+FROM contact_datasources ctd
+JOIN contacts ct ON ct.contact_id = ctd.contact_id
+JOIN companies c ON c.company_id = ct.company_id
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE ctd.datasource_id = $1
+UNION
+SELECT wm.cognito_sub, wm.role
+FROM project_datasources pd
+JOIN projects p ON p.project_id = pd.project_id
+JOIN workspace_members wm ON wm.workspace_id = p.workspace_id
+WHERE pd.datasource_id = $1
+ORDER BY cognito_sub, role;
@@ -4,32 +4,41 @@
 
 -- name: CreateMasterBrief :one
 INSERT INTO master_briefs (
-    cognito_sub, company_id, contact_id, company_reference, contact_reference
+    cognito_sub, company_id, contact_id, company_reference, contact_reference, workspace_id
 )
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at;
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id;
 
 -- name: GetMasterBriefByID :one
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE id = $1;
 
 -- name: ListMasterBriefsByUser :many
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE cognito_sub = $1
 ORDER BY updated_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListMasterBriefsByMember :many
+-- Master briefs in the workspaces a user belongs to
+SELECT mb.id, mb.cognito_sub, mb.company_id, mb.contact_id, mb.company_reference, mb.contact_reference, mb.created_at, mb.updated_at, mb.workspace_id
+FROM master_briefs mb
+JOIN workspace_members wm ON wm.workspace_id = mb.workspace_id
+WHERE wm.cognito_sub = $1
+ORDER BY mb.updated_at DESC
+LIMIT $2 OFFSET $3;
+
 -- name: ListMasterBriefsByCompany :many
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE company_id = $1
 ORDER BY updated_at DESC
 LIMIT $2 OFFSET $3;
 
 -- name: ListMasterBriefsByContact :many
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE contact_id = $1
 ORDER BY updated_at DESC
@@ -43,7 +52,7 @@ SET company_id = $2,
     contact_reference = $5,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at;
+RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id;
 
 -- name: DeleteMasterBrief :exec
 DELETE FROM master_briefs
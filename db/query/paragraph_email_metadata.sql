@@ -0,0 +1,17 @@
+-- name: CreateParagraphEmailMetadata :one
+INSERT INTO paragraph_email_metadata (
+    paragraph_id, message_id, subject, sender_name, sender_email, recipients, sent_at
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING paragraph_id, message_id, subject, sender_name, sender_email, recipients, sent_at, created_at;
+
+-- name: GetParagraphEmailMetadata :one
+SELECT paragraph_id, message_id, subject, sender_name, sender_email, recipients, sent_at, created_at
+FROM paragraph_email_metadata
+WHERE paragraph_id = $1;
+
+-- name: ListParagraphEmailMetadata :many
+SELECT paragraph_id, message_id, subject, sender_name, sender_email, recipients, sent_at, created_at
+FROM paragraph_email_metadata
+WHERE paragraph_id = ANY(sqlc.arg(paragraph_ids)::int[])
+ORDER BY paragraph_id ASC;
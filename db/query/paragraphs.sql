@@ -34,4 +34,8 @@ RETURNING paragraph_id, datasource_id, title, main_idea, content, created_at;
 
 -- name: DeleteParagraph :exec
 DELETE FROM paragraphs
-WHERE paragraph_id = $1;
\ No newline at end of file
+WHERE paragraph_id = $1;
+
+-- name: DeleteParagraphsByDatasource :exec
+DELETE FROM paragraphs
+WHERE datasource_id = $1;
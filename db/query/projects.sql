@@ -1,12 +1,12 @@
 -- name: CreateProject :one
 INSERT INTO projects (
-    cognito_sub, project_name, main_idea
+    cognito_sub, project_name, main_idea, workspace_id
 )
-VALUES ($1, $2, $3)
-RETURNING project_id, cognito_sub, project_name, main_idea, created_at, updated_at;
+VALUES ($1, $2, $3, $4)
+RETURNING project_id, cognito_sub, project_name, main_idea, created_at, updated_at, workspace_id;
 
 -- name: GetProjectByID :one
-SELECT project_id, cognito_sub, project_name, main_idea, created_at, updated_at
+SELECT project_id, cognito_sub, project_name, main_idea, created_at, updated_at, workspace_id
 FROM projects
 WHERE project_id = $1;
 
@@ -17,6 +17,16 @@ WHERE cognito_sub = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListProjectsByMember :many
+-- Projects in the workspaces a user belongs to, optionally in one workspace only
+SELECT p.project_id, p.cognito_sub, p.project_name, p.main_idea, p.created_at, p.updated_at, p.workspace_id
+FROM projects p
+JOIN workspace_members wm ON wm.workspace_id = p.workspace_id
+WHERE wm.cognito_sub = sqlc.arg(cognito_sub)
+  AND (sqlc.narg(workspace_id)::int IS NULL OR p.workspace_id = sqlc.narg(workspace_id))
+ORDER BY p.created_at DESC
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
 -- name: SearchProjectsByName :many
 SELECT project_id, cognito_sub, project_name, main_idea, created_at, updated_at
 FROM projects
@@ -1,12 +1,12 @@
 -- name: CreateSalesProcess :one
 INSERT INTO sales_processes (
-    cognito_sub, contact_id, overall_matching_score, status
+    cognito_sub, contact_id, overall_matching_score, status, workspace_id
 )
-VALUES ($1, $2, $3, $4)
-RETURNING sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at;
+VALUES ($1, $2, $3, $4, $5)
+RETURNING sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at, workspace_id;
 
 -- name: GetSalesProcessByID :one
-SELECT sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at
+SELECT sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at, workspace_id
 FROM sales_processes
 WHERE sales_process_id = $1;
 
@@ -17,6 +17,15 @@ WHERE cognito_sub = $1
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $3;
 
+-- name: ListSalesProcessesByMember :many
+-- Sales processes in the workspaces a user belongs to
+SELECT sp.sales_process_id, sp.cognito_sub, sp.contact_id, sp.overall_matching_score, sp.status, sp.created_at, sp.updated_at, sp.workspace_id
+FROM sales_processes sp
+JOIN workspace_members wm ON wm.workspace_id = sp.workspace_id
+WHERE wm.cognito_sub = $1
+ORDER BY sp.created_at DESC
+LIMIT $2 OFFSET $3;
+
 -- name: ListSalesProcessesByContact :many
 SELECT sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at
 FROM sales_processes
@@ -0,0 +1,16 @@
+-- name: GrantUserRole :exec
+INSERT INTO user_roles (
+    cognito_sub, role, granted_by
+)
+VALUES ($1, $2, $3)
+ON CONFLICT (cognito_sub, role) DO NOTHING;
+
+-- name: ListUserRoles :many
+SELECT role
+FROM user_roles
+WHERE cognito_sub = $1
+ORDER BY role ASC;
+
+-- name: RevokeUserRole :exec
+DELETE FROM user_roles
+WHERE cognito_sub = $1 AND role = $2;
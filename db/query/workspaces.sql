@@ -0,0 +1,109 @@
+-- name: CreateWorkspace :one
+INSERT INTO workspaces (
+    name, created_by
+)
+VALUES ($1, $2)
+RETURNING workspace_id, name, created_by, created_at;
+
+-- name: GetWorkspaceByID :one
+SELECT workspace_id, name, created_by, created_at
+FROM workspaces
+WHERE workspace_id = $1;
+
+-- name: GetDefaultWorkspace :one
+-- The oldest workspace a user owns, where their new companies and projects go
+SELECT w.workspace_id, w.name, w.created_by, w.created_at
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.workspace_id
+WHERE wm.cognito_sub = $1 AND wm.role = 'owner'
+ORDER BY w.created_at ASC, w.workspace_id ASC
+LIMIT 1;
+
+-- name: ListWorkspacesByMember :many
+SELECT w.workspace_id, w.name, w.created_by, w.created_at, wm.role
+FROM workspaces w
+JOIN workspace_members wm ON wm.workspace_id = w.workspace_id
+WHERE wm.cognito_sub = $1
+ORDER BY w.created_at ASC, w.workspace_id ASC;
+
+-- name: UpdateWorkspace :one
+UPDATE workspaces
+SET name = $2
+WHERE workspace_id = $1
+RETURNING workspace_id, name, created_by, created_at;
+
+-- name: DeleteWorkspace :exec
+DELETE FROM workspaces
+WHERE workspace_id = $1;
+
+-- name: CountWorkspaceItems :one
+-- Companies, projects, master briefs and sales processes still in a workspace
+SELECT
+    (SELECT COUNT(*) FROM companies c WHERE c.workspace_id = $1) +
+    (SELECT COUNT(*) FROM projects p WHERE p.workspace_id = $1) +
+    (SELECT COUNT(*) FROM master_briefs mb WHERE mb.workspace_id = $1) +
+    (SELECT COUNT(*) FROM sales_processes sp WHERE sp.workspace_id = $1) AS item_count;
+
+-- name: AddWorkspaceMember :one
+INSERT INTO workspace_members (
+    workspace_id, cognito_sub, role
+)
+VALUES ($1, $2, $3)
+ON CONFLICT (workspace_id, cognito_sub) DO UPDATE SET role = EXCLUDED.role
+RETURNING workspace_id, cognito_sub, role, created_at;
+
+-- name: GetWorkspaceMember :one
+SELECT workspace_id, cognito_sub, role, created_at
+FROM workspace_members
+WHERE workspace_id = $1 AND cognito_sub = $2;
+
+-- name: ListWorkspaceMembers :many
+SELECT wm.workspace_id, wm.cognito_sub, u.username, wm.role, wm.created_at
+FROM workspace_members wm
+JOIN users u ON u.cognito_sub = wm.cognito_sub
+WHERE wm.workspace_id = $1
+ORDER BY wm.created_at ASC;
+
+-- name: UpdateWorkspaceMemberRole :one
+UPDATE workspace_members
+SET role = $3
+WHERE workspace_id = $1 AND cognito_sub = $2
+RETURNING workspace_id, cognito_sub, role, created_at;
+
+-- name: RemoveWorkspaceMember :exec
+DELETE FROM workspace_members
+WHERE workspace_id = $1 AND cognito_sub = $2;
+
+-- name: CountWorkspaceOwners :one
+SELECT COUNT(*)
+FROM workspace_members
+WHERE workspace_id = $1 AND role = 'owner';
+
+-- name: CreateWorkspaceInvitation :one
+INSERT INTO workspace_invitations (
+    workspace_id, email, role, token_hash, invited_by, expires_at
+)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING invitation_id, workspace_id, email, role, token_hash, invited_by, created_at, expires_at, accepted_by, accepted_at;
+
+-- name: GetWorkspaceInvitationByTokenHash :one
+SELECT invitation_id, workspace_id, email, role, token_hash, invited_by, created_at, expires_at, accepted_by, accepted_at
+FROM workspace_invitations
+WHERE token_hash = $1;
+
+-- name: ListPendingWorkspaceInvitations :many
+SELECT invitation_id, workspace_id, email, role, token_hash, invited_by, created_at, expires_at, accepted_by, accepted_at
+FROM workspace_invitations
+WHERE workspace_id = $1 AND accepted_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+ORDER BY created_at DESC;
+
+-- name: AcceptWorkspaceInvitation :one
+-- Marks a pending invitation as accepted; no row is returned if it was already used
+UPDATE workspace_invitations
+SET accepted_by = $2, accepted_at = CURRENT_TIMESTAMP
+WHERE invitation_id = $1 AND accepted_at IS NULL
+RETURNING invitation_id, workspace_id, email, role, token_hash, invited_by, created_at, expires_at, accepted_by, accepted_at;
+
+-- name: DeleteWorkspaceInvitation :exec
+DELETE FROM workspace_invitations
+WHERE invitation_id = $1 AND workspace_id = $2;
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CreateBlobTx records a blob and calls put to store its content while holding the blob's
+// row, so a concurrent DeleteBlobTx can't remove the content put relies on
+func (store *Store) CreateBlobTx(ctx context.Context, arg CreateBlobParams, put func() error) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		if err := q.CreateBlob(ctx, arg); err != nil {
+			return fmt.Errorf("failed to record blob: %w", err)
+		}
+		if _, err := q.LockBlob(ctx, arg.Hash); err != nil {
+			return fmt.Errorf("failed to lock blob: %w", err)
+		}
+		return put()
+	})
+}
+
+// DeleteBlobTx deletes a blob no datasource references. remove is called to delete the
+// content before the row's deletion is committed, so an upload of the same content waiting
+// on the row stores it again. It reports whether the blob was deleted.
+func (store *Store) DeleteBlobTx(ctx context.Context, hash string, remove func() error) (bool, error) {
+	deleted := false
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// The lock also keeps new datasources from referencing the blob until it's gone
+		_, err := q.LockBlob(ctx, hash)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to lock blob: %w", err)
+		}
+
+		count, err := q.CountBlobReferences(ctx, sql.NullString{String: hash, Valid: true})
+		if err != nil {
+			return fmt.Errorf("failed to count blob references: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+
+		if err := q.DeleteBlob(ctx, hash); err != nil {
+			return fmt.Errorf("failed to delete blob: %w", err)
+		}
+		if err := remove(); err != nil {
+			return fmt.Errorf("failed to delete blob content: %w", err)
+		}
+		deleted = true
+		return nil
+	})
+
+	return deleted, err
+}
@@ -12,10 +12,10 @@ import (
 
 const createCompany = `-- name: CreateCompany :one
 INSERT INTO companies (
-    cognito_sub, company_name, industry, website, address, description
+    cognito_sub, company_name, industry, website, address, description, workspace_id
 )
-VALUES ($1, $2, $3, $4, $5, $6)
-RETURNING company_id, cognito_sub, company_name, industry, website, address, description, created_at
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING company_id, cognito_sub, company_name, industry, website, address, description, created_at, workspace_id
 `
 
 type CreateCompanyParams struct {
@@ -25,6 +25,7 @@ type CreateCompanyParams struct {
 	Website     sql.NullString `json:"website"`
 	Address     sql.NullString `json:"address"`
 	Description sql.NullString `json:"description"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 type CreateCompanyRow struct {
@@ -36,6 +37,7 @@ type CreateCompanyRow struct {
 	Address     sql.NullString `json:"address"`
 	Description sql.NullString `json:"description"`
 	CreatedAt   sql.NullTime   `json:"created_at"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 func (q *Queries) CreateCompany(ctx context.Context, arg CreateCompanyParams) (CreateCompanyRow, error) {
@@ -46,6 +48,7 @@ func (q *Queries) CreateCompany(ctx context.Context, arg CreateCompanyParams) (C
 		arg.Website,
 		arg.Address,
 		arg.Description,
+		arg.WorkspaceID,
 	)
 	var i CreateCompanyRow
 	err := row.Scan(
@@ -57,6 +60,7 @@ func (q *Queries) CreateCompany(ctx context.Context, arg CreateCompanyParams) (C
 		&i.Address,
 		&i.Description,
 		&i.CreatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
@@ -129,7 +133,7 @@ func (q *Queries) GetCompaniesByCognitoSub(ctx context.Context, arg GetCompanies
 }
 
 const getCompanyByID = `-- name: GetCompanyByID :one
-SELECT company_id, cognito_sub, company_name, industry, website, address, description, created_at
+SELECT company_id, cognito_sub, company_name, industry, website, address, description, created_at, workspace_id
 FROM companies
 WHERE company_id = $1
 `
@@ -143,6 +147,7 @@ type GetCompanyByIDRow struct {
 	Address     sql.NullString `json:"address"`
 	Description sql.NullString `json:"description"`
 	CreatedAt   sql.NullTime   `json:"created_at"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 func (q *Queries) GetCompanyByID(ctx context.Context, companyID int32) (GetCompanyByIDRow, error) {
@@ -157,6 +162,7 @@ func (q *Queries) GetCompanyByID(ctx context.Context, companyID int32) (GetCompa
 		&i.Address,
 		&i.Description,
 		&i.CreatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
@@ -249,6 +255,74 @@ func (q *Queries) ListCompanies(ctx context.Context, arg ListCompaniesParams) ([
 	return items, nil
 }
 
+const listCompaniesByMember = `-- name: ListCompaniesByMember :many
+SELECT c.company_id, c.cognito_sub, c.company_name, c.industry, c.website, c.address, c.description, c.created_at, c.workspace_id
+FROM companies c
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE wm.cognito_sub = $1
+  AND ($2::int IS NULL OR c.workspace_id = $2)
+ORDER BY c.created_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListCompaniesByMemberParams struct {
+	CognitoSub  string        `json:"cognito_sub"`
+	WorkspaceID sql.NullInt32 `json:"workspace_id"`
+	RowLimit    int32         `json:"row_limit"`
+	RowOffset   int32         `json:"row_offset"`
+}
+
+type ListCompaniesByMemberRow struct {
+	CompanyID   int32          `json:"company_id"`
+	CognitoSub  sql.NullString `json:"cognito_sub"`
+	CompanyName string         `json:"company_name"`
+	Industry    sql.NullString `json:"industry"`
+	Website     sql.NullString `json:"website"`
+	Address     sql.NullString `json:"address"`
+	Description sql.NullString `json:"description"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
+}
+
+// Companies in the workspaces a user belongs to, optionally in one workspace only
+func (q *Queries) ListCompaniesByMember(ctx context.Context, arg ListCompaniesByMemberParams) ([]ListCompaniesByMemberRow, error) {
+	rows, err := q.db.QueryContext(ctx, listCompaniesByMember,
+		arg.CognitoSub,
+		arg.WorkspaceID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCompaniesByMemberRow
+	for rows.Next() {
+		var i ListCompaniesByMemberRow
+		if err := rows.Scan(
+			&i.CompanyID,
+			&i.CognitoSub,
+			&i.CompanyName,
+			&i.Industry,
+			&i.Website,
+			&i.Address,
+			&i.Description,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateCompany = `-- name: UpdateCompany :one
 UPDATE companies
 SET company_name = $2,
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// ApplyCompanyEnrichmentTxParams contains the input parameters of the apply company enrichment transaction
+type ApplyCompanyEnrichmentTxParams struct {
+	Company  *UpdateCompanyParams // Nil when no company fields change
+	Contacts []CreateContactParams
+}
+
+// ApplyCompanyEnrichmentTx updates a company and creates contacts from its extracted facts.
+// Either all of the changes are saved or none are.
+func (store *Store) ApplyCompanyEnrichmentTx(ctx context.Context, arg ApplyCompanyEnrichmentTxParams) ([]Contact, error) {
+	contacts := make([]Contact, 0, len(arg.Contacts))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if arg.Company != nil {
+			if _, err := q.UpdateCompany(ctx, *arg.Company); err != nil {
+				return fmt.Errorf("failed to update company: %w", err)
+			}
+		}
+
+		for _, contactArg := range arg.Contacts {
+			contact, err := q.CreateContact(ctx, contactArg)
+			if err != nil {
+				return fmt.Errorf("failed to create contact: %w", err)
+			}
+			contacts = append(contacts, contact)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
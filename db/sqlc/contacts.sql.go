@@ -8,6 +8,8 @@ package db
 import (
 	"context"
 	"database/sql"
+
+	"github.com/lib/pq"
 )
 
 const createContact = `-- name: CreateContact :one
@@ -133,6 +135,55 @@ func (q *Queries) ListContactsByCompanyID(ctx context.Context, arg ListContactsB
 	return items, nil
 }
 
+const listUserContactsByEmails = `-- name: ListUserContactsByEmails :many
+SELECT ct.contact_id, ct.company_id, ct.first_name, ct.last_name, ct.position, ct.email, ct.phone, ct.notes, ct.created_at
+FROM contacts ct
+JOIN companies c ON c.company_id = ct.company_id
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE wm.cognito_sub = $1 AND wm.role IN ('owner', 'editor')
+  AND LOWER(ct.email) = ANY($2::text[])
+ORDER BY ct.contact_id ASC
+`
+
+type ListUserContactsByEmailsParams struct {
+	CognitoSub string   `json:"cognito_sub"`
+	Emails     []string `json:"emails"`
+}
+
+// Contacts with one of the given addresses in workspaces where the user may edit
+func (q *Queries) ListUserContactsByEmails(ctx context.Context, arg ListUserContactsByEmailsParams) ([]Contact, error) {
+	rows, err := q.db.QueryContext(ctx, listUserContactsByEmails, arg.CognitoSub, pq.Array(arg.Emails))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Contact
+	for rows.Next() {
+		var i Contact
+		if err := rows.Scan(
+			&i.ContactID,
+			&i.CompanyID,
+			&i.FirstName,
+			&i.LastName,
+			&i.Position,
+			&i.Email,
+			&i.Phone,
+			&i.Notes,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const searchContactsByCompanyAndName = `-- name: SearchContactsByCompanyAndName :many
 SELECT contact_id, company_id, first_name, last_name, position, email, phone, notes, created_at
 FROM contacts
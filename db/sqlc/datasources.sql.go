@@ -12,10 +12,10 @@ import (
 
 const createDatasource = `-- name: CreateDatasource :one
 INSERT INTO datasources (
-    source_type, link, file_data, file_name
+    source_type, link, file_data, file_name, blob_hash
 )
-VALUES ($1, $2, $3, $4)
-RETURNING datasource_id, source_type, link, file_data, file_name, created_at
+VALUES ($1, $2, $3, $4, $5)
+RETURNING datasource_id, source_type, link, file_data, file_name, created_at, blob_hash
 `
 
 type CreateDatasourceParams struct {
@@ -23,6 +23,7 @@ type CreateDatasourceParams struct {
 	Link       sql.NullString `json:"link"`
 	FileData   []byte         `json:"file_data"`
 	FileName   sql.NullString `json:"file_name"`
+	BlobHash   sql.NullString `json:"blob_hash"`
 }
 
 func (q *Queries) CreateDatasource(ctx context.Context, arg CreateDatasourceParams) (Datasource, error) {
@@ -31,6 +32,7 @@ func (q *Queries) CreateDatasource(ctx context.Context, arg CreateDatasourcePara
 		arg.Link,
 		arg.FileData,
 		arg.FileName,
+		arg.BlobHash,
 	)
 	var i Datasource
 	err := row.Scan(
@@ -40,6 +42,7 @@ func (q *Queries) CreateDatasource(ctx context.Context, arg CreateDatasourcePara
 		&i.FileData,
 		&i.FileName,
 		&i.CreatedAt,
+		&i.BlobHash,
 	)
 	return i, err
 }
@@ -55,7 +58,7 @@ func (q *Queries) DeleteDatasource(ctx context.Context, datasourceID int32) erro
 }
 
 const getDatasourceByID = `-- name: GetDatasourceByID :one
-SELECT datasource_id, source_type, link, file_name, created_at
+SELECT datasource_id, source_type, link, file_name, created_at, blob_hash
 FROM datasources
 WHERE datasource_id = $1
 `
@@ -66,6 +69,7 @@ type GetDatasourceByIDRow struct {
 	Link         sql.NullString `json:"link"`
 	FileName     sql.NullString `json:"file_name"`
 	CreatedAt    sql.NullTime   `json:"created_at"`
+	BlobHash     sql.NullString `json:"blob_hash"`
 }
 
 func (q *Queries) GetDatasourceByID(ctx context.Context, datasourceID int32) (GetDatasourceByIDRow, error) {
@@ -77,12 +81,13 @@ func (q *Queries) GetDatasourceByID(ctx context.Context, datasourceID int32) (Ge
 		&i.Link,
 		&i.FileName,
 		&i.CreatedAt,
+		&i.BlobHash,
 	)
 	return i, err
 }
 
 const getFullDatasourceByID = `-- name: GetFullDatasourceByID :one
-SELECT datasource_id, source_type, link, file_data, file_name, created_at
+SELECT datasource_id, source_type, link, file_data, file_name, created_at, blob_hash
 FROM datasources
 WHERE datasource_id = $1
 `
@@ -97,10 +102,101 @@ func (q *Queries) GetFullDatasourceByID(ctx context.Context, datasourceID int32)
 		&i.FileData,
 		&i.FileName,
 		&i.CreatedAt,
+		&i.BlobHash,
 	)
 	return i, err
 }
 
+const listDatasourceIDsWithFileData = `-- name: ListDatasourceIDsWithFileData :many
+SELECT datasource_id
+FROM datasources
+WHERE datasource_id > $1 AND blob_hash IS NULL AND file_data IS NOT NULL AND length(file_data) > 0
+ORDER BY datasource_id
+LIMIT $2
+`
+
+type ListDatasourceIDsWithFileDataParams struct {
+	DatasourceID int32 `json:"datasource_id"`
+	Limit        int32 `json:"limit"`
+}
+
+// Datasources whose file is still stored in the database, in batches after a datasource ID
+func (q *Queries) ListDatasourceIDsWithFileData(ctx context.Context, arg ListDatasourceIDsWithFileDataParams) ([]int32, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasourceIDsWithFileData, arg.DatasourceID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var datasource_id int32
+		if err := rows.Scan(&datasource_id); err != nil {
+			return nil, err
+		}
+		items = append(items, datasource_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDatasourceMembers = `-- name: ListDatasourceMembers :many
+SELECT wm.cognito_sub, wm.role
+FROM company_datasources cd
+JOIN companies c ON c.company_id = cd.company_id
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE cd.datasource_id = $1
+UNION
+SELECT wm.cognito_sub, wm.role
+FROM contact_datasources ctd
+JOIN contacts ct ON ct.contact_id = ctd.contact_id
+JOIN companies c ON c.company_id = ct.company_id
+JOIN workspace_members wm ON wm.workspace_id = c.workspace_id
+WHERE ctd.datasource_id = $1
+UNION
+SELECT wm.cognito_sub, wm.role
+FROM project_datasources pd
+JOIN projects p ON p.project_id = pd.project_id
+JOIN workspace_members wm ON wm.workspace_id = p.workspace_id
+WHERE pd.datasource_id = $1
+ORDER BY cognito_sub, role
+`
+
+type ListDatasourceMembersRow struct {
+	CognitoSub string        `json:"cognito_sub"`
+	Role       WorkspaceRole `json:"role"`
+}
+
+// Users who can access a datasource through the companies, contacts or projects it is
+// associated with, and their role in the workspace those belong to. A user in several of
+// those workspaces appears once per distinct role.
+func (q *Queries) ListDatasourceMembers(ctx context.Context, datasourceID int32) ([]ListDatasourceMembersRow, error) {
+	rows, err := q.db.QueryContext(ctx, listDatasourceMembers, datasourceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListDatasourceMembersRow
+	for rows.Next() {
+		var i ListDatasourceMembersRow
+		if err := rows.Scan(&i.CognitoSub, &i.Role); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listDatasources = `-- name: ListDatasources :many
 SELECT datasource_id, source_type, link, file_name, created_at
 FROM datasources
@@ -200,3 +296,19 @@ func (q *Queries) ListDatasourcesByType(ctx context.Context, arg ListDatasources
 	}
 	return items, nil
 }
+
+const setDatasourceBlob = `-- name: SetDatasourceBlob :exec
+UPDATE datasources
+SET blob_hash = $2, file_data = NULL
+WHERE datasource_id = $1
+`
+
+type SetDatasourceBlobParams struct {
+	DatasourceID int32          `json:"datasource_id"`
+	BlobHash     sql.NullString `json:"blob_hash"`
+}
+
+func (q *Queries) SetDatasourceBlob(ctx context.Context, arg SetDatasourceBlobParams) error {
+	_, err := q.db.ExecContext(ctx, setDatasourceBlob, arg.DatasourceID, arg.BlobHash)
+	return err
+}
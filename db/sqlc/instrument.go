@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mbaxamb3/nusli/metrics"
+)
+
+// instrumentedDB times and traces the queries run by Queries. Each query is named after the
+// Queries method that runs it, e.g. "GetUser".
+type instrumentedDB struct {
+	DBTX
+}
+
+func (d instrumentedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	q := startQuery(ctx, queryName(), query)
+	result, err := d.DBTX.ExecContext(q.ctx, query, args...)
+	q.end(err)
+	return result, err
+}
+
+func (d instrumentedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	q := startQuery(ctx, queryName(), query)
+	stmt, err := d.DBTX.PrepareContext(q.ctx, query)
+	q.end(err)
+	return stmt, err
+}
+
+func (d instrumentedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	q := startQuery(ctx, queryName(), query)
+	rows, err := d.DBTX.QueryContext(q.ctx, query, args...)
+	q.end(err)
+	return rows, err
+}
+
+func (d instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	q := startQuery(ctx, queryName(), query)
+	row := d.DBTX.QueryRowContext(q.ctx, query, args...)
+	// sql.ErrNoRows only shows up in Scan; it isn't a failed query anyway
+	q.end(row.Err())
+	return row
+}
+
+// runningQuery is a query whose time and span are being recorded
+type runningQuery struct {
+	ctx   context.Context
+	name  string
+	start time.Time
+	span  trace.Span
+}
+
+// startQuery starts a client span for a query as a child of the span in ctx
+func startQuery(ctx context.Context, name, query string) runningQuery {
+	ctx, span := otel.Tracer("github.com/mbaxamb3/nusli/db").Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", name),
+			attribute.String("db.query.text", query),
+		))
+	return runningQuery{ctx: ctx, name: name, start: time.Now(), span: span}
+}
+
+// end records the query's duration and ends its span
+func (q runningQuery) end(err error) {
+	metrics.ObserveQuery(q.name, time.Since(q.start), err)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		q.span.RecordError(err)
+		q.span.SetStatus(codes.Error, err.Error())
+	}
+	q.span.End()
+}
+
+// queriesPrefix starts the function names of Queries methods in stack traces
+const queriesPrefix = "(*Queries)."
+
+// queryName returns the name of the Queries method up the stack, or "other"
+func queryName() string {
+	var pcs [8]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if _, name, ok := strings.Cut(frame.Function, queriesPrefix); ok {
+			return name
+		}
+		if !more {
+			return "other"
+		}
+	}
+}
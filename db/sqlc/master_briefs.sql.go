@@ -15,10 +15,10 @@ import (
 const createMasterBrief = `-- name: CreateMasterBrief :one
 
 INSERT INTO master_briefs (
-    cognito_sub, company_id, contact_id, company_reference, contact_reference
+    cognito_sub, company_id, contact_id, company_reference, contact_reference, workspace_id
 )
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 `
 
 type CreateMasterBriefParams struct {
@@ -27,6 +27,7 @@ type CreateMasterBriefParams struct {
 	ContactID        sql.NullInt32 `json:"contact_id"`
 	CompanyReference string        `json:"company_reference"`
 	ContactReference string        `json:"contact_reference"`
+	WorkspaceID      sql.NullInt32 `json:"workspace_id"`
 }
 
 // =============================================================================
@@ -39,6 +40,7 @@ func (q *Queries) CreateMasterBrief(ctx context.Context, arg CreateMasterBriefPa
 		arg.ContactID,
 		arg.CompanyReference,
 		arg.ContactReference,
+		arg.WorkspaceID,
 	)
 	var i MasterBrief
 	err := row.Scan(
@@ -50,6 +52,7 @@ func (q *Queries) CreateMasterBrief(ctx context.Context, arg CreateMasterBriefPa
 		&i.ContactReference,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
@@ -65,7 +68,7 @@ func (q *Queries) DeleteMasterBrief(ctx context.Context, id uuid.UUID) error {
 }
 
 const getMasterBriefByID = `-- name: GetMasterBriefByID :one
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE id = $1
 `
@@ -82,12 +85,13 @@ func (q *Queries) GetMasterBriefByID(ctx context.Context, id uuid.UUID) (MasterB
 		&i.ContactReference,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
 
 const listMasterBriefsByCompany = `-- name: ListMasterBriefsByCompany :many
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE company_id = $1
 ORDER BY updated_at DESC
@@ -118,6 +122,7 @@ func (q *Queries) ListMasterBriefsByCompany(ctx context.Context, arg ListMasterB
 			&i.ContactReference,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.WorkspaceID,
 		); err != nil {
 			return nil, err
 		}
@@ -133,7 +138,7 @@ func (q *Queries) ListMasterBriefsByCompany(ctx context.Context, arg ListMasterB
 }
 
 const listMasterBriefsByContact = `-- name: ListMasterBriefsByContact :many
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE contact_id = $1
 ORDER BY updated_at DESC
@@ -164,6 +169,56 @@ func (q *Queries) ListMasterBriefsByContact(ctx context.Context, arg ListMasterB
 			&i.ContactReference,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMasterBriefsByMember = `-- name: ListMasterBriefsByMember :many
+SELECT mb.id, mb.cognito_sub, mb.company_id, mb.contact_id, mb.company_reference, mb.contact_reference, mb.created_at, mb.updated_at, mb.workspace_id
+FROM master_briefs mb
+JOIN workspace_members wm ON wm.workspace_id = mb.workspace_id
+WHERE wm.cognito_sub = $1
+ORDER BY mb.updated_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListMasterBriefsByMemberParams struct {
+	CognitoSub string `json:"cognito_sub"`
+	Limit      int32  `json:"limit"`
+	Offset     int32  `json:"offset"`
+}
+
+// Master briefs in the workspaces a user belongs to
+func (q *Queries) ListMasterBriefsByMember(ctx context.Context, arg ListMasterBriefsByMemberParams) ([]MasterBrief, error) {
+	rows, err := q.db.QueryContext(ctx, listMasterBriefsByMember, arg.CognitoSub, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MasterBrief
+	for rows.Next() {
+		var i MasterBrief
+		if err := rows.Scan(
+			&i.ID,
+			&i.CognitoSub,
+			&i.CompanyID,
+			&i.ContactID,
+			&i.CompanyReference,
+			&i.ContactReference,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorkspaceID,
 		); err != nil {
 			return nil, err
 		}
@@ -179,7 +234,7 @@ func (q *Queries) ListMasterBriefsByContact(ctx context.Context, arg ListMasterB
 }
 
 const listMasterBriefsByUser = `-- name: ListMasterBriefsByUser :many
-SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+SELECT id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 FROM master_briefs
 WHERE cognito_sub = $1
 ORDER BY updated_at DESC
@@ -210,6 +265,7 @@ func (q *Queries) ListMasterBriefsByUser(ctx context.Context, arg ListMasterBrie
 			&i.ContactReference,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.WorkspaceID,
 		); err != nil {
 			return nil, err
 		}
@@ -232,7 +288,7 @@ SET company_id = $2,
     contact_reference = $5,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at
+RETURNING id, cognito_sub, company_id, contact_id, company_reference, contact_reference, created_at, updated_at, workspace_id
 `
 
 type UpdateMasterBriefParams struct {
@@ -261,6 +317,7 @@ func (q *Queries) UpdateMasterBrief(ctx context.Context, arg UpdateMasterBriefPa
 		&i.ContactReference,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
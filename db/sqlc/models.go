@@ -7,6 +7,7 @@ package db
 import (
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -111,6 +112,7 @@ const (
 	DatasourceTypeExcel        DatasourceType = "excel"
 	DatasourceTypePowerpoint   DatasourceType = "powerpoint"
 	DatasourceTypePlainText    DatasourceType = "plain_text"
+	DatasourceTypeEmail        DatasourceType = "email"
 )
 
 func (e *DatasourceType) Scan(src interface{}) error {
@@ -148,6 +150,54 @@ func (ns NullDatasourceType) Value() (driver.Value, error) {
 	return string(ns.DatasourceType), nil
 }
 
+type FactType string
+
+const (
+	FactTypeOrganization  FactType = "organization"
+	FactTypePerson        FactType = "person"
+	FactTypeProduct       FactType = "product"
+	FactTypeOpenGraph     FactType = "open_graph"
+	FactTypeEmail         FactType = "email"
+	FactTypePhone         FactType = "phone"
+	FactTypeAddress       FactType = "address"
+	FactTypeSocialProfile FactType = "social_profile"
+)
+
+func (e *FactType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = FactType(s)
+	case string:
+		*e = FactType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for FactType: %T", src)
+	}
+	return nil
+}
+
+type NullFactType struct {
+	FactType FactType `json:"fact_type"`
+	Valid    bool     `json:"valid"` // Valid is true if FactType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullFactType) Scan(value interface{}) error {
+	if value == nil {
+		ns.FactType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.FactType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullFactType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.FactType), nil
+}
+
 type InputType string
 
 const (
@@ -235,6 +285,49 @@ func (ns NullTaskStatus) Value() (driver.Value, error) {
 	return string(ns.TaskStatus), nil
 }
 
+type WorkspaceRole string
+
+const (
+	WorkspaceRoleOwner  WorkspaceRole = "owner"
+	WorkspaceRoleEditor WorkspaceRole = "editor"
+	WorkspaceRoleViewer WorkspaceRole = "viewer"
+)
+
+func (e *WorkspaceRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = WorkspaceRole(s)
+	case string:
+		*e = WorkspaceRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for WorkspaceRole: %T", src)
+	}
+	return nil
+}
+
+type NullWorkspaceRole struct {
+	WorkspaceRole WorkspaceRole `json:"workspace_role"`
+	Valid         bool          `json:"valid"` // Valid is true if WorkspaceRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullWorkspaceRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.WorkspaceRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.WorkspaceRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullWorkspaceRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.WorkspaceRole), nil
+}
+
 type Analysis struct {
 	AnalysisID     int32        `json:"analysis_id"`
 	SalesProcessID int32        `json:"sales_process_id"`
@@ -265,6 +358,19 @@ type AnalysisInput struct {
 	CreatedAt    sql.NullTime   `json:"created_at"`
 }
 
+type ApiToken struct {
+	TokenID     int32        `json:"token_id"`
+	CognitoSub  string       `json:"cognito_sub"`
+	Name        string       `json:"name"`
+	TokenHash   string       `json:"token_hash"`
+	TokenPrefix string       `json:"token_prefix"`
+	Scopes      []string     `json:"scopes"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	LastUsedAt  sql.NullTime `json:"last_used_at"`
+	RevokedAt   sql.NullTime `json:"revoked_at"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+}
+
 type BehavioralInsight struct {
 	ID                           uuid.UUID      `json:"id"`
 	BriefID                      uuid.NullUUID  `json:"brief_id"`
@@ -287,6 +393,12 @@ type BehavioralInsight struct {
 	UpdatedAt                    sql.NullTime   `json:"updated_at"`
 }
 
+type Blob struct {
+	Hash      string       `json:"hash"`
+	Size      int64        `json:"size"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
 type Brief struct {
 	ID            uuid.UUID      `json:"id"`
 	MasterBriefID uuid.NullUUID  `json:"master_brief_id"`
@@ -342,6 +454,7 @@ type Company struct {
 	Description sql.NullString `json:"description"`
 	CreatedAt   sql.NullTime   `json:"created_at"`
 	CognitoSub  sql.NullString `json:"cognito_sub"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 type CompanyDatasource struct {
@@ -466,6 +579,34 @@ type Datasource struct {
 	FileData     []byte         `json:"file_data"`
 	FileName     sql.NullString `json:"file_name"`
 	CreatedAt    sql.NullTime   `json:"created_at"`
+	BlobHash     sql.NullString `json:"blob_hash"`
+}
+
+type DatasourceFact struct {
+	FactID       int32                 `json:"fact_id"`
+	DatasourceID int32                 `json:"datasource_id"`
+	FactType     FactType              `json:"fact_type"`
+	FactKey      string                `json:"fact_key"`
+	Value        string                `json:"value"`
+	Attributes   pqtype.NullRawMessage `json:"attributes"`
+	Source       sql.NullString        `json:"source"`
+	SourceUrl    sql.NullString        `json:"source_url"`
+	CreatedAt    sql.NullTime          `json:"created_at"`
+}
+
+type DatasourcePageSelection struct {
+	DatasourceID int32           `json:"datasource_id"`
+	Selection    json.RawMessage `json:"selection"`
+	CreatedAt    sql.NullTime    `json:"created_at"`
+	UpdatedAt    sql.NullTime    `json:"updated_at"`
+}
+
+type DatasourceSiteTree struct {
+	DatasourceID int32           `json:"datasource_id"`
+	Tree         json.RawMessage `json:"tree"`
+	PageCount    int32           `json:"page_count"`
+	CreatedAt    sql.NullTime    `json:"created_at"`
+	UpdatedAt    sql.NullTime    `json:"updated_at"`
 }
 
 type FinancialProcurement struct {
@@ -509,6 +650,7 @@ type MasterBrief struct {
 	ContactReference string        `json:"contact_reference"`
 	CreatedAt        sql.NullTime  `json:"created_at"`
 	UpdatedAt        sql.NullTime  `json:"updated_at"`
+	WorkspaceID      sql.NullInt32 `json:"workspace_id"`
 }
 
 type Meeting struct {
@@ -539,6 +681,17 @@ type Paragraph struct {
 	CreatedAt    sql.NullTime   `json:"created_at"`
 }
 
+type ParagraphEmailMetadatum struct {
+	ParagraphID int32          `json:"paragraph_id"`
+	MessageID   sql.NullString `json:"message_id"`
+	Subject     sql.NullString `json:"subject"`
+	SenderName  sql.NullString `json:"sender_name"`
+	SenderEmail string         `json:"sender_email"`
+	Recipients  []string       `json:"recipients"`
+	SentAt      sql.NullTime   `json:"sent_at"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+}
+
 type Project struct {
 	ProjectID   int32          `json:"project_id"`
 	ProjectName string         `json:"project_name"`
@@ -546,6 +699,7 @@ type Project struct {
 	CreatedAt   sql.NullTime   `json:"created_at"`
 	UpdatedAt   sql.NullTime   `json:"updated_at"`
 	CognitoSub  sql.NullString `json:"cognito_sub"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 type ProjectDatasource struct {
@@ -593,6 +747,7 @@ type SalesProcess struct {
 	CreatedAt            sql.NullTime   `json:"created_at"`
 	UpdatedAt            sql.NullTime   `json:"updated_at"`
 	CognitoSub           sql.NullString `json:"cognito_sub"`
+	WorkspaceID          sql.NullInt32  `json:"workspace_id"`
 }
 
 type SalesProcessBrief struct {
@@ -684,3 +839,37 @@ type User struct {
 	CreatedAt  sql.NullTime `json:"created_at"`
 	CognitoSub string       `json:"cognito_sub"`
 }
+
+type UserRole struct {
+	CognitoSub string         `json:"cognito_sub"`
+	Role       string         `json:"role"`
+	GrantedBy  sql.NullString `json:"granted_by"`
+	CreatedAt  sql.NullTime   `json:"created_at"`
+}
+
+type Workspace struct {
+	WorkspaceID int32          `json:"workspace_id"`
+	Name        string         `json:"name"`
+	CreatedBy   sql.NullString `json:"created_by"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+}
+
+type WorkspaceInvitation struct {
+	InvitationID int32          `json:"invitation_id"`
+	WorkspaceID  int32          `json:"workspace_id"`
+	Email        string         `json:"email"`
+	Role         WorkspaceRole  `json:"role"`
+	TokenHash    string         `json:"token_hash"`
+	InvitedBy    sql.NullString `json:"invited_by"`
+	CreatedAt    sql.NullTime   `json:"created_at"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	AcceptedBy   sql.NullString `json:"accepted_by"`
+	AcceptedAt   sql.NullTime   `json:"accepted_at"`
+}
+
+type WorkspaceMember struct {
+	WorkspaceID int32         `json:"workspace_id"`
+	CognitoSub  string        `json:"cognito_sub"`
+	Role        WorkspaceRole `json:"role"`
+	CreatedAt   sql.NullTime  `json:"created_at"`
+}
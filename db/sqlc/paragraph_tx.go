@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"fmt"
+)
+
+// ReplaceParagraphsTxParams contains the input parameters of the replace paragraphs transaction
+type ReplaceParagraphsTxParams struct {
+	DatasourceID  int32
+	Paragraphs    []CreateParagraphParams
+	EmailMetadata []CreateParagraphEmailMetadataParams // Optional; one per paragraph, whose ID is filled in
+}
+
+// ReplaceParagraphsTx deletes the paragraphs of a datasource and creates new ones, so
+// processing a datasource again doesn't duplicate its paragraphs. The old paragraphs are
+// kept if any new one can't be created.
+func (store *Store) ReplaceParagraphsTx(ctx context.Context, arg ReplaceParagraphsTxParams) ([]Paragraph, error) {
+	if arg.EmailMetadata != nil && len(arg.EmailMetadata) != len(arg.Paragraphs) {
+		return nil, fmt.Errorf("got email metadata for %d of %d paragraphs", len(arg.EmailMetadata), len(arg.Paragraphs))
+	}
+	paragraphs := make([]Paragraph, 0, len(arg.Paragraphs))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		if err := q.DeleteParagraphsByDatasource(ctx, arg.DatasourceID); err != nil {
+			return fmt.Errorf("failed to delete previous paragraphs: %w", err)
+		}
+
+		for i, paragraphArg := range arg.Paragraphs {
+			paragraphArg.DatasourceID = arg.DatasourceID
+			paragraph, err := q.CreateParagraph(ctx, paragraphArg)
+			if err != nil {
+				return fmt.Errorf("failed to create paragraph: %w", err)
+			}
+			paragraphs = append(paragraphs, paragraph)
+
+			if arg.EmailMetadata != nil {
+				metadataArg := arg.EmailMetadata[i]
+				metadataArg.ParagraphID = paragraph.ParagraphID
+				if _, err := q.CreateParagraphEmailMetadata(ctx, metadataArg); err != nil {
+					return fmt.Errorf("failed to save email metadata: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paragraphs, nil
+}
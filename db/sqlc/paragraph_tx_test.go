@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceParagraphsTx(t *testing.T) {
+	store := NewStore(testDB)
+	datasource := createRandomDatasource(t)
+
+	arg := ReplaceParagraphsTxParams{DatasourceID: datasource.DatasourceID}
+	for i := 0; i < 3; i++ {
+		arg.Paragraphs = append(arg.Paragraphs, CreateParagraphParams{
+			Title:   sql.NullString{String: randomString(6), Valid: true},
+			Content: randomString(120),
+		})
+	}
+
+	// Processing the datasource again replaces its paragraphs instead of adding copies
+	for run := 0; run < 2; run++ {
+		paragraphs, err := store.ReplaceParagraphsTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Len(t, paragraphs, len(arg.Paragraphs))
+
+		stored, err := testQueries.ListParagraphsByDatasource(context.Background(), ListParagraphsByDatasourceParams{
+			DatasourceID: datasource.DatasourceID,
+			Limit:        10,
+		})
+		require.NoError(t, err)
+		require.Len(t, stored, len(arg.Paragraphs))
+	}
+}
@@ -54,6 +54,16 @@ func (q *Queries) DeleteParagraph(ctx context.Context, paragraphID int32) error
 	return err
 }
 
+const deleteParagraphsByDatasource = `-- name: DeleteParagraphsByDatasource :exec
+DELETE FROM paragraphs
+WHERE datasource_id = $1
+`
+
+func (q *Queries) DeleteParagraphsByDatasource(ctx context.Context, datasourceID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteParagraphsByDatasource, datasourceID)
+	return err
+}
+
 const getParagraphByID = `-- name: GetParagraphByID :one
 SELECT paragraph_id, datasource_id, title, main_idea, content, created_at
 FROM paragraphs
@@ -12,16 +12,17 @@ import (
 
 const createProject = `-- name: CreateProject :one
 INSERT INTO projects (
-    cognito_sub, project_name, main_idea
+    cognito_sub, project_name, main_idea, workspace_id
 )
-VALUES ($1, $2, $3)
-RETURNING project_id, cognito_sub, project_name, main_idea, created_at, updated_at
+VALUES ($1, $2, $3, $4)
+RETURNING project_id, cognito_sub, project_name, main_idea, created_at, updated_at, workspace_id
 `
 
 type CreateProjectParams struct {
 	CognitoSub  sql.NullString `json:"cognito_sub"`
 	ProjectName string         `json:"project_name"`
 	MainIdea    sql.NullString `json:"main_idea"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 type CreateProjectRow struct {
@@ -31,10 +32,16 @@ type CreateProjectRow struct {
 	MainIdea    sql.NullString `json:"main_idea"`
 	CreatedAt   sql.NullTime   `json:"created_at"`
 	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (CreateProjectRow, error) {
-	row := q.db.QueryRowContext(ctx, createProject, arg.CognitoSub, arg.ProjectName, arg.MainIdea)
+	row := q.db.QueryRowContext(ctx, createProject,
+		arg.CognitoSub,
+		arg.ProjectName,
+		arg.MainIdea,
+		arg.WorkspaceID,
+	)
 	var i CreateProjectRow
 	err := row.Scan(
 		&i.ProjectID,
@@ -43,6 +50,7 @@ func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (C
 		&i.MainIdea,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
@@ -58,7 +66,7 @@ func (q *Queries) DeleteProject(ctx context.Context, projectID int32) error {
 }
 
 const getProjectByID = `-- name: GetProjectByID :one
-SELECT project_id, cognito_sub, project_name, main_idea, created_at, updated_at
+SELECT project_id, cognito_sub, project_name, main_idea, created_at, updated_at, workspace_id
 FROM projects
 WHERE project_id = $1
 `
@@ -70,6 +78,7 @@ type GetProjectByIDRow struct {
 	MainIdea    sql.NullString `json:"main_idea"`
 	CreatedAt   sql.NullTime   `json:"created_at"`
 	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
 }
 
 func (q *Queries) GetProjectByID(ctx context.Context, projectID int32) (GetProjectByIDRow, error) {
@@ -82,6 +91,7 @@ func (q *Queries) GetProjectByID(ctx context.Context, projectID int32) (GetProje
 		&i.MainIdea,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
@@ -139,6 +149,70 @@ func (q *Queries) ListProjectsByCognitoSub(ctx context.Context, arg ListProjects
 	return items, nil
 }
 
+const listProjectsByMember = `-- name: ListProjectsByMember :many
+SELECT p.project_id, p.cognito_sub, p.project_name, p.main_idea, p.created_at, p.updated_at, p.workspace_id
+FROM projects p
+JOIN workspace_members wm ON wm.workspace_id = p.workspace_id
+WHERE wm.cognito_sub = $1
+  AND ($2::int IS NULL OR p.workspace_id = $2)
+ORDER BY p.created_at DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListProjectsByMemberParams struct {
+	CognitoSub  string        `json:"cognito_sub"`
+	WorkspaceID sql.NullInt32 `json:"workspace_id"`
+	RowLimit    int32         `json:"row_limit"`
+	RowOffset   int32         `json:"row_offset"`
+}
+
+type ListProjectsByMemberRow struct {
+	ProjectID   int32          `json:"project_id"`
+	CognitoSub  sql.NullString `json:"cognito_sub"`
+	ProjectName string         `json:"project_name"`
+	MainIdea    sql.NullString `json:"main_idea"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	WorkspaceID sql.NullInt32  `json:"workspace_id"`
+}
+
+// Projects in the workspaces a user belongs to, optionally in one workspace only
+func (q *Queries) ListProjectsByMember(ctx context.Context, arg ListProjectsByMemberParams) ([]ListProjectsByMemberRow, error) {
+	rows, err := q.db.QueryContext(ctx, listProjectsByMember,
+		arg.CognitoSub,
+		arg.WorkspaceID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProjectsByMemberRow
+	for rows.Next() {
+		var i ListProjectsByMemberRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.CognitoSub,
+			&i.ProjectName,
+			&i.MainIdea,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const searchProjectsByName = `-- name: SearchProjectsByName :many
 SELECT project_id, cognito_sub, project_name, main_idea, created_at, updated_at
 FROM projects
@@ -12,10 +12,10 @@ import (
 
 const createSalesProcess = `-- name: CreateSalesProcess :one
 INSERT INTO sales_processes (
-    cognito_sub, contact_id, overall_matching_score, status
+    cognito_sub, contact_id, overall_matching_score, status, workspace_id
 )
-VALUES ($1, $2, $3, $4)
-RETURNING sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at
+VALUES ($1, $2, $3, $4, $5)
+RETURNING sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at, workspace_id
 `
 
 type CreateSalesProcessParams struct {
@@ -23,6 +23,7 @@ type CreateSalesProcessParams struct {
 	ContactID            int32          `json:"contact_id"`
 	OverallMatchingScore sql.NullString `json:"overall_matching_score"`
 	Status               sql.NullString `json:"status"`
+	WorkspaceID          sql.NullInt32  `json:"workspace_id"`
 }
 
 type CreateSalesProcessRow struct {
@@ -33,6 +34,7 @@ type CreateSalesProcessRow struct {
 	Status               sql.NullString `json:"status"`
 	CreatedAt            sql.NullTime   `json:"created_at"`
 	UpdatedAt            sql.NullTime   `json:"updated_at"`
+	WorkspaceID          sql.NullInt32  `json:"workspace_id"`
 }
 
 func (q *Queries) CreateSalesProcess(ctx context.Context, arg CreateSalesProcessParams) (CreateSalesProcessRow, error) {
@@ -41,6 +43,7 @@ func (q *Queries) CreateSalesProcess(ctx context.Context, arg CreateSalesProcess
 		arg.ContactID,
 		arg.OverallMatchingScore,
 		arg.Status,
+		arg.WorkspaceID,
 	)
 	var i CreateSalesProcessRow
 	err := row.Scan(
@@ -51,6 +54,7 @@ func (q *Queries) CreateSalesProcess(ctx context.Context, arg CreateSalesProcess
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
@@ -66,7 +70,7 @@ func (q *Queries) DeleteSalesProcess(ctx context.Context, salesProcessID int32)
 }
 
 const getSalesProcessByID = `-- name: GetSalesProcessByID :one
-SELECT sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at
+SELECT sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at, workspace_id
 FROM sales_processes
 WHERE sales_process_id = $1
 `
@@ -79,6 +83,7 @@ type GetSalesProcessByIDRow struct {
 	Status               sql.NullString `json:"status"`
 	CreatedAt            sql.NullTime   `json:"created_at"`
 	UpdatedAt            sql.NullTime   `json:"updated_at"`
+	WorkspaceID          sql.NullInt32  `json:"workspace_id"`
 }
 
 func (q *Queries) GetSalesProcessByID(ctx context.Context, salesProcessID int32) (GetSalesProcessByIDRow, error) {
@@ -92,6 +97,7 @@ func (q *Queries) GetSalesProcessByID(ctx context.Context, salesProcessID int32)
 		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WorkspaceID,
 	)
 	return i, err
 }
@@ -206,6 +212,65 @@ func (q *Queries) ListSalesProcessesByContact(ctx context.Context, arg ListSales
 	return items, nil
 }
 
+const listSalesProcessesByMember = `-- name: ListSalesProcessesByMember :many
+SELECT sp.sales_process_id, sp.cognito_sub, sp.contact_id, sp.overall_matching_score, sp.status, sp.created_at, sp.updated_at, sp.workspace_id
+FROM sales_processes sp
+JOIN workspace_members wm ON wm.workspace_id = sp.workspace_id
+WHERE wm.cognito_sub = $1
+ORDER BY sp.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListSalesProcessesByMemberParams struct {
+	CognitoSub string `json:"cognito_sub"`
+	Limit      int32  `json:"limit"`
+	Offset     int32  `json:"offset"`
+}
+
+type ListSalesProcessesByMemberRow struct {
+	SalesProcessID       int32          `json:"sales_process_id"`
+	CognitoSub           sql.NullString `json:"cognito_sub"`
+	ContactID            int32          `json:"contact_id"`
+	OverallMatchingScore sql.NullString `json:"overall_matching_score"`
+	Status               sql.NullString `json:"status"`
+	CreatedAt            sql.NullTime   `json:"created_at"`
+	UpdatedAt            sql.NullTime   `json:"updated_at"`
+	WorkspaceID          sql.NullInt32  `json:"workspace_id"`
+}
+
+// Sales processes in the workspaces a user belongs to
+func (q *Queries) ListSalesProcessesByMember(ctx context.Context, arg ListSalesProcessesByMemberParams) ([]ListSalesProcessesByMemberRow, error) {
+	rows, err := q.db.QueryContext(ctx, listSalesProcessesByMember, arg.CognitoSub, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSalesProcessesByMemberRow
+	for rows.Next() {
+		var i ListSalesProcessesByMemberRow
+		if err := rows.Scan(
+			&i.SalesProcessID,
+			&i.CognitoSub,
+			&i.ContactID,
+			&i.OverallMatchingScore,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listSalesProcessesByStatus = `-- name: ListSalesProcessesByStatus :many
 SELECT sales_process_id, cognito_sub, contact_id, overall_matching_score, status, created_at, updated_at
 FROM sales_processes
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+)
+
+// Ping checks that the database can be reached
+func (store *Store) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// SchemaVersion returns the version golang-migrate recorded in schema_migrations and whether
+// the last migration stopped halfway
+func (store *Store) SchemaVersion(ctx context.Context) (int64, bool, error) {
+	var version int64
+	var dirty bool
+	err := store.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	return version, dirty, err
+}
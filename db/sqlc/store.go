@@ -24,7 +24,7 @@ func NewStore(db *sql.DB) *Store {
 
 		db: db,
 
-		Queries: New(db),
+		Queries: New(instrumentedDB{db}),
 	}
 
 }
@@ -41,7 +41,7 @@ func (store *Store) execTx(ctx context.Context, fn func(*Queries) error) error {
 
 	}
 
-	q := New(tx)
+	q := New(instrumentedDB{tx})
 
 	err = fn(q)
 
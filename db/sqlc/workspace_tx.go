@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrInvitationUsed is returned when a workspace invitation has already been accepted
+var ErrInvitationUsed = errors.New("invitation has already been used")
+
+// CreateWorkspaceTxParams contains the input parameters of the create workspace transaction
+type CreateWorkspaceTxParams struct {
+	Name       string
+	CognitoSub string // Becomes the first owner
+}
+
+// CreateWorkspaceTx creates a workspace and makes the creating user its owner
+func (store *Store) CreateWorkspaceTx(ctx context.Context, arg CreateWorkspaceTxParams) (Workspace, error) {
+	var workspace Workspace
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		workspace, err = q.CreateWorkspace(ctx, CreateWorkspaceParams{
+			Name:      arg.Name,
+			CreatedBy: sql.NullString{String: arg.CognitoSub, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create workspace: %w", err)
+		}
+
+		_, err = q.AddWorkspaceMember(ctx, AddWorkspaceMemberParams{
+			WorkspaceID: workspace.WorkspaceID,
+			CognitoSub:  arg.CognitoSub,
+			Role:        WorkspaceRoleOwner,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add workspace owner: %w", err)
+		}
+		return nil
+	})
+
+	return workspace, err
+}
+
+// AcceptWorkspaceInvitationTx marks an invitation as accepted and adds the accepting user to
+// its workspace. A user who is already a member keeps their role if it's higher than the
+// invited one.
+func (store *Store) AcceptWorkspaceInvitationTx(ctx context.Context, invitationID int32, cognitoSub string) (WorkspaceMember, error) {
+	var member WorkspaceMember
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		invitation, err := q.AcceptWorkspaceInvitation(ctx, AcceptWorkspaceInvitationParams{
+			InvitationID: invitationID,
+			AcceptedBy:   sql.NullString{String: cognitoSub, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvitationUsed
+		}
+		if err != nil {
+			return fmt.Errorf("failed to accept invitation: %w", err)
+		}
+
+		existing, err := q.GetWorkspaceMember(ctx, GetWorkspaceMemberParams{
+			WorkspaceID: invitation.WorkspaceID,
+			CognitoSub:  cognitoSub,
+		})
+		switch {
+		case err == nil && !outranks(invitation.Role, existing.Role):
+			member = existing
+			return nil
+		case err != nil && !errors.Is(err, sql.ErrNoRows):
+			return fmt.Errorf("failed to check workspace membership: %w", err)
+		}
+
+		member, err = q.AddWorkspaceMember(ctx, AddWorkspaceMemberParams{
+			WorkspaceID: invitation.WorkspaceID,
+			CognitoSub:  cognitoSub,
+			Role:        invitation.Role,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add workspace member: %w", err)
+		}
+		return nil
+	})
+
+	return member, err
+}
+
+// outranks reports whether role a grants more than role b
+func outranks(a, b WorkspaceRole) bool {
+	order := map[WorkspaceRole]int{WorkspaceRoleViewer: 1, WorkspaceRoleEditor: 2, WorkspaceRoleOwner: 3}
+	return order[a] > order[b]
+}
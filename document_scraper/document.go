@@ -0,0 +1,33 @@
+package docscraper
+
+// documentBlock is a top-level piece of a document body
+type documentBlock struct {
+	Paragraph *documentParagraph
+	Table     [][]string // Rows of cell text
+}
+
+// documentParagraph is a body paragraph with the details needed to structure it
+type documentParagraph struct {
+	Text         string
+	HeadingLevel int      // 0 for normal paragraphs
+	ListLevel    int      // Nesting level of a list item, -1 when not in a list
+	FootnoteIDs  []string // Footnotes referenced from this paragraph
+	EndnoteIDs   []string // Endnotes referenced from this paragraph
+	CommentIDs   []string // Comments anchored in this paragraph
+}
+
+// documentNote is a footnote, endnote or reviewer comment
+type documentNote struct {
+	ID     string
+	Author string
+	Text   string
+}
+
+// parsedDocument holds the parts of a document the scraper extracts, whatever its file format
+type parsedDocument struct {
+	Blocks         []documentBlock
+	Footnotes      map[string]documentNote
+	Endnotes       map[string]documentNote
+	Comments       map[string]documentNote
+	CommentAnchors map[string]string // Comment ID to the text it was placed on
+}
@@ -3,17 +3,38 @@
 package docscraper
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"log/slog"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
-	"github.com/unidoc/unioffice/document"
+	"github.com/mbaxamb3/nusli/progress"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
+// ItemKind describes what part of the document a content item came from
+type ItemKind string
+
+const (
+	ItemParagraph ItemKind = "paragraph" // Body text
+	ItemList      ItemKind = "list"      // A paragraph followed by its list items, or a list on its own
+	ItemTable     ItemKind = "table"     // Table content, one line per row
+	ItemFootnote  ItemKind = "footnote"  // Footnote or endnote text
+	ItemComment   ItemKind = "comment"   // Reviewer comment
+)
+
+// minParagraphWords is the shortest plain paragraph worth keeping; lists, tables and notes are always kept
+const minParagraphWords = 10
+
 // ContentItem represents a piece of content extracted from the document
 type ContentItem struct {
 	Heading      string   // Section heading
@@ -21,15 +42,24 @@ type ContentItem struct {
 	HeadingLevel int      // Level of the parent heading (1-based)
 	Title        string   // Cleaned up title for storage
 	Paragraph    string   // The actual content - CHANGED from Content to Paragraph
+	Kind         ItemKind // What the content is, e.g. a table or a comment
+	Annotation   string   // Context for notes and comments, e.g. "Comment by Jane Doe"
 	Hash         string   // Unique hash to identify content
 }
 
-// DocumentScraper handles extraction from Word documents
+// DocumentScraper handles extraction from Word (.docx and .doc), OpenDocument and RTF documents
 type DocumentScraper struct {
-	FilePath     string
-	ContentItems []ContentItem
-	seenContent  map[string]bool // Track already seen content by hash
-	mu           sync.Mutex
+	FilePath          string
+	Format            Format // Detected when the document is opened
+	ContentItems      []ContentItem
+	ItemsBeforeDedup  int             // Content items checked by the final de-duplication
+	DuplicatesRemoved int             // Content items it removed as duplicates
+	seenContent       map[string]bool // Track already seen content by hash
+	mu                sync.Mutex
+	Progress          chan<- progress.Event // Optional; receives progress events without blocking the scraper
+	Logger            *slog.Logger          // Optional; the default logger is used when nil
+	Context           context.Context       // Optional; parents the trace span of the parse
+	Name              string                // Optional; names the file in progress events, which never carry its path
 }
 
 // NewDocumentScraper creates a new document scraper instance
@@ -46,111 +76,241 @@ func NewDocumentScraper(filePath string) (*DocumentScraper, error) {
 	}, nil
 }
 
+// logger returns the logger to log to
+func (ds *DocumentScraper) logger() *slog.Logger {
+	if ds.Logger != nil {
+		return ds.Logger
+	}
+	return slog.Default()
+}
+
 // Run executes the complete document scraping process
 func (ds *DocumentScraper) Run() error {
-	// Open the document
-	doc, err := document.Open(ds.FilePath)
+	ctx := ds.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, span := otel.Tracer("github.com/mbaxamb3/nusli/document_scraper").Start(ctx, "parse document")
+	defer span.End()
+
+	// Open the document in whatever format it really is
+	doc, format, err := openDocument(ds.FilePath)
 	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		progress.Emit(ds.Progress, progress.Event{Type: progress.EventError, URL: ds.Name, Message: progress.ErrorMessage(err)})
 		return fmt.Errorf("failed to open document: %w", err)
 	}
+	ds.Format = format
+	span.SetAttributes(attribute.String("document.format", string(format)))
+	progress.Emit(ds.Progress, progress.Event{Type: progress.EventPageFetched, URL: ds.Name, Message: string(format)})
 
 	// Process document content
-	err = ds.extractStructuredContent(doc)
-	if err != nil {
-		return fmt.Errorf("error extracting content: %w", err)
-	}
+	ds.extractStructuredContent(doc)
 
 	// Apply additional deduplication
 	ds.removeDuplicateContent()
+	span.SetAttributes(attribute.Int("document.content_items", len(ds.ContentItems)))
+	progress.Emit(ds.Progress, progress.Event{Type: progress.EventItemsExtracted, URL: ds.Name, Count: len(ds.ContentItems)})
 
 	return nil
 }
 
-// extractStructuredContent processes the document to extract structured content
-func (ds *DocumentScraper) extractStructuredContent(doc *document.Document) error {
-	// Current heading context for associating content
-	var currentHeadingPath []string
-	currentHeadingLevel := 0
+// headingContext tracks the headings above the current position in the document
+type headingContext struct {
+	path  []string
+	level int
+}
+
+// enter updates the context for a new heading
+func (hc *headingContext) enter(text string, level int) {
+	// If it's at the same or higher level than current, pop back to appropriate level
+	if level <= len(hc.path) {
+		hc.path = hc.path[:level-1]
+	}
+	hc.path = append(hc.path, text)
+	hc.level = level
+}
+
+// newItem creates a content item placed under the current heading
+func (hc *headingContext) newItem(kind ItemKind, content string) ContentItem {
+	headingText := "Untitled Section"
+	if len(hc.path) > 0 {
+		headingText = hc.path[len(hc.path)-1]
+	}
+
+	// Create clean title (for database storage)
+	title := cleanText(headingText)
+	if title == "" {
+		title = "Section"
+	}
+
+	return ContentItem{
+		Heading:      headingText,
+		HeadingPath:  append([]string{}, hc.path...),
+		HeadingLevel: hc.level,
+		Title:        title,
+		Paragraph:    content,
+		Kind:         kind,
+	}
+}
+
+// extractStructuredContent turns the document body, notes and comments into content items
+func (ds *DocumentScraper) extractStructuredContent(doc *parsedDocument) {
+	var headings headingContext
+	var pending *ContentItem // Last paragraph, kept open so following list items can join it
 
-	// Process all paragraphs
-	for _, para := range doc.Paragraphs() {
-		// Get the text content of the paragraph
-		paraText := ""
-		for _, run := range para.Runs() {
-			paraText += run.Text()
+	// Notes and comments are placed under the heading where they are referenced
+	noteHeadings := make(map[string]headingContext)
+
+	flush := func() {
+		if pending == nil {
+			return
+		}
+		if pending.Kind != ItemParagraph || countWords(pending.Paragraph) >= minParagraphWords {
+			pending.Hash = generateContentHash(pending.Heading, pending.Paragraph)
+			ds.addContentItem(*pending)
 		}
+		pending = nil
+	}
 
-		// Skip empty paragraphs
-		if strings.TrimSpace(paraText) == "" {
+	for _, block := range doc.Blocks {
+		if block.Table != nil {
+			flush()
+			if text := tableText(block.Table); text != "" {
+				item := headings.newItem(ItemTable, text)
+				item.Hash = generateContentHash(item.Heading, item.Paragraph)
+				ds.addContentItem(item)
+			}
 			continue
 		}
 
-		// Check for heading style
-		isHeading := false
-		headingLevel := 0
-
-		// Determine if this is a heading and what level
-		if para.Properties().Style != nil {
-			styleFunc := para.Properties().Style
-			style := styleFunc()
-			if strings.HasPrefix(style, "Heading") && len(style) > 7 {
-				// Try to extract heading level
-				levelStr := style[7:]
-				fmt.Sscanf(levelStr, "%d", &headingLevel)
-				if headingLevel > 0 {
-					isHeading = true
-				}
-			}
+		paragraph := block.Paragraph
+		for _, id := range paragraph.FootnoteIDs {
+			noteHeadings["footnote:"+id] = headings.snapshot()
+		}
+		for _, id := range paragraph.EndnoteIDs {
+			noteHeadings["endnote:"+id] = headings.snapshot()
+		}
+		for _, id := range paragraph.CommentIDs {
+			noteHeadings["comment:"+id] = headings.snapshot()
 		}
 
-		// If it's a heading, update the heading context
-		if isHeading {
-			headingText := strings.TrimSpace(paraText)
+		content := cleanText(paragraph.Text)
+		if content == "" {
+			continue
+		}
 
-			// Update heading path based on level
-			if headingLevel <= len(currentHeadingPath) {
-				// If it's at the same or higher level than current, pop back to appropriate level
-				currentHeadingPath = currentHeadingPath[:headingLevel-1]
+		switch {
+		case paragraph.HeadingLevel > 0:
+			// If it's a heading, update the heading context
+			flush()
+			headings.enter(content, paragraph.HeadingLevel)
+
+		case paragraph.ListLevel >= 0:
+			// Group list items under the paragraph that introduces them
+			line := strings.Repeat("  ", paragraph.ListLevel) + "- " + content
+			if pending == nil {
+				item := headings.newItem(ItemList, line)
+				pending = &item
+			} else {
+				pending.Kind = ItemList
+				pending.Paragraph += "\n" + line
 			}
-			// Now add the new heading
-			currentHeadingPath = append(currentHeadingPath, headingText)
-			currentHeadingLevel = headingLevel
+
+		default:
+			flush()
+			item := headings.newItem(ItemParagraph, content)
+			pending = &item
+		}
+	}
+	flush()
+
+	ds.addNotes(doc.Footnotes, "footnote", "Footnote", noteHeadings, nil)
+	ds.addNotes(doc.Endnotes, "endnote", "Endnote", noteHeadings, nil)
+	ds.addNotes(doc.Comments, "comment", "Comment", noteHeadings, doc.CommentAnchors)
+}
+
+// snapshot copies the heading context so later headings don't change it
+func (hc headingContext) snapshot() headingContext {
+	return headingContext{path: append([]string{}, hc.path...), level: hc.level}
+}
+
+// addNotes adds footnotes, endnotes or comments as annotated items, in ID order
+func (ds *DocumentScraper) addNotes(notes map[string]documentNote, refPrefix, label string, noteHeadings map[string]headingContext, anchors map[string]string) {
+	ids := make([]string, 0, len(notes))
+	for id := range notes {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return ids[i] < ids[j]
+	})
+
+	for _, id := range ids {
+		note := notes[id]
+		if note.Text == "" {
 			continue
 		}
 
-		// Handle normal paragraph content
-		content := strings.TrimSpace(paraText)
-		if countWords(content) >= 10 {
-			// Create content item
-			headingText := "Untitled Section"
-			if len(currentHeadingPath) > 0 {
-				headingText = currentHeadingPath[len(currentHeadingPath)-1]
+		headings := noteHeadings[refPrefix+":"+id]
+		kind := ItemFootnote
+		annotation := fmt.Sprintf("%s %s", label, id)
+		if refPrefix == "comment" {
+			kind = ItemComment
+			annotation = label
+			if note.Author != "" {
+				annotation += " by " + note.Author
 			}
-
-			// Create clean title (for database storage)
-			title := cleanText(headingText)
-			if title == "" {
-				title = "Section"
+			if anchor := cleanText(anchors[id]); anchor != "" {
+				annotation += fmt.Sprintf(" on %q", anchor)
 			}
+		}
 
-			// Create content item
-			item := ContentItem{
-				Heading:      headingText,
-				HeadingPath:  append([]string{}, currentHeadingPath...),
-				HeadingLevel: currentHeadingLevel,
-				Title:        title,
-				Paragraph:    cleanText(content),
-			}
+		item := headings.newItem(kind, note.Text)
+		item.Annotation = annotation
+		item.Hash = generateContentHash(item.Heading, annotation+"|"+item.Paragraph)
+		ds.addContentItem(item)
+	}
+}
 
-			// Generate hash
-			item.Hash = generateContentHash(item.Heading, item.Paragraph)
+// tableText renders a table one row per line. When the first row looks like a header,
+// every following cell is labelled with its column header.
+func tableText(rows [][]string) string {
+	if len(rows) == 0 {
+		return ""
+	}
 
-			// Add to collection
-			ds.addContentItem(item)
+	header := rows[0]
+	hasHeader := len(rows) > 1
+	for _, cell := range header {
+		if cell == "" {
+			hasHeader = false
+			break
 		}
 	}
 
-	return nil
+	var lines []string
+	for i, row := range rows {
+		var cells []string
+		for j, cell := range row {
+			if cell == "" {
+				continue
+			}
+			if hasHeader && i > 0 && j < len(header) {
+				cell = header[j] + ": " + cell
+			}
+			cells = append(cells, cell)
+		}
+		if len(cells) > 0 {
+			lines = append(lines, strings.Join(cells, " | "))
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 // addContentItem adds a content item to the document scraper
@@ -182,9 +342,12 @@ func (ds *DocumentScraper) removeDuplicateContent() {
 		}
 	}
 
+	ds.ItemsBeforeDedup += len(ds.ContentItems)
+	ds.DuplicatesRemoved += len(ds.ContentItems) - len(uniqueItems)
+	ds.logger().Debug("Removed duplicate content", "file", ds.FilePath, "duplicates", len(ds.ContentItems)-len(uniqueItems), "content_items", len(uniqueItems))
+
 	// Replace with deduplicated list
 	ds.ContentItems = uniqueItems
-	fmt.Printf("Removed %d duplicate items\n", len(ds.seenContent)-len(uniqueItems))
 }
 
 // generateContentHash creates a unique hash for content
@@ -0,0 +1,424 @@
+package docscraper
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Namespaces of the WordprocessingML elements the reader understands
+const (
+	wordNamespace   = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	markupNamespace = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+)
+
+// maxDocxPartSize caps how much of a single XML part is read, to guard against zip bombs
+const maxDocxPartSize = 64 << 20
+
+// headingStylePattern matches heading style IDs and names such as "Heading2" or "heading 2"
+var headingStylePattern = regexp.MustCompile(`(?i)^heading\s*(\d)$`)
+
+// xmlNode is a generic element tree used to walk OOXML and OpenDocument parts.
+// Character data is also kept as unnamed children so mixed content stays in order.
+type xmlNode struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Children []*xmlNode
+	Text     string
+}
+
+// attr returns the value of the attribute with the given local name
+func (n *xmlNode) attr(local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// is reports whether the node is the WordprocessingML element with the given local name
+func (n *xmlNode) is(local string) bool {
+	return n.isIn(wordNamespace, local)
+}
+
+// isIn reports whether the node is the element with the given namespace and local name
+func (n *xmlNode) isIn(space, local string) bool {
+	return n.Name.Space == space && n.Name.Local == local
+}
+
+// isText reports whether the node holds character data rather than an element
+func (n *xmlNode) isText() bool {
+	return n.Name.Local == ""
+}
+
+// child returns the first WordprocessingML child with the given local name
+func (n *xmlNode) child(local string) *xmlNode {
+	for _, c := range n.Children {
+		if c.is(local) {
+			return c
+		}
+	}
+	return nil
+}
+
+// parseXMLTree reads an XML document into a node tree
+func parseXMLTree(r io.Reader) (*xmlNode, error) {
+	dec := xml.NewDecoder(r)
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: t.Name, Attrs: t.Attr}
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent := stack[len(stack)-1]
+			parent.Text += string(t)
+			if len(stack) > 1 {
+				parent.Children = append(parent.Children, &xmlNode{Text: string(t)})
+			}
+		}
+	}
+
+	return root, nil
+}
+
+// openDocx reads a .docx file into its body blocks, notes and comments
+func openDocx(path string) (*parsedDocument, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid .docx file: %w", err)
+	}
+	defer archive.Close()
+
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	body, err := readDocxPart(parts, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+	if body == nil {
+		return nil, fmt.Errorf("not a valid .docx file: word/document.xml is missing")
+	}
+
+	styles, err := readDocxPart(parts, "word/styles.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &docxReader{
+		headingStyles: headingStyleLevels(styles),
+		doc: &parsedDocument{
+			CommentAnchors: make(map[string]string),
+		},
+		openComments: make(map[string]bool),
+	}
+	if bodyNode := findDescendant(body, "body"); bodyNode != nil {
+		reader.readBlocks(bodyNode)
+	}
+
+	for part, target := range map[string]*map[string]documentNote{
+		"word/footnotes.xml": &reader.doc.Footnotes,
+		"word/endnotes.xml":  &reader.doc.Endnotes,
+		"word/comments.xml":  &reader.doc.Comments,
+	} {
+		node, err := readDocxPart(parts, part)
+		if err != nil {
+			return nil, err
+		}
+		*target = readNotes(node)
+	}
+
+	return reader.doc, nil
+}
+
+// readDocxPart parses an XML part of the package, returning nil when the part doesn't exist
+func readDocxPart(parts map[string]*zip.File, name string) (*xmlNode, error) {
+	f, ok := parts[name]
+	if !ok {
+		return nil, nil
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	node, err := parseXMLTree(io.LimitReader(rc, maxDocxPartSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	return node, nil
+}
+
+// headingStyleLevels maps paragraph style IDs to heading levels using styles.xml,
+// so localized heading styles are recognised by their built-in names
+func headingStyleLevels(styles *xmlNode) map[string]int {
+	levels := make(map[string]int)
+	if styles == nil {
+		return levels
+	}
+
+	for _, style := range findDescendant(styles, "styles").childrenNamed("style") {
+		id := style.attr("styleId")
+		if name := style.child("name"); name != nil {
+			if level := headingLevelFromStyle(name.attr("val")); level > 0 {
+				levels[id] = level
+				continue
+			}
+		}
+		if pPr := style.child("pPr"); pPr != nil {
+			if outline := pPr.child("outlineLvl"); outline != nil {
+				if level, err := strconv.Atoi(outline.attr("val")); err == nil && level < 9 {
+					levels[id] = level + 1
+				}
+			}
+		}
+	}
+	return levels
+}
+
+// headingLevelFromStyle returns the heading level encoded in a style ID or name, or 0
+func headingLevelFromStyle(style string) int {
+	if strings.EqualFold(style, "Title") {
+		return 1
+	}
+	match := headingStylePattern.FindStringSubmatch(strings.TrimSpace(style))
+	if match == nil {
+		return 0
+	}
+	level, _ := strconv.Atoi(match[1])
+	return level
+}
+
+// childrenNamed returns all WordprocessingML children with the given local name
+func (n *xmlNode) childrenNamed(local string) []*xmlNode {
+	if n == nil {
+		return nil
+	}
+	var children []*xmlNode
+	for _, c := range n.Children {
+		if c.is(local) {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// findDescendant returns the first WordprocessingML element with the given local name
+func findDescendant(n *xmlNode, local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	if n.is(local) {
+		return n
+	}
+	for _, c := range n.Children {
+		if found := findDescendant(c, local); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// docxReader walks the document body in reading order
+type docxReader struct {
+	headingStyles map[string]int
+	doc           *parsedDocument
+	openComments  map[string]bool // Comments whose anchored range is being read
+}
+
+// readBlocks reads paragraphs and tables from a block container such as the body
+func (r *docxReader) readBlocks(container *xmlNode) {
+	for _, node := range container.Children {
+		switch {
+		case node.is("p"):
+			r.readParagraph(node)
+		case node.is("tbl"):
+			r.doc.Blocks = append(r.doc.Blocks, documentBlock{Table: r.readTable(node)})
+		case node.is("sdt"):
+			// Content controls wrap ordinary blocks
+			if content := node.child("sdtContent"); content != nil {
+				r.readBlocks(content)
+			}
+		}
+	}
+}
+
+// readParagraph adds a paragraph, followed by any text boxes anchored in it
+func (r *docxReader) readParagraph(node *xmlNode) {
+	paragraph := &documentParagraph{ListLevel: -1}
+	var textBoxes []*xmlNode
+
+	if pPr := node.child("pPr"); pPr != nil {
+		if style := pPr.child("pStyle"); style != nil {
+			styleID := style.attr("val")
+			paragraph.HeadingLevel = r.headingStyles[styleID]
+			if paragraph.HeadingLevel == 0 {
+				paragraph.HeadingLevel = headingLevelFromStyle(styleID)
+			}
+		}
+		if numPr := pPr.child("numPr"); numPr != nil {
+			numID := ""
+			if id := numPr.child("numId"); id != nil {
+				numID = id.attr("val")
+			}
+			// numId 0 explicitly removes numbering
+			if numID != "" && numID != "0" {
+				paragraph.ListLevel = 0
+				if ilvl := numPr.child("ilvl"); ilvl != nil {
+					paragraph.ListLevel, _ = strconv.Atoi(ilvl.attr("val"))
+				}
+			}
+		}
+	}
+
+	var sb strings.Builder
+	r.readInline(node, paragraph, &sb, &textBoxes)
+	paragraph.Text = sb.String()
+	r.doc.Blocks = append(r.doc.Blocks, documentBlock{Paragraph: paragraph})
+
+	for _, textBox := range textBoxes {
+		r.readBlocks(textBox)
+	}
+}
+
+// readInline collects the text of runs and the references inside a paragraph
+func (r *docxReader) readInline(node *xmlNode, paragraph *documentParagraph, sb *strings.Builder, textBoxes *[]*xmlNode) {
+	for _, child := range node.Children {
+		// Alternate content repeats the same text for older readers
+		if child.Name.Space == markupNamespace && child.Name.Local == "Fallback" {
+			continue
+		}
+
+		switch {
+		case child.is("pPr"), child.is("rPr"), child.is("del"):
+			continue
+		case child.is("t"):
+			r.writeText(sb, child.Text)
+		case child.is("tab"):
+			r.writeText(sb, "\t")
+		case child.is("br"), child.is("cr"):
+			r.writeText(sb, "\n")
+		case child.is("footnoteReference"):
+			paragraph.FootnoteIDs = append(paragraph.FootnoteIDs, child.attr("id"))
+		case child.is("endnoteReference"):
+			paragraph.EndnoteIDs = append(paragraph.EndnoteIDs, child.attr("id"))
+		case child.is("commentRangeStart"):
+			r.openComments[child.attr("id")] = true
+		case child.is("commentRangeEnd"):
+			delete(r.openComments, child.attr("id"))
+		case child.is("commentReference"):
+			paragraph.CommentIDs = append(paragraph.CommentIDs, child.attr("id"))
+		case child.is("txbxContent"):
+			*textBoxes = append(*textBoxes, child)
+		default:
+			// Runs, hyperlinks, insertions, smart tags and drawing wrappers hold more inline content
+			r.readInline(child, paragraph, sb, textBoxes)
+		}
+	}
+}
+
+// writeText appends text to the paragraph and to every open comment anchor
+func (r *docxReader) writeText(sb *strings.Builder, text string) {
+	sb.WriteString(text)
+	for id := range r.openComments {
+		r.doc.CommentAnchors[id] += text
+	}
+}
+
+// readTable returns the text of each row's cells; nested tables are flattened into their cell
+func (r *docxReader) readTable(node *xmlNode) [][]string {
+	var rows [][]string
+	for _, row := range node.childrenNamed("tr") {
+		var cells []string
+		for _, cell := range row.childrenNamed("tc") {
+			cells = append(cells, cleanText(nodeText(cell)))
+		}
+		rows = append(rows, cells)
+	}
+	return rows
+}
+
+// readNotes reads footnotes, endnotes or comments keyed by ID, skipping separator notes
+func readNotes(part *xmlNode) map[string]documentNote {
+	notes := make(map[string]documentNote)
+	if part == nil || len(part.Children) == 0 {
+		return notes
+	}
+
+	for _, note := range part.Children[0].Children {
+		if note.Name.Space != wordNamespace {
+			continue
+		}
+		if noteType := note.attr("type"); noteType == "separator" || noteType == "continuationSeparator" || noteType == "continuationNotice" {
+			continue
+		}
+
+		var paragraphs []string
+		for _, p := range note.childrenNamed("p") {
+			if text := cleanText(nodeText(p)); text != "" {
+				paragraphs = append(paragraphs, text)
+			}
+		}
+
+		id := note.attr("id")
+		notes[id] = documentNote{
+			ID:     id,
+			Author: note.attr("author"),
+			Text:   strings.Join(paragraphs, "\n"),
+		}
+	}
+	return notes
+}
+
+// nodeText returns all text inside a node, with paragraphs separated by spaces
+func nodeText(n *xmlNode) string {
+	var sb strings.Builder
+	var walk func(*xmlNode)
+	walk = func(node *xmlNode) {
+		if node.Name.Space == markupNamespace && node.Name.Local == "Fallback" {
+			return
+		}
+		switch {
+		case node.is("t"):
+			sb.WriteString(node.Text)
+		case node.is("tab"), node.is("br"), node.is("cr"):
+			sb.WriteString(" ")
+		case node.is("del"):
+			return
+		}
+		for _, c := range node.Children {
+			walk(c)
+		}
+		if node.is("p") {
+			sb.WriteString(" ")
+		}
+	}
+	walk(n)
+	return sb.String()
+}
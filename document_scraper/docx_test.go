@@ -0,0 +1,127 @@
+package docscraper
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testDocumentXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006">
+<w:body>
+	<w:p><w:pPr><w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t>Project Overview</w:t></w:r></w:p>
+	<w:p><w:r><w:t xml:space="preserve">The rollout covers three regional offices and starts </w:t></w:r>
+		<w:commentRangeStart w:id="0"/><w:r><w:t>in the first quarter</w:t></w:r><w:commentRangeEnd w:id="0"/>
+		<w:r><w:commentReference w:id="0"/></w:r>
+		<w:r><w:t xml:space="preserve"> of next year.</w:t></w:r>
+		<w:r><w:footnoteReference w:id="1"/></w:r></w:p>
+	<w:p><w:r><w:t>Key requirements:</w:t></w:r></w:p>
+	<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Single sign-on</w:t></w:r></w:p>
+	<w:p><w:pPr><w:numPr><w:ilvl w:val="1"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Azure AD</w:t></w:r></w:p>
+	<w:p><w:pPr><w:numPr><w:ilvl w:val="0"/><w:numId w:val="1"/></w:numPr></w:pPr><w:r><w:t>Audit logs</w:t></w:r></w:p>
+	<w:p><w:pPr><w:pStyle w:val="Berschrift2"/></w:pPr><w:r><w:t>Budget</w:t></w:r></w:p>
+	<w:tbl>
+		<w:tr><w:tc><w:p><w:r><w:t>Item</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>Cost</w:t></w:r></w:p></w:tc></w:tr>
+		<w:tr><w:tc><w:p><w:r><w:t>Licenses</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>40,000 EUR</w:t></w:r></w:p></w:tc></w:tr>
+		<w:tr><w:tc><w:p><w:r><w:t>Training</w:t></w:r></w:p></w:tc><w:tc><w:p><w:r><w:t>5,000 EUR</w:t></w:r></w:p></w:tc></w:tr>
+	</w:tbl>
+	<w:p><w:r><mc:AlternateContent>
+		<mc:Choice><w:drawing><w:txbxContent><w:p><w:r><w:t>Callout: budget approval is expected from the steering committee by the end of March.</w:t></w:r></w:p></w:txbxContent></w:drawing></mc:Choice>
+		<mc:Fallback><w:pict><w:txbxContent><w:p><w:r><w:t>Callout: budget approval is expected from the steering committee by the end of March.</w:t></w:r></w:p></w:txbxContent></w:pict></mc:Fallback>
+	</mc:AlternateContent></w:r></w:p>
+	<w:p><w:r><w:t>Too short to keep.</w:t></w:r></w:p>
+	<w:sectPr/>
+</w:body>
+</w:document>`
+
+const testStylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+	<w:style w:type="paragraph" w:styleId="Berschrift2"><w:name w:val="heading 2"/></w:style>
+</w:styles>`
+
+const testFootnotesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:footnotes xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+	<w:footnote w:type="separator" w:id="-1"><w:p><w:r><w:separator/></w:r></w:p></w:footnote>
+	<w:footnote w:id="1"><w:p><w:r><w:t>Dates depend on the procurement review.</w:t></w:r></w:p></w:footnote>
+</w:footnotes>`
+
+const testCommentsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:comments xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+	<w:comment w:id="0" w:author="Jane Doe"><w:p><w:r><w:t>Customer said Q2 is more realistic.</w:t></w:r></w:p></w:comment>
+</w:comments>`
+
+// writeTestDocx builds a minimal .docx package from the given parts
+func writeTestDocx(t *testing.T, parts map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.docx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create test document: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to finish test document: %v", err)
+	}
+	return path
+}
+
+func TestDocumentScraperExtractsStructuredContent(t *testing.T) {
+	path := writeTestDocx(t, map[string]string{
+		"word/document.xml":  testDocumentXML,
+		"word/styles.xml":    testStylesXML,
+		"word/footnotes.xml": testFootnotesXML,
+		"word/comments.xml":  testCommentsXML,
+	})
+
+	ds, err := NewDocumentScraper(path)
+	if err != nil {
+		t.Fatalf("Failed to create document scraper: %v", err)
+	}
+	if err := ds.Run(); err != nil {
+		t.Fatalf("Document scraping failed: %v", err)
+	}
+
+	want := []ContentItem{
+		{Title: "Project Overview", Kind: ItemParagraph, Paragraph: "The rollout covers three regional offices and starts in the first quarter of next year."},
+		{Title: "Project Overview", Kind: ItemList, Paragraph: "Key requirements:\n- Single sign-on\n  - Azure AD\n- Audit logs"},
+		{Title: "Budget", Kind: ItemTable, Paragraph: "Item | Cost\nItem: Licenses | Cost: 40,000 EUR\nItem: Training | Cost: 5,000 EUR"},
+		{Title: "Budget", Kind: ItemParagraph, Paragraph: "Callout: budget approval is expected from the steering committee by the end of March."},
+		{Title: "Project Overview", Kind: ItemFootnote, Annotation: "Footnote 1", Paragraph: "Dates depend on the procurement review."},
+		{Title: "Project Overview", Kind: ItemComment, Annotation: `Comment by Jane Doe on "in the first quarter"`, Paragraph: "Customer said Q2 is more realistic."},
+	}
+
+	assertContentItems(t, ds.ContentItems, want)
+
+	if path := ds.ContentItems[2].HeadingPath; len(path) != 2 || path[0] != "Project Overview" || path[1] != "Budget" {
+		t.Errorf("Expected localized heading style to nest under level 1, got path %v", path)
+	}
+}
+
+func TestDocumentScraperRejectsNonDocx(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.docx")
+	if err := os.WriteFile(path, []byte("plain text, not a zip"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	ds, err := NewDocumentScraper(path)
+	if err != nil {
+		t.Fatalf("Failed to create document scraper: %v", err)
+	}
+	if err := ds.Run(); err == nil {
+		t.Error("Expected an error for a file that isn't a .docx package")
+	}
+}
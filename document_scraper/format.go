@@ -0,0 +1,239 @@
+package docscraper
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Format is the real file format of a document, detected from its content
+type Format string
+
+const (
+	FormatDOCX    Format = "docx"    // Office Open XML (Word 2007 and later)
+	FormatODT     Format = "odt"     // OpenDocument text
+	FormatRTF     Format = "rtf"     // Rich Text Format
+	FormatDOC     Format = "doc"     // Legacy Word binary format (OLE compound file)
+	FormatUnknown Format = "unknown" // Anything else
+)
+
+// Magic bytes at the start of the supported formats
+var (
+	zipMagic = []byte("PK\x03\x04")
+	rtfMagic = []byte(`{\rtf`)
+	oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+)
+
+// odtMimeType is the content of the mimetype entry of an OpenDocument text file (or template)
+const odtMimeType = "application/vnd.oasis.opendocument.text"
+
+// conversionTimeout bounds how long LibreOffice may take to convert a legacy document
+const conversionTimeout = 2 * time.Minute
+
+// ErrConverterUnavailable is returned for legacy .doc files when LibreOffice isn't installed
+var ErrConverterUnavailable = errors.New("legacy .doc files are converted with LibreOffice, which is not installed (no soffice or libreoffice on PATH); install it or save the document as .docx")
+
+// DetectFormat inspects the start of a file to find its real format, ignoring the file extension
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FormatUnknown, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(oleMagic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return FormatUnknown, err
+	}
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, oleMagic):
+		return FormatDOC, nil
+	case bytes.HasPrefix(header, rtfMagic):
+		return FormatRTF, nil
+	case bytes.HasPrefix(header, zipMagic):
+		return detectZipFormat(path)
+	}
+	return FormatUnknown, nil
+}
+
+// detectZipFormat tells Word and OpenDocument packages apart by their entries
+func detectZipFormat(path string) (Format, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return FormatUnknown, nil
+	}
+	defer archive.Close()
+
+	return detectPackageFormat(archive.File)
+}
+
+// DetectFormatBytes is DetectFormat for a document already in memory
+func DetectFormatBytes(data []byte) Format {
+	return DetectFormatReader(bytes.NewReader(data), int64(len(data)))
+}
+
+// DetectFormatReader is DetectFormat for a document of the given size read through r
+func DetectFormatReader(r io.ReaderAt, size int64) Format {
+	header := make([]byte, len(oleMagic))
+	n, _ := r.ReadAt(header, 0)
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, oleMagic):
+		return FormatDOC
+	case bytes.HasPrefix(header, rtfMagic):
+		return FormatRTF
+	case bytes.HasPrefix(header, zipMagic):
+		archive, err := zip.NewReader(r, size)
+		if err != nil {
+			return FormatUnknown
+		}
+		format, err := detectPackageFormat(archive.File)
+		if err != nil {
+			return FormatUnknown
+		}
+		return format
+	}
+	return FormatUnknown
+}
+
+// detectPackageFormat looks for the entries that identify a Word or OpenDocument text package
+func detectPackageFormat(files []*zip.File) (Format, error) {
+	for _, f := range files {
+		switch f.Name {
+		case "word/document.xml":
+			return FormatDOCX, nil
+		case "mimetype":
+			rc, err := f.Open()
+			if err != nil {
+				return FormatUnknown, err
+			}
+			mimeType, err := io.ReadAll(io.LimitReader(rc, 256))
+			rc.Close()
+			if err != nil {
+				return FormatUnknown, err
+			}
+			if strings.HasPrefix(strings.TrimSpace(string(mimeType)), odtMimeType) {
+				return FormatODT, nil
+			}
+		}
+	}
+	return FormatUnknown, nil
+}
+
+// openDocument reads a document of any supported format into the common document model
+func openDocument(path string) (*parsedDocument, Format, error) {
+	format, err := DetectFormat(path)
+	if err != nil {
+		return nil, FormatUnknown, err
+	}
+
+	var doc *parsedDocument
+	switch format {
+	case FormatDOCX:
+		doc, err = openDocx(path)
+	case FormatODT:
+		doc, err = openODT(path)
+	case FormatRTF:
+		doc, err = openRTF(path)
+	case FormatDOC:
+		doc, err = openLegacyDoc(path)
+	default:
+		err = fmt.Errorf("unsupported document format: expected .docx, .doc, .odt or .rtf content")
+	}
+	return doc, format, err
+}
+
+// openLegacyDoc converts a legacy .doc file to .docx with LibreOffice and reads the result
+func openLegacyDoc(path string) (*parsedDocument, error) {
+	converted, cleanup, err := convertWithLibreOffice(path)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return openDocx(converted)
+}
+
+// CheckConverter reports whether LibreOffice is available to convert legacy .doc files.
+// It returns ErrConverterUnavailable when it isn't.
+func CheckConverter() error {
+	_, err := findLibreOffice()
+	return err
+}
+
+// findLibreOffice returns the path of the LibreOffice executable, or ErrConverterUnavailable
+func findLibreOffice() (string, error) {
+	for _, name := range []string{"soffice", "libreoffice"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrConverterUnavailable
+}
+
+// convertWithLibreOffice runs a headless LibreOffice conversion of a legacy document to .docx.
+// It returns the converted file and a function that removes it.
+func convertWithLibreOffice(path string) (string, func(), error) {
+	soffice, err := findLibreOffice()
+	if err != nil {
+		return "", nil, err
+	}
+
+	workDir, err := os.MkdirTemp("", "docscraper-convert-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create conversion directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(workDir) }
+
+	// LibreOffice names its output after the input, so copy the input to a known name
+	input := filepath.Join(workDir, "document.doc")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to prepare document for conversion: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conversionTimeout)
+	defer cancel()
+
+	// A private profile lets several conversions run at the same time
+	cmd := exec.CommandContext(ctx, soffice,
+		"-env:UserInstallation=file://"+filepath.ToSlash(filepath.Join(workDir, "profile")),
+		"--headless", "--norestore",
+		"--convert-to", "docx:MS Word 2007 XML",
+		"--outdir", workDir,
+		input,
+	)
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		cleanup()
+		return "", nil, fmt.Errorf("LibreOffice conversion timed out after %s", conversionTimeout)
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("LibreOffice conversion failed: %v, output: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	converted := filepath.Join(workDir, "document.docx")
+	if _, err := os.Stat(converted); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("LibreOffice conversion produced no output: %s", strings.TrimSpace(string(output)))
+	}
+	return converted, cleanup, nil
+}
@@ -0,0 +1,84 @@
+package docscraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// assertContentItems compares extracted items by title, kind, annotation and text
+func assertContentItems(t *testing.T, items []ContentItem, want []ContentItem) {
+	t.Helper()
+
+	if len(items) != len(want) {
+		var got []string
+		for _, item := range items {
+			got = append(got, string(item.Kind)+": "+item.Paragraph)
+		}
+		t.Fatalf("Expected %d items, got %d:\n%s", len(want), len(items), strings.Join(got, "\n"))
+	}
+
+	for i, w := range want {
+		got := items[i]
+		if got.Title != w.Title || got.Kind != w.Kind || got.Annotation != w.Annotation || got.Paragraph != w.Paragraph {
+			t.Errorf("Item %d:\n got: %q %s %q %q\nwant: %q %s %q %q",
+				i, got.Title, got.Kind, got.Annotation, got.Paragraph, w.Title, w.Kind, w.Annotation, w.Paragraph)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want Format
+	}{
+		{"docx", writeTestDocx(t, map[string]string{"word/document.xml": testDocumentXML}), FormatDOCX},
+		{"odt", writeTestDocx(t, map[string]string{"mimetype": odtMimeType, "content.xml": testODTContentXML}), FormatODT},
+		{"other zip", writeTestDocx(t, map[string]string{"readme.txt": "hello"}), FormatUnknown},
+		{"rtf named .docx", write("report.docx", []byte(testRTF)), FormatRTF},
+		{"ole compound file", write("legacy.doc", append(append([]byte{}, oleMagic...), make([]byte, 512)...)), FormatDOC},
+		{"plain text", write("notes.txt", []byte("just text")), FormatUnknown},
+		{"empty", write("empty.doc", nil), FormatUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.path)
+			if err != nil {
+				t.Fatalf("DetectFormat failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLegacyDocWithoutLibreOffice(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "legacy.doc")
+	if err := os.WriteFile(path, append(append([]byte{}, oleMagic...), make([]byte, 512)...), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	ds, err := NewDocumentScraper(path)
+	if err != nil {
+		t.Fatalf("Failed to create document scraper: %v", err)
+	}
+	if err := ds.Run(); !errors.Is(err, ErrConverterUnavailable) {
+		t.Errorf("Expected ErrConverterUnavailable, got %v", err)
+	}
+}
@@ -0,0 +1,347 @@
+package docscraper
+
+import (
+	"archive/zip"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Namespaces of the OpenDocument elements the reader understands
+const (
+	odfOfficeNamespace  = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odfTextNamespace    = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odfTableNamespace   = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odfDrawNamespace    = "urn:oasis:names:tc:opendocument:xmlns:drawing:1.0"
+	odfStyleNamespace   = "urn:oasis:names:tc:opendocument:xmlns:style:1.0"
+	dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+)
+
+// openODT reads an OpenDocument text file into its body blocks, notes and comments
+func openODT(path string) (*parsedDocument, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("not a valid .odt file: %w", err)
+	}
+	defer archive.Close()
+
+	parts := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		parts[f.Name] = f
+	}
+
+	content, err := readDocxPart(parts, "content.xml")
+	if err != nil {
+		return nil, err
+	}
+	if content == nil {
+		return nil, fmt.Errorf("not a valid .odt file: content.xml is missing")
+	}
+
+	styles, err := readDocxPart(parts, "styles.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	reader := &odtReader{
+		headingStyles: odtHeadingStyleLevels(styles, content),
+		doc: &parsedDocument{
+			Footnotes:      make(map[string]documentNote),
+			Endnotes:       make(map[string]documentNote),
+			Comments:       make(map[string]documentNote),
+			CommentAnchors: make(map[string]string),
+		},
+		openComments: make(map[string]string),
+	}
+	if text := findODFDescendant(content, odfOfficeNamespace, "text"); text != nil {
+		reader.readBlocks(text, -1)
+	}
+
+	return reader.doc, nil
+}
+
+// findODFDescendant returns the first element with the given namespace and local name
+func findODFDescendant(n *xmlNode, space, local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+	if n.isIn(space, local) {
+		return n
+	}
+	for _, c := range n.Children {
+		if found := findODFDescendant(c, space, local); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// odfAttr returns the value of the attribute with the given namespace and local name
+func (n *xmlNode) odfAttr(space, local string) string {
+	for _, a := range n.Attrs {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// odtHeadingStyleLevels maps paragraph style names to heading levels. Automatic styles in
+// content.xml usually only point at a parent such as "Heading_20_2", so parents are followed.
+func odtHeadingStyleLevels(parts ...*xmlNode) map[string]int {
+	parents := make(map[string]string)
+	levels := make(map[string]int)
+
+	var collect func(*xmlNode)
+	collect = func(n *xmlNode) {
+		if n.isIn(odfStyleNamespace, "style") {
+			name := n.odfAttr(odfStyleNamespace, "name")
+			parents[name] = n.odfAttr(odfStyleNamespace, "parent-style-name")
+			if level, err := strconv.Atoi(n.odfAttr(odfStyleNamespace, "default-outline-level")); err == nil && level > 0 {
+				levels[name] = level
+			}
+			return
+		}
+		for _, c := range n.Children {
+			collect(c)
+		}
+	}
+	for _, part := range parts {
+		if part != nil {
+			collect(part)
+		}
+	}
+
+	resolved := make(map[string]int)
+	for name := range parents {
+		seen := make(map[string]bool)
+		for current := name; current != "" && !seen[current]; current = parents[current] {
+			seen[current] = true
+			level := levels[current]
+			if level == 0 {
+				// Built-in parents like "Heading_20_2" may only be referenced, not defined
+				level = headingLevelFromStyle(odfStyleDisplayName(current))
+			}
+			if level > 0 {
+				resolved[name] = level
+				break
+			}
+		}
+	}
+	return resolved
+}
+
+// odfStyleDisplayName decodes the escaped characters of an OpenDocument style name, e.g. "Heading_20_1"
+func odfStyleDisplayName(name string) string {
+	return strings.ReplaceAll(name, "_20_", " ")
+}
+
+// odtReader walks the document body in reading order
+type odtReader struct {
+	headingStyles map[string]int
+	doc           *parsedDocument
+	openComments  map[string]string // Annotation names to comment IDs whose anchored range is being read
+	commentCount  int
+}
+
+// readBlocks reads paragraphs, headings, lists and tables from a block container.
+// listLevel is the nesting level of the enclosing list, -1 outside lists.
+func (r *odtReader) readBlocks(container *xmlNode, listLevel int) {
+	for _, node := range container.Children {
+		if node.Name.Space != odfTextNamespace && node.Name.Space != odfTableNamespace {
+			continue
+		}
+
+		switch node.Name.Local {
+		case "h":
+			level, err := strconv.Atoi(node.odfAttr(odfTextNamespace, "outline-level"))
+			if err != nil || level < 1 {
+				level = 1
+			}
+			r.readParagraph(node, level, listLevel)
+		case "p":
+			style := node.odfAttr(odfTextNamespace, "style-name")
+			level, ok := r.headingStyles[style]
+			if !ok {
+				level = headingLevelFromStyle(odfStyleDisplayName(style))
+			}
+			r.readParagraph(node, level, listLevel)
+		case "list":
+			r.readBlocks(node, listLevel+1)
+		case "list-item", "list-header", "section":
+			r.readBlocks(node, listLevel)
+		case "table":
+			if node.Name.Space == odfTableNamespace {
+				r.doc.Blocks = append(r.doc.Blocks, documentBlock{Table: readODTTable(node)})
+			}
+		}
+	}
+}
+
+// readParagraph adds a paragraph or heading, followed by any text boxes anchored in it
+func (r *odtReader) readParagraph(node *xmlNode, headingLevel, listLevel int) {
+	paragraph := &documentParagraph{HeadingLevel: headingLevel, ListLevel: listLevel}
+	if headingLevel > 0 {
+		paragraph.ListLevel = -1
+	}
+	var textBoxes []*xmlNode
+
+	var sb strings.Builder
+	r.readInline(node, paragraph, &sb, &textBoxes)
+	paragraph.Text = sb.String()
+	r.doc.Blocks = append(r.doc.Blocks, documentBlock{Paragraph: paragraph})
+
+	for _, textBox := range textBoxes {
+		r.readBlocks(textBox, -1)
+	}
+}
+
+// readInline collects the text of a paragraph along with its notes and annotations
+func (r *odtReader) readInline(node *xmlNode, paragraph *documentParagraph, sb *strings.Builder, textBoxes *[]*xmlNode) {
+	for _, child := range node.Children {
+		switch {
+		case child.isText():
+			r.writeText(sb, child.Text)
+		case child.isIn(odfTextNamespace, "s"):
+			count, err := strconv.Atoi(child.odfAttr(odfTextNamespace, "c"))
+			if err != nil || count < 1 {
+				count = 1
+			}
+			r.writeText(sb, strings.Repeat(" ", count))
+		case child.isIn(odfTextNamespace, "tab"):
+			r.writeText(sb, "\t")
+		case child.isIn(odfTextNamespace, "line-break"):
+			r.writeText(sb, "\n")
+		case child.isIn(odfTextNamespace, "note"):
+			r.readNote(child, paragraph)
+		case child.isIn(odfOfficeNamespace, "annotation"):
+			r.readAnnotation(child, paragraph)
+		case child.isIn(odfOfficeNamespace, "annotation-end"):
+			delete(r.openComments, child.odfAttr(odfOfficeNamespace, "name"))
+		case child.isIn(odfDrawNamespace, "text-box"):
+			*textBoxes = append(*textBoxes, child)
+		default:
+			// Spans, links, metadata fields and frames hold more inline content
+			r.readInline(child, paragraph, sb, textBoxes)
+		}
+	}
+}
+
+// writeText appends text to the paragraph and to every open comment anchor
+func (r *odtReader) writeText(sb *strings.Builder, text string) {
+	sb.WriteString(text)
+	for _, id := range r.openComments {
+		r.doc.CommentAnchors[id] += text
+	}
+}
+
+// readNote stores a footnote or endnote under its citation, e.g. "1" or "i"
+func (r *odtReader) readNote(node *xmlNode, paragraph *documentParagraph) {
+	id := ""
+	if citation := findODFDescendant(node, odfTextNamespace, "note-citation"); citation != nil {
+		id = strings.TrimSpace(odfText(citation))
+	}
+	if id == "" {
+		id = node.odfAttr(odfTextNamespace, "id")
+	}
+
+	note := documentNote{ID: id}
+	if body := findODFDescendant(node, odfTextNamespace, "note-body"); body != nil {
+		note.Text = odfParagraphsText(body)
+	}
+
+	if node.odfAttr(odfTextNamespace, "note-class") == "endnote" {
+		r.doc.Endnotes[id] = note
+		paragraph.EndnoteIDs = append(paragraph.EndnoteIDs, id)
+		return
+	}
+	r.doc.Footnotes[id] = note
+	paragraph.FootnoteIDs = append(paragraph.FootnoteIDs, id)
+}
+
+// readAnnotation stores a reviewer comment. Comments on a range of text are closed by a
+// matching annotation-end element; the others are placed on a single point.
+func (r *odtReader) readAnnotation(node *xmlNode, paragraph *documentParagraph) {
+	id := strconv.Itoa(r.commentCount)
+	r.commentCount++
+
+	note := documentNote{ID: id}
+	var paragraphs []string
+	for _, child := range node.Children {
+		switch {
+		case child.isIn(dublinCoreNamespace, "creator"):
+			note.Author = strings.TrimSpace(child.Text)
+		case child.isIn(odfTextNamespace, "p"), child.isIn(odfTextNamespace, "list"):
+			if text := cleanText(odfText(child)); text != "" {
+				paragraphs = append(paragraphs, text)
+			}
+		}
+	}
+	note.Text = strings.Join(paragraphs, "\n")
+
+	r.doc.Comments[id] = note
+	paragraph.CommentIDs = append(paragraph.CommentIDs, id)
+	if name := node.odfAttr(odfOfficeNamespace, "name"); name != "" {
+		r.openComments[name] = id
+	}
+}
+
+// readODTTable returns the text of each row's cells; nested tables are flattened into their cell
+func readODTTable(node *xmlNode) [][]string {
+	var rows [][]string
+	var walk func(*xmlNode)
+	walk = func(n *xmlNode) {
+		for _, child := range n.Children {
+			switch {
+			case child.isIn(odfTableNamespace, "table-row"):
+				var cells []string
+				for _, cell := range child.Children {
+					if cell.isIn(odfTableNamespace, "table-cell") {
+						cells = append(cells, cleanText(odfText(cell)))
+					}
+				}
+				rows = append(rows, cells)
+			case child.isIn(odfTableNamespace, "table-header-rows"), child.isIn(odfTableNamespace, "table-rows"), child.isIn(odfTableNamespace, "table-row-group"):
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+	return rows
+}
+
+// odfParagraphsText returns the text of each paragraph in a container, one per line
+func odfParagraphsText(n *xmlNode) string {
+	var paragraphs []string
+	for _, child := range n.Children {
+		if text := cleanText(odfText(child)); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// odfText returns all text inside a node, leaving out notes and annotations
+func odfText(n *xmlNode) string {
+	var sb strings.Builder
+	var walk func(*xmlNode)
+	walk = func(node *xmlNode) {
+		switch {
+		case node.isText():
+			sb.WriteString(node.Text)
+			return
+		case node.isIn(odfTextNamespace, "note"), node.isIn(odfOfficeNamespace, "annotation"):
+			return
+		case node.isIn(odfTextNamespace, "s"), node.isIn(odfTextNamespace, "tab"), node.isIn(odfTextNamespace, "line-break"):
+			sb.WriteString(" ")
+		}
+		for _, c := range node.Children {
+			walk(c)
+		}
+		if node.isIn(odfTextNamespace, "p") || node.isIn(odfTextNamespace, "h") {
+			sb.WriteString(" ")
+		}
+	}
+	walk(n)
+	return sb.String()
+}
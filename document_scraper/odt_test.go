@@ -0,0 +1,60 @@
+package docscraper
+
+import "testing"
+
+const testODTContentXML = `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"
+	xmlns:dc="http://purl.org/dc/elements/1.1/">
+<office:automatic-styles>
+	<style:style style:name="P1" style:family="paragraph" style:parent-style-name="Heading_20_2"/>
+</office:automatic-styles>
+<office:body><office:text>
+	<text:h text:outline-level="1">Project Overview</text:h>
+	<text:p>The rollout covers three regional offices and starts <office:annotation office:name="c1"><dc:creator>Jane Doe</dc:creator><text:p>Customer said Q2 is more realistic.</text:p></office:annotation>in the first quarter<office:annotation-end office:name="c1"/> of<text:s/>next year.<text:note text:id="ftn1" text:note-class="footnote"><text:note-citation>1</text:note-citation><text:note-body><text:p>Dates depend on the procurement review.</text:p></text:note-body></text:note></text:p>
+	<text:p>Key requirements:</text:p>
+	<text:list>
+		<text:list-item><text:p>Single sign-on</text:p>
+			<text:list><text:list-item><text:p>Azure AD</text:p></text:list-item></text:list>
+		</text:list-item>
+		<text:list-item><text:p>Audit <text:span>logs</text:span></text:p></text:list-item>
+	</text:list>
+	<text:p text:style-name="P1">Budget</text:p>
+	<table:table>
+		<table:table-header-rows>
+			<table:table-row><table:table-cell><text:p>Item</text:p></table:table-cell><table:table-cell><text:p>Cost</text:p></table:table-cell></table:table-row>
+		</table:table-header-rows>
+		<table:table-row><table:table-cell><text:p>Licenses</text:p></table:table-cell><table:table-cell><text:p>40,000 EUR</text:p></table:table-cell></table:table-row>
+	</table:table>
+	<text:p>Too short to keep.</text:p>
+</office:text></office:body>
+</office:document-content>`
+
+func TestDocumentScraperReadsODT(t *testing.T) {
+	// Packages are detected by content, so the extension doesn't matter
+	path := writeTestDocx(t, map[string]string{
+		"mimetype":    odtMimeType,
+		"content.xml": testODTContentXML,
+	})
+
+	ds, err := NewDocumentScraper(path)
+	if err != nil {
+		t.Fatalf("Failed to create document scraper: %v", err)
+	}
+	if err := ds.Run(); err != nil {
+		t.Fatalf("Document scraping failed: %v", err)
+	}
+	if ds.Format != FormatODT {
+		t.Errorf("Expected format %s, got %s", FormatODT, ds.Format)
+	}
+
+	assertContentItems(t, ds.ContentItems, []ContentItem{
+		{Title: "Project Overview", Kind: ItemParagraph, Paragraph: "The rollout covers three regional offices and starts in the first quarter of next year."},
+		{Title: "Project Overview", Kind: ItemList, Paragraph: "Key requirements:\n- Single sign-on\n  - Azure AD\n- Audit logs"},
+		{Title: "Budget", Kind: ItemTable, Paragraph: "Item | Cost\nItem: Licenses | Cost: 40,000 EUR"},
+		{Title: "Project Overview", Kind: ItemFootnote, Annotation: "Footnote 1", Paragraph: "Dates depend on the procurement review."},
+		{Title: "Project Overview", Kind: ItemComment, Annotation: `Comment by Jane Doe on "in the first quarter"`, Paragraph: "Customer said Q2 is more realistic."},
+	})
+}
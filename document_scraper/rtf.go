@@ -0,0 +1,530 @@
+package docscraper
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxRTFSize caps how much of an RTF file is read
+const maxRTFSize = 64 << 20
+
+// rtfDestination says where the text of an RTF group goes
+type rtfDestination int
+
+const (
+	rtfBody        rtfDestination = iota // Document body
+	rtfSkip                              // Ignored: font tables, pictures, headers, field instructions, ...
+	rtfStyleSheet                        // Style definitions, read for heading names
+	rtfFootnote                          // Footnote or endnote text
+	rtfAnnotation                        // Reviewer comment text
+	rtfAuthor                            // Author of the next reviewer comment
+	rtfAnchorStart                       // Name of a commented range that starts here
+	rtfAnchorEnd                         // Name of a commented range that ends here
+	rtfAnchorRef                         // Name of the range a comment belongs to
+)
+
+// rtfSkippedDestinations are groups whose text is never part of the content
+var rtfSkippedDestinations = map[string]bool{
+	"fonttbl": true, "colortbl": true, "info": true, "pict": true, "object": true,
+	"header": true, "headerl": true, "headerr": true, "headerf": true,
+	"footer": true, "footerl": true, "footerr": true, "footerf": true,
+	"listtable": true, "listoverridetable": true, "revtbl": true, "rsidtbl": true,
+	"fldinst": true, "pntext": true, "listtext": true, "themedata": true, "colorschememapping": true,
+	"latentstyles": true, "datastore": true, "xmlnstbl": true, "generator": true,
+	"bkmkstart": true, "bkmkend": true, "atnid": true, "atndate": true, "atnicn": true,
+}
+
+// cp1252High maps bytes 0x80-0x9F of Windows-1252 to Unicode; other bytes map directly
+var cp1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// rtfState is the formatting state of an RTF group; groups inherit it from their parent
+type rtfState struct {
+	dest         rtfDestination
+	style        int
+	outlineLevel int  // -1 when the paragraph isn't in the outline
+	listLevel    int  // -1 when the paragraph isn't a list item
+	inTable      bool // The paragraph is part of a table cell
+	unicodeSkip  int  // Number of fallback characters following \u
+	endnote      bool // The footnote group is an endnote
+}
+
+// rtfReader turns an RTF stream into the common document model
+type rtfReader struct {
+	doc   *parsedDocument
+	state rtfState
+	stack []rtfState
+
+	text       strings.Builder // Current body paragraph or table cell
+	paragraph  *documentParagraph
+	table      [][]string
+	row        []string
+	pendingRaw int // Fallback characters still to skip after \u
+
+	styleLevels map[int]int
+	styleText   strings.Builder
+
+	noteText      strings.Builder
+	footnoteCount int
+	endnoteCount  int
+	commentCount  int
+	author        string
+	anchorName    strings.Builder
+	anchorRef     string
+	openAnchors   map[string]*strings.Builder // Commented ranges being read
+	anchors       map[string]string           // Range name to its text
+}
+
+// openRTF reads an RTF file into its body blocks, notes and comments
+func openRTF(path string) (*parsedDocument, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxRTFSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RTF file: %w", err)
+	}
+	if !strings.HasPrefix(string(data), `{\rtf`) {
+		return nil, fmt.Errorf("not a valid .rtf file")
+	}
+
+	reader := &rtfReader{
+		doc: &parsedDocument{
+			Footnotes:      make(map[string]documentNote),
+			Endnotes:       make(map[string]documentNote),
+			Comments:       make(map[string]documentNote),
+			CommentAnchors: make(map[string]string),
+		},
+		state:       rtfState{outlineLevel: -1, listLevel: -1, unicodeSkip: 1},
+		paragraph:   &documentParagraph{ListLevel: -1},
+		styleLevels: make(map[int]int),
+		openAnchors: make(map[string]*strings.Builder),
+		anchors:     make(map[string]string),
+	}
+	reader.parse(data)
+	return reader.doc, nil
+}
+
+// parse tokenizes the RTF data into groups, control words and text
+func (r *rtfReader) parse(data []byte) {
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '{':
+			r.stack = append(r.stack, r.state)
+			r.startGroup()
+		case '}':
+			r.endGroup()
+			if len(r.stack) > 0 {
+				r.state = r.stack[len(r.stack)-1]
+				r.stack = r.stack[:len(r.stack)-1]
+			}
+		case '\\':
+			i = r.parseControl(data, i+1) - 1
+		case '\r', '\n':
+			// Line breaks in the source are not part of the text
+		default:
+			r.writeRaw(rune(c))
+		}
+	}
+	r.endParagraph()
+	r.flushTable()
+}
+
+// parseControl handles the control word or symbol starting at i and returns the position after it
+func (r *rtfReader) parseControl(data []byte, i int) int {
+	if i >= len(data) {
+		return i
+	}
+
+	// Control symbols
+	if !isASCIILetter(data[i]) {
+		switch data[i] {
+		case '\\', '{', '}':
+			r.writeRaw(rune(data[i]))
+		case '~':
+			r.writeText(" ")
+		case '_':
+			r.writeText("‑")
+		case '*':
+			// The next control word is an optional destination; skip it unless it's understood
+			r.state.dest = rtfSkip
+			return r.parseStarredDestination(data, i+1)
+		case '\'':
+			if i+2 < len(data) {
+				if b, err := strconv.ParseUint(string(data[i+1:i+3]), 16, 8); err == nil {
+					r.writeRaw(decodeCP1252(byte(b)))
+				}
+				return i + 3
+			}
+		case '\r', '\n':
+			r.endParagraph()
+		}
+		return i + 1
+	}
+
+	word, param, hasParam, next := readControlWord(data, i)
+	if word == "bin" && hasParam {
+		return next + param
+	}
+	r.handleControlWord(word, param, hasParam)
+	return next
+}
+
+// parseStarredDestination handles the control word after \*
+func (r *rtfReader) parseStarredDestination(data []byte, i int) int {
+	for i < len(data) && (data[i] == ' ' || data[i] == '\r' || data[i] == '\n') {
+		i++
+	}
+	if i >= len(data) || data[i] != '\\' || i+1 >= len(data) || !isASCIILetter(data[i+1]) {
+		return i
+	}
+
+	word, _, _, next := readControlWord(data, i+1)
+	parent := r.parentDestination()
+	switch word {
+	case "annotation":
+		if parent == rtfBody {
+			r.startAnnotation()
+		}
+	case "atnauthor":
+		r.state.dest = rtfAuthor
+		r.author = ""
+	case "atrfstart":
+		r.state.dest = rtfAnchorStart
+		r.anchorName.Reset()
+	case "atrfend":
+		r.state.dest = rtfAnchorEnd
+		r.anchorName.Reset()
+	case "atnref":
+		if parent == rtfAnnotation {
+			r.state.dest = rtfAnchorRef
+			r.anchorName.Reset()
+		}
+	}
+	return next
+}
+
+// readControlWord reads a control word with its optional numeric parameter and delimiter
+func readControlWord(data []byte, i int) (string, int, bool, int) {
+	start := i
+	for i < len(data) && isASCIILetter(data[i]) {
+		i++
+	}
+	word := string(data[start:i])
+
+	paramStart := i
+	if i < len(data) && data[i] == '-' {
+		i++
+	}
+	for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+		i++
+	}
+	param, err := strconv.Atoi(string(data[paramStart:i]))
+	hasParam := err == nil
+
+	// A single space delimits the control word and isn't part of the text
+	if i < len(data) && data[i] == ' ' {
+		i++
+	}
+	return word, param, hasParam, i
+}
+
+// handleControlWord applies a control word to the reader state
+func (r *rtfReader) handleControlWord(word string, param int, hasParam bool) {
+	if rtfSkippedDestinations[word] {
+		r.state.dest = rtfSkip
+		return
+	}
+
+	switch word {
+	case "stylesheet":
+		r.state.dest = rtfStyleSheet
+	case "footnote":
+		if r.parentDestination() == rtfBody {
+			r.startFootnote()
+		}
+	case "ftnalt":
+		r.state.endnote = true
+	case "par", "sect", "page":
+		r.endParagraph()
+	case "line":
+		r.writeText("\n")
+	case "tab":
+		r.writeText("\t")
+	case "pard":
+		r.state.style = 0
+		r.state.outlineLevel = -1
+		r.state.listLevel = -1
+		r.state.inTable = false
+	case "s":
+		r.state.style = param
+	case "outlinelevel":
+		r.state.outlineLevel = param
+	case "ls":
+		if param > 0 && r.state.listLevel < 0 {
+			r.state.listLevel = 0
+		}
+	case "ilvl":
+		r.state.listLevel = param
+	case "intbl":
+		r.state.inTable = true
+	case "cell", "nestcell":
+		r.endCell()
+	case "row":
+		r.endRow()
+	case "uc":
+		r.state.unicodeSkip = param
+	case "u":
+		if hasParam {
+			if param < 0 {
+				param += 65536
+			}
+			r.writeText(string(rune(param)))
+			r.pendingRaw = r.state.unicodeSkip
+		}
+	case "emdash":
+		r.writeText("—")
+	case "endash":
+		r.writeText("–")
+	case "bullet":
+		r.writeText("•")
+	case "lquote":
+		r.writeText("‘")
+	case "rquote":
+		r.writeText("’")
+	case "ldblquote":
+		r.writeText("“")
+	case "rdblquote":
+		r.writeText("”")
+	}
+}
+
+// parentDestination returns the destination of the enclosing group
+func (r *rtfReader) parentDestination() rtfDestination {
+	if len(r.stack) == 0 {
+		return rtfBody
+	}
+	return r.stack[len(r.stack)-1].dest
+}
+
+// startGroup resets per-definition state when a new style definition begins
+func (r *rtfReader) startGroup() {
+	if r.state.dest == rtfStyleSheet {
+		r.styleText.Reset()
+		r.state.style = 0
+		r.state.outlineLevel = -1
+	}
+}
+
+// endGroup completes destinations whose group just closed
+func (r *rtfReader) endGroup() {
+	parent := r.parentDestination()
+
+	switch r.state.dest {
+	case rtfStyleSheet:
+		if parent == rtfStyleSheet {
+			name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(r.styleText.String()), ";"))
+			if level := headingLevelFromStyle(name); level > 0 {
+				r.styleLevels[r.state.style] = level
+			} else if r.state.outlineLevel >= 0 && r.state.outlineLevel < 9 {
+				r.styleLevels[r.state.style] = r.state.outlineLevel + 1
+			}
+		}
+	case rtfFootnote:
+		if parent != rtfFootnote {
+			r.finishFootnote()
+		}
+	case rtfAnnotation:
+		if parent != rtfAnnotation {
+			r.finishAnnotation()
+		}
+	case rtfAnchorStart:
+		name := strings.TrimSpace(r.anchorName.String())
+		r.openAnchors[name] = &strings.Builder{}
+	case rtfAnchorEnd:
+		name := strings.TrimSpace(r.anchorName.String())
+		if anchor, ok := r.openAnchors[name]; ok {
+			r.anchors[name] = anchor.String()
+			delete(r.openAnchors, name)
+		}
+	case rtfAnchorRef:
+		r.anchorRef = strings.TrimSpace(r.anchorName.String())
+	}
+}
+
+// writeRaw writes a character from the RTF source, honouring the \u fallback skip count
+func (r *rtfReader) writeRaw(c rune) {
+	if r.pendingRaw > 0 {
+		r.pendingRaw--
+		return
+	}
+	r.writeText(string(c))
+}
+
+// writeText sends text to the current destination
+func (r *rtfReader) writeText(text string) {
+	switch r.state.dest {
+	case rtfBody:
+		r.text.WriteString(text)
+		for _, anchor := range r.openAnchors {
+			anchor.WriteString(text)
+		}
+	case rtfStyleSheet:
+		r.styleText.WriteString(text)
+	case rtfFootnote, rtfAnnotation:
+		r.noteText.WriteString(text)
+	case rtfAuthor:
+		r.author += text
+	case rtfAnchorStart, rtfAnchorEnd, rtfAnchorRef:
+		r.anchorName.WriteString(text)
+	}
+}
+
+// endParagraph finishes the current body paragraph
+func (r *rtfReader) endParagraph() {
+	switch r.state.dest {
+	case rtfFootnote, rtfAnnotation:
+		r.noteText.WriteString("\n")
+		return
+	case rtfBody:
+	default:
+		return
+	}
+
+	if r.state.inTable {
+		// Paragraph breaks inside a cell don't end the cell
+		r.text.WriteString(" ")
+		return
+	}
+	r.flushTable()
+
+	paragraph := r.paragraph
+	paragraph.Text = r.text.String()
+	paragraph.ListLevel = r.state.listLevel
+	if r.state.outlineLevel >= 0 && r.state.outlineLevel < 9 {
+		paragraph.HeadingLevel = r.state.outlineLevel + 1
+	} else {
+		paragraph.HeadingLevel = r.styleLevels[r.state.style]
+	}
+	if paragraph.HeadingLevel > 0 {
+		paragraph.ListLevel = -1
+	}
+
+	r.doc.Blocks = append(r.doc.Blocks, documentBlock{Paragraph: paragraph})
+	r.paragraph = &documentParagraph{ListLevel: -1}
+	r.text.Reset()
+}
+
+// endCell finishes a table cell
+func (r *rtfReader) endCell() {
+	if r.state.dest != rtfBody {
+		return
+	}
+	r.row = append(r.row, cleanText(r.text.String()))
+	r.text.Reset()
+}
+
+// endRow finishes a table row
+func (r *rtfReader) endRow() {
+	if r.state.dest != rtfBody {
+		return
+	}
+	if r.row != nil {
+		r.table = append(r.table, r.row)
+	}
+	r.row = nil
+	r.text.Reset()
+}
+
+// flushTable adds the table read so far as a block; references made in it stay
+// with the next paragraph
+func (r *rtfReader) flushTable() {
+	if r.table == nil {
+		return
+	}
+	r.doc.Blocks = append(r.doc.Blocks, documentBlock{Table: r.table})
+	r.table = nil
+}
+
+// startFootnote begins collecting a footnote referenced from the current paragraph
+func (r *rtfReader) startFootnote() {
+	r.state.dest = rtfFootnote
+	r.noteText.Reset()
+}
+
+// finishFootnote stores the collected footnote or endnote, numbered in reading order
+func (r *rtfReader) finishFootnote() {
+	text := noteParagraphs(r.noteText.String())
+	r.noteText.Reset()
+
+	if r.state.endnote {
+		r.endnoteCount++
+		id := strconv.Itoa(r.endnoteCount)
+		r.doc.Endnotes[id] = documentNote{ID: id, Text: text}
+		r.paragraph.EndnoteIDs = append(r.paragraph.EndnoteIDs, id)
+		return
+	}
+	r.footnoteCount++
+	id := strconv.Itoa(r.footnoteCount)
+	r.doc.Footnotes[id] = documentNote{ID: id, Text: text}
+	r.paragraph.FootnoteIDs = append(r.paragraph.FootnoteIDs, id)
+}
+
+// startAnnotation begins collecting a reviewer comment
+func (r *rtfReader) startAnnotation() {
+	r.state.dest = rtfAnnotation
+	r.noteText.Reset()
+	r.anchorRef = ""
+}
+
+// finishAnnotation stores the collected comment along with its author and commented text
+func (r *rtfReader) finishAnnotation() {
+	id := strconv.Itoa(r.commentCount)
+	r.commentCount++
+
+	r.doc.Comments[id] = documentNote{
+		ID:     id,
+		Author: strings.TrimSpace(r.author),
+		Text:   noteParagraphs(r.noteText.String()),
+	}
+	if anchor, ok := r.anchors[r.anchorRef]; ok {
+		r.doc.CommentAnchors[id] = anchor
+	}
+	r.paragraph.CommentIDs = append(r.paragraph.CommentIDs, id)
+
+	r.noteText.Reset()
+	r.author = ""
+}
+
+// noteParagraphs cleans each line of a note and drops empty ones
+func noteParagraphs(text string) string {
+	var paragraphs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = cleanText(line); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// decodeCP1252 converts a Windows-1252 byte, the code page of almost all RTF files, to a rune
+func decodeCP1252(b byte) rune {
+	if b >= 0x80 && b <= 0x9F {
+		return cp1252High[b-0x80]
+	}
+	return rune(b)
+}
+
+// isASCIILetter reports whether b can be part of an RTF control word
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
@@ -0,0 +1,72 @@
+package docscraper
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testRTF = `{\rtf1\ansi\ansicpg1252\deff0
+{\fonttbl{\f0\froman Times New Roman;}}
+{\stylesheet{\s0 Normal;}{\s2\outlinelevel1 heading 2;}}
+{\info{\title Ignored metadata}}
+{\header\pard Page header text\par}
+\pard\outlinelevel0 Project Overview\par
+\pard The rollout covers three regional offices and starts {\*\atrfstart 0}in the first quarter{\*\atrfend 0} of next year.{\*\atnid JD}{\*\atnauthor Jane Doe}\chatn{\*\annotation{\*\atnref 0}\pard Customer said Q2 is more realistic.}{\super\chftn}{\footnote\pard{\super\chftn} Dates depend on the procurement review.}\par
+\pard Key requirements:\par
+{\listtext\'b7\tab}\pard\ls1\ilvl0 Single sign-on\par
+{\listtext o\tab}\pard\ls1\ilvl1 Azure AD\par
+{\listtext\'b7\tab}\pard\ls1\ilvl0 Audit logs\par
+\pard\s2 Budget\par
+\trowd\cellx2000\cellx4000
+\pard\intbl Item\cell Cost\cell\row
+\trowd\cellx2000\cellx4000
+\pard\intbl Licenses\cell 40,000 \'80\cell\row
+\pard Caf\'e9 caf\u233?s\par
+}`
+
+func TestDocumentScraperReadsRTF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.doc")
+	if err := os.WriteFile(path, []byte(testRTF), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	ds, err := NewDocumentScraper(path)
+	if err != nil {
+		t.Fatalf("Failed to create document scraper: %v", err)
+	}
+	if err := ds.Run(); err != nil {
+		t.Fatalf("Document scraping failed: %v", err)
+	}
+	if ds.Format != FormatRTF {
+		t.Errorf("Expected format %s, got %s", FormatRTF, ds.Format)
+	}
+
+	assertContentItems(t, ds.ContentItems, []ContentItem{
+		{Title: "Project Overview", Kind: ItemParagraph, Paragraph: "The rollout covers three regional offices and starts in the first quarter of next year."},
+		{Title: "Project Overview", Kind: ItemList, Paragraph: "Key requirements:\n- Single sign-on\n  - Azure AD\n- Audit logs"},
+		{Title: "Budget", Kind: ItemTable, Paragraph: "Item | Cost\nItem: Licenses | Cost: 40,000 €"},
+		{Title: "Project Overview", Kind: ItemFootnote, Annotation: "Footnote 1", Paragraph: "Dates depend on the procurement review."},
+		{Title: "Project Overview", Kind: ItemComment, Annotation: `Comment by Jane Doe on "in the first quarter"`, Paragraph: "Customer said Q2 is more realistic."},
+	})
+}
+
+func TestReadRTFDecodesCharacters(t *testing.T) {
+	reader := &rtfReader{
+		doc:         &parsedDocument{},
+		state:       rtfState{outlineLevel: -1, listLevel: -1, unicodeSkip: 1},
+		paragraph:   &documentParagraph{ListLevel: -1},
+		styleLevels: make(map[int]int),
+		openAnchors: make(map[string]*strings.Builder),
+		anchors:     make(map[string]string),
+	}
+	reader.parse([]byte(`{\rtf1 Caf\'e9 \u8364? 5\{x\}\par}`))
+
+	if len(reader.doc.Blocks) == 0 || reader.doc.Blocks[0].Paragraph == nil {
+		t.Fatal("Expected a paragraph")
+	}
+	if got, want := reader.doc.Blocks[0].Paragraph.Text, "Café € 5{x}"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,134 @@
+package emailscraper
+
+import (
+	"regexp"
+	"strings"
+)
+
+// signOffSearchLines is how far from the end of a message a sign-off is looked for
+const signOffSearchLines = 8
+
+// Reply headers that introduce the quoted previous message; everything after them is dropped
+var (
+	// "On Tue, 3 Jun 2025 at 10:12, Jane Doe <jane@example.com> wrote:", possibly wrapped over two lines
+	replyHeaderPattern = regexp.MustCompile(`(?i)^(on\s.+\swrote|am\s.+\sschrieb\s.+|le\s.+\sa\s[ée]crit\s?)\s?:$`)
+	// "-----Original Message-----", "---------- Forwarded message ---------"
+	separatorPattern = regexp.MustCompile(`(?i)^-{2,}\s*(original message|forwarded message|ursprüngliche nachricht|weitergeleitete nachricht)\s*-{2,}$`)
+	// Outlook draws a line above its reply header
+	outlookRulePattern = regexp.MustCompile(`^_{10,}$`)
+	// Outlook's reply header starts with "From:" and has "Sent:" or "Date:" a line or two later
+	outlookFromPattern = regexp.MustCompile(`(?i)^\*?(from|von):\*?\s`)
+	outlookSentPattern = regexp.MustCompile(`(?i)^\*?(sent|date|gesendet|datum):\*?\s`)
+)
+
+// Signature markers; everything from them on is dropped
+var (
+	// The standard "-- " delimiter, often stripped of its trailing space
+	signatureDelimiterPattern = regexp.MustCompile(`^--\s?$`)
+	mobileSignaturePattern    = regexp.MustCompile(`(?i)^(sent from my|get outlook for|von meinem .+ gesendet)`)
+	signOffPattern            = regexp.MustCompile(`(?i)^((best|kind|warm|many)\s+)?(regards|wishes)[,!.]?$|^(thanks|thank you|cheers|sincerely|best|mit freundlichen grüßen|viele grüße|beste grüße|lg|mfg)[,!.]?$`)
+)
+
+// cleanBody removes quoted replies, forwarded history and the signature from a message body
+func cleanBody(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	lines = cutAtReplyHeader(lines)
+
+	var kept []string
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if signatureDelimiterPattern.MatchString(line) || mobileSignaturePattern.MatchString(trimmed) {
+			break
+		}
+		kept = append(kept, strings.TrimRight(line, " \t"))
+	}
+
+	kept = cutAtSignOff(kept)
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// cutAtReplyHeader returns the lines before the first reply or forward header
+func cutAtReplyHeader(lines []string) []string {
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if replyHeaderPattern.MatchString(trimmed) || separatorPattern.MatchString(trimmed) || outlookRulePattern.MatchString(trimmed) {
+			return lines[:i]
+		}
+		// Mail clients wrap long "On ... wrote:" lines
+		if i+1 < len(lines) && strings.HasPrefix(strings.ToLower(trimmed), "on ") &&
+			replyHeaderPattern.MatchString(trimmed+" "+strings.TrimSpace(lines[i+1])) {
+			return lines[:i]
+		}
+		if outlookFromPattern.MatchString(trimmed) {
+			for j := i + 1; j < len(lines) && j <= i+3; j++ {
+				if outlookSentPattern.MatchString(strings.TrimSpace(lines[j])) {
+					return lines[:i]
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// cutAtSignOff drops a closing such as "Best regards," and the name below it
+func cutAtSignOff(lines []string) []string {
+	seen := 0
+	for i := len(lines) - 1; i >= 0 && seen < signOffSearchLines; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			continue
+		}
+		seen++
+		if signOffPattern.MatchString(trimmed) {
+			return lines[:i]
+		}
+	}
+	return lines
+}
+
+// splitParagraphs splits a cleaned body on blank lines and unwraps hard-wrapped lines.
+// List items keep their own line.
+func splitParagraphs(body string) []string {
+	var paragraphs []string
+	var current []string
+
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, ""))
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.Join(strings.Fields(line), " ")
+		if trimmed == "" {
+			flush()
+			continue
+		}
+		switch {
+		case len(current) == 0:
+			current = append(current, trimmed)
+		case isListLine(trimmed):
+			current = append(current, "\n"+trimmed)
+		default:
+			current = append(current, " "+trimmed)
+		}
+	}
+	flush()
+	return paragraphs
+}
+
+// listLinePattern matches bulleted and numbered list items
+var listLinePattern = regexp.MustCompile(`^([-*•]|\d+[.)])\s`)
+
+// isListLine reports whether a line is a list item
+func isListLine(line string) bool {
+	return listLinePattern.MatchString(line)
+}
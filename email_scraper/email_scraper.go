@@ -0,0 +1,282 @@
+// email_scraper/email_scraper.go
+
+package emailscraper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mbaxamb3/nusli/progress"
+)
+
+// maxEmailFileSize caps how much of an .eml file or mbox archive is read
+const maxEmailFileSize = 256 << 20
+
+// minParagraphWords is the shortest paragraph worth keeping; shorter ones are greetings and sign-offs
+const minParagraphWords = 4
+
+// oleMagic starts Outlook .msg files, which are OLE compound files rather than MIME text
+var oleMagic = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// ErrOutlookMessage is returned for Outlook .msg files, which can't be read natively
+var ErrOutlookMessage = errors.New("Outlook .msg files are not supported; save the message as .eml or export the folder as mbox")
+
+// Message is a single email with its quoted replies and signature removed
+type Message struct {
+	MessageID  string
+	InReplyTo  string
+	Subject    string
+	From       *mail.Address
+	Recipients []*mail.Address // To and Cc
+	Date       time.Time
+	Body       string
+}
+
+// ContentItem represents a paragraph of an email along with who wrote it and when
+type ContentItem struct {
+	Title       string    // Thread subject without Re:/Fwd: prefixes
+	Paragraph   string    // The paragraph text
+	MessageID   string    // Message the paragraph belongs to
+	SenderName  string    // Display name of the sender, if any
+	SenderEmail string    // Address of the sender
+	Recipients  []string  // To and Cc addresses
+	Date        time.Time // When the message was sent; zero if unknown
+	Hash        string    // Unique hash to identify content
+}
+
+// EmailScraper handles extraction from .eml files and mbox archives
+type EmailScraper struct {
+	FilePath     string
+	Messages     []Message
+	ContentItems []ContentItem
+	seenContent  map[string]bool       // Track already seen content by hash
+	Progress     chan<- progress.Event // Optional; receives progress events without blocking the scraper
+	Name         string                // Optional; names the file in progress events, which never carry its path
+	Logger       *slog.Logger          // Optional; the default logger is used when nil
+}
+
+// NewEmailScraper creates a new email scraper instance
+func NewEmailScraper(filePath string) (*EmailScraper, error) {
+	// Check if file exists
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file does not exist: %s", filePath)
+	}
+
+	return &EmailScraper{
+		FilePath:     filePath,
+		ContentItems: []ContentItem{},
+		seenContent:  make(map[string]bool),
+	}, nil
+}
+
+// logger returns the logger to log to
+func (es *EmailScraper) logger() *slog.Logger {
+	if es.Logger != nil {
+		return es.Logger
+	}
+	return slog.Default()
+}
+
+// Run reads every message in the file and splits them into attributed paragraphs
+func (es *EmailScraper) Run() error {
+	data, err := readEmailFile(es.FilePath)
+	if err != nil {
+		progress.Emit(es.Progress, progress.Event{Type: progress.EventError, URL: es.Name, Message: progress.ErrorMessage(err)})
+		return err
+	}
+
+	var raws [][]byte
+	if isMbox(data) {
+		raws = splitMbox(data)
+	} else {
+		raws = [][]byte{data}
+	}
+
+	for i, raw := range raws {
+		msg, err := parseMessage(raw)
+		if err != nil {
+			// A single broken message shouldn't lose the rest of an archive
+			if len(raws) == 1 {
+				progress.Emit(es.Progress, progress.Event{Type: progress.EventError, URL: es.Name, Message: progress.ErrorMessage(err)})
+				return fmt.Errorf("failed to parse email: %w", err)
+			}
+			es.logger().Warn("Skipped unreadable message", "message", i+1, "error", err)
+			progress.Emit(es.Progress, progress.Event{Type: progress.EventError, URL: es.Name, Message: fmt.Sprintf("message %d: %v", i+1, err)})
+			continue
+		}
+		es.Messages = append(es.Messages, msg)
+		progress.Emit(es.Progress, progress.Event{Type: progress.EventPageFetched, URL: msg.MessageID, Message: msg.Subject})
+	}
+
+	if len(es.Messages) == 0 {
+		return fmt.Errorf("no readable messages found in %s", es.FilePath)
+	}
+
+	es.sortMessages()
+
+	for _, msg := range es.Messages {
+		es.extractParagraphs(msg)
+	}
+	es.logger().Debug("Extracted email content", "messages", len(es.Messages), "content_items", len(es.ContentItems))
+	progress.Emit(es.Progress, progress.Event{Type: progress.EventItemsExtracted, URL: es.Name, Count: len(es.ContentItems)})
+
+	return nil
+}
+
+// SenderEmails returns the distinct sender addresses of the messages, lower-cased
+func (es *EmailScraper) SenderEmails() []string {
+	seen := make(map[string]bool)
+	var emails []string
+	for _, msg := range es.Messages {
+		if msg.From == nil {
+			continue
+		}
+		email := strings.ToLower(msg.From.Address)
+		if email != "" && !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+	return emails
+}
+
+// sortMessages orders messages oldest first, since threads read best that way.
+// A message without a date stays right after the message before it in the file.
+func (es *EmailScraper) sortMessages() {
+	type dated struct {
+		msg  Message
+		date time.Time
+	}
+
+	messages := make([]dated, len(es.Messages))
+	var last time.Time
+	for i, msg := range es.Messages {
+		if !msg.Date.IsZero() {
+			last = msg.Date
+		}
+		messages[i] = dated{msg: msg, date: last}
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].date.Before(messages[j].date)
+	})
+	for i, m := range messages {
+		es.Messages[i] = m.msg
+	}
+}
+
+// extractParagraphs adds the paragraphs of a message as content items
+func (es *EmailScraper) extractParagraphs(msg Message) {
+	if msg.From == nil {
+		return
+	}
+
+	recipients := make([]string, 0, len(msg.Recipients))
+	for _, addr := range msg.Recipients {
+		recipients = append(recipients, strings.ToLower(addr.Address))
+	}
+
+	title := normalizeSubject(msg.Subject)
+	if title == "" {
+		title = "(no subject)"
+	}
+
+	for _, paragraph := range splitParagraphs(msg.Body) {
+		if countWords(paragraph) < minParagraphWords {
+			continue
+		}
+
+		item := ContentItem{
+			Title:       title,
+			Paragraph:   paragraph,
+			MessageID:   msg.MessageID,
+			SenderName:  msg.From.Name,
+			SenderEmail: strings.ToLower(msg.From.Address),
+			Recipients:  recipients,
+			Date:        msg.Date,
+			// The same text quoted or forwarded by the same sender is only kept once
+			Hash: generateContentHash(strings.ToLower(msg.From.Address), paragraph),
+		}
+		if !es.seenContent[item.Hash] {
+			es.seenContent[item.Hash] = true
+			es.ContentItems = append(es.ContentItems, item)
+		}
+	}
+}
+
+// emailHeaderPattern matches the header lines that identify an RFC 822 message
+var emailHeaderPattern = regexp.MustCompile(`(?im)^(from|date|message-id|received|return-path|subject|to):[ \t]`)
+
+// LooksLikeEmail reports whether data is an RFC 822 message or an mbox archive
+func LooksLikeEmail(data []byte) bool {
+	if isMbox(data) {
+		return true
+	}
+
+	// Look at the header block only: everything up to the first blank line
+	header := data
+	if end := bytes.Index(header, []byte("\n\n")); end >= 0 {
+		header = header[:end]
+	} else if end := bytes.Index(header, []byte("\r\n\r\n")); end >= 0 {
+		header = header[:end]
+	}
+	if len(header) > 64<<10 {
+		return false
+	}
+
+	found := make(map[string]bool)
+	for _, match := range emailHeaderPattern.FindAllSubmatch(header, -1) {
+		found[strings.ToLower(string(match[1]))] = true
+	}
+	// A sender plus at least one other header that only mail has
+	return found["from"] && (found["date"] || found["message-id"] || found["received"] || found["return-path"])
+}
+
+// readEmailFile reads an email file, rejecting formats that aren't MIME text
+func readEmailFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxEmailFileSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read email file: %w", err)
+	}
+	if bytes.HasPrefix(data, oleMagic) {
+		return nil, ErrOutlookMessage
+	}
+	return data, nil
+}
+
+// subjectPrefixPattern matches reply and forward prefixes, including common localized ones
+var subjectPrefixPattern = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|aw|wg|sv|vs|tr|rif)(\[\d+\])?\s*:\s*)+`)
+
+// normalizeSubject removes reply and forward prefixes so every message of a thread shares a title
+func normalizeSubject(subject string) string {
+	return strings.TrimSpace(subjectPrefixPattern.ReplaceAllString(subject, ""))
+}
+
+// generateContentHash creates a unique hash for content
+func generateContentHash(sender, content string) string {
+	h := sha256.New()
+	h.Write([]byte(sender + "|" + strings.Join(strings.Fields(content), " ")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// countWords counts words in a string
+func countWords(s string) int {
+	return len(strings.Fields(s))
+}
@@ -0,0 +1,239 @@
+package emailscraper
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mbaxamb3/nusli/progress"
+)
+
+const testMbox = `From jane@example.com Tue Jun  3 10:12:00 2025
+Message-ID: <1@example.com>
+From: Jane Doe <Jane@Example.com>
+To: Bob Smith <bob@acme.test>
+Cc: sales@acme.test
+Subject: Rollout plan
+Date: Tue, 3 Jun 2025 10:12:00 +0000
+Content-Type: text/plain; charset=utf-8
+
+Hi Bob,
+
+The rollout covers three regional offices and starts in the first
+quarter of next year.
+
+>From our side the budget is already approved.
+
+Best regards,
+Jane
+
+--
+Jane Doe | Head of IT
+
+From bob@acme.test Wed Jun  4 08:00:00 2025
+Message-ID: <2@acme.test>
+In-Reply-To: <1@example.com>
+From: =?UTF-8?Q?Bob_Sm=C3=AFth?= <bob@acme.test>
+To: Jane Doe <jane@example.com>
+Subject: RE: Rollout plan
+Date: Wed, 4 Jun 2025 08:00:00 +0000
+MIME-Version: 1.0
+Content-Type: multipart/alternative; boundary="b1"
+
+--b1
+Content-Type: text/plain; charset=iso-8859-1
+Content-Transfer-Encoding: quoted-printable
+
+Thanks Jane, our procurement review takes about six weeks.
+We need these before signing:
+- Single sign-on
+- Audit logs
+
+On Tue, 3 Jun 2025 at 10:12, Jane Doe <jane@example.com>
+wrote:
+> The rollout covers three regional offices and starts in the first
+> quarter of next year.
+
+Sent from my phone
+--b1
+Content-Type: text/html; charset=utf-8
+
+<p>Thanks Jane, our procurement review takes about six weeks.</p>
+--b1--
+`
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestEmailScraperReadsMbox(t *testing.T) {
+	es, err := NewEmailScraper(writeTestFile(t, "archive.mbox", testMbox))
+	if err != nil {
+		t.Fatalf("Failed to create email scraper: %v", err)
+	}
+	if err := es.Run(); err != nil {
+		t.Fatalf("Email scraping failed: %v", err)
+	}
+
+	if len(es.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(es.Messages))
+	}
+
+	want := []ContentItem{
+		{Title: "Rollout plan", SenderEmail: "jane@example.com", Paragraph: "The rollout covers three regional offices and starts in the first quarter of next year."},
+		{Title: "Rollout plan", SenderEmail: "jane@example.com", Paragraph: "From our side the budget is already approved."},
+		{Title: "Rollout plan", SenderEmail: "bob@acme.test", SenderName: "Bob Smïth", Paragraph: "Thanks Jane, our procurement review takes about six weeks. We need these before signing:\n- Single sign-on\n- Audit logs"},
+	}
+	if len(es.ContentItems) != len(want) {
+		var got []string
+		for _, item := range es.ContentItems {
+			got = append(got, item.SenderEmail+": "+item.Paragraph)
+		}
+		t.Fatalf("Expected %d items, got %d:\n%s", len(want), len(es.ContentItems), strings.Join(got, "\n"))
+	}
+	for i, w := range want {
+		got := es.ContentItems[i]
+		if got.Title != w.Title || got.SenderEmail != w.SenderEmail || got.Paragraph != w.Paragraph {
+			t.Errorf("Item %d:\n got: %q %q %q\nwant: %q %q %q", i, got.Title, got.SenderEmail, got.Paragraph, w.Title, w.SenderEmail, w.Paragraph)
+		}
+		if w.SenderName != "" && got.SenderName != w.SenderName {
+			t.Errorf("Item %d: expected sender name %q, got %q", i, w.SenderName, got.SenderName)
+		}
+	}
+
+	first := es.ContentItems[0]
+	if strings.Join(first.Recipients, ",") != "bob@acme.test,sales@acme.test" {
+		t.Errorf("Unexpected recipients %v", first.Recipients)
+	}
+	if !first.Date.Equal(time.Date(2025, 6, 3, 10, 12, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected date %v", first.Date)
+	}
+	if first.MessageID != "1@example.com" {
+		t.Errorf("Unexpected message ID %q", first.MessageID)
+	}
+
+	if senders := strings.Join(es.SenderEmails(), ","); senders != "jane@example.com,bob@acme.test" {
+		t.Errorf("Unexpected senders %s", senders)
+	}
+}
+
+func TestEmailScraperReadsHTMLOnlyEml(t *testing.T) {
+	eml := "From: Jane Doe <jane@example.com>\r\n" +
+		"Subject: Fwd: Pricing\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: base64\r\n\r\n"
+	eml += "PHA+V2UgY2FuIG9mZmVyIGEgdGVuIHBlcmNlbnQgZGlzY291bnQgZm9yIHRocmVlIHll\r\n" +
+		"YXJzLjwvcD48YmxvY2txdW90ZT5PbGQgcXVvdGVkIHRleHQgaGVyZTwvYmxvY2txdW90ZT4=\r\n"
+
+	es, err := NewEmailScraper(writeTestFile(t, "pricing.eml", eml))
+	if err != nil {
+		t.Fatalf("Failed to create email scraper: %v", err)
+	}
+	if err := es.Run(); err != nil {
+		t.Fatalf("Email scraping failed: %v", err)
+	}
+
+	if len(es.ContentItems) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(es.ContentItems))
+	}
+	if got := es.ContentItems[0]; got.Title != "Pricing" || got.Paragraph != "We can offer a ten percent discount for three years." {
+		t.Errorf("Unexpected item %q %q", got.Title, got.Paragraph)
+	}
+}
+
+func TestEmailScraperRejectsOutlookMsg(t *testing.T) {
+	path := writeTestFile(t, "message.msg", string([]byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1, 0, 0}))
+
+	es, err := NewEmailScraper(path)
+	if err != nil {
+		t.Fatalf("Failed to create email scraper: %v", err)
+	}
+	if err := es.Run(); !errors.Is(err, ErrOutlookMessage) {
+		t.Errorf("Expected ErrOutlookMessage, got %v", err)
+	}
+}
+
+func TestEmailScraperEventsHidePath(t *testing.T) {
+	path := writeTestFile(t, "upload-1234.eml", testMbox)
+	es, err := NewEmailScraper(path)
+	if err != nil {
+		t.Fatalf("Failed to create email scraper: %v", err)
+	}
+	events := make(chan progress.Event, 10)
+	es.Progress = events
+	es.Name = "thread.eml"
+
+	// The file disappears before it's read, so the read error names the path
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("Failed to remove %s: %v", path, err)
+	}
+	if err := es.Run(); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+	close(events)
+
+	for event := range events {
+		if strings.Contains(event.URL+event.Message, filepath.Dir(path)) {
+			t.Errorf("Event %+v contains the file's path", event)
+		}
+		if event.URL != "thread.eml" {
+			t.Errorf("Expected the event to name thread.eml, got %q", event.URL)
+		}
+	}
+}
+
+func TestCleanBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "outlook reply header",
+			body: "Sounds good.\n\n________________________________\nFrom: Jane Doe\nSent: Monday\nSubject: Hi\n\nOld text",
+			want: "Sounds good.",
+		},
+		{
+			name: "outlook header without rule",
+			body: "Sounds good.\n\nFrom: Jane Doe <jane@example.com>\nSent: Monday, June 2, 2025\nOld text",
+			want: "Sounds good.",
+		},
+		{
+			name: "german reply header",
+			body: "Passt so.\n\nAm 03.06.2025 um 10:12 schrieb Jane Doe <jane@example.com>:\n> Alt",
+			want: "Passt so.",
+		},
+		{
+			name: "forwarded message",
+			body: "FYI\n\n---------- Forwarded message ---------\nFrom: someone",
+			want: "FYI",
+		},
+		{
+			name: "sign-off with name",
+			body: "Let's meet on Friday.\n\nCheers,\nBob",
+			want: "Let's meet on Friday.",
+		},
+		{
+			name: "from in body text is kept",
+			body: "From what I hear the budget is approved.",
+			want: "From what I hear the budget is approved.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanBody(tt.body); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package emailscraper
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// maxMultipartDepth limits how deeply nested multipart bodies are followed
+const maxMultipartDepth = 10
+
+// headerDecoder decodes RFC 2047 encoded words in any charset
+var headerDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// addressParser parses address headers with encoded display names
+var addressParser = &mail.AddressParser{WordDecoder: headerDecoder}
+
+// isMbox reports whether data is an mbox archive rather than a single message
+func isMbox(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("From "))
+}
+
+// escapedFromPattern matches body lines escaped by mbox writers, e.g. ">From " or ">>From "
+var escapedFromPattern = regexp.MustCompile(`^>+From `)
+
+// splitMbox splits an mbox archive into raw messages. A message starts at a "From " line at
+// the start of the file or after a blank line; escaped ">From " body lines are restored.
+func splitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	var current bytes.Buffer
+	started := false
+	previousBlank := true
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), maxEmailFileSize)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		if previousBlank && strings.HasPrefix(line, "From ") {
+			if started && len(bytes.TrimSpace(current.Bytes())) > 0 {
+				messages = append(messages, append([]byte(nil), current.Bytes()...))
+			}
+			current.Reset()
+			started = true
+			previousBlank = false
+			continue
+		}
+
+		if escapedFromPattern.MatchString(line) {
+			line = line[1:]
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		previousBlank = line == ""
+	}
+	if started && len(bytes.TrimSpace(current.Bytes())) > 0 {
+		messages = append(messages, current.Bytes())
+	}
+	return messages
+}
+
+// parseMessage reads the headers and readable body of a single RFC 822 message
+func parseMessage(raw []byte) (Message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{
+		MessageID: strings.Trim(strings.TrimSpace(m.Header.Get("Message-Id")), "<>"),
+		InReplyTo: strings.Trim(strings.TrimSpace(m.Header.Get("In-Reply-To")), "<>"),
+	}
+
+	msg.Subject, err = headerDecoder.DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		msg.Subject = m.Header.Get("Subject")
+	}
+
+	if from := m.Header.Get("From"); from != "" {
+		if addr, err := addressParser.Parse(from); err == nil {
+			msg.From = addr
+		}
+	}
+	if msg.From == nil {
+		return Message{}, fmt.Errorf("message has no valid sender")
+	}
+
+	for _, field := range []string{"To", "Cc"} {
+		if value := m.Header.Get(field); value != "" {
+			if addrs, err := addressParser.ParseList(value); err == nil {
+				msg.Recipients = append(msg.Recipients, addrs...)
+			}
+		}
+	}
+
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date.UTC()
+	}
+
+	plain, htmlText, err := readBody(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"), m.Body, 0)
+	if err != nil {
+		return Message{}, err
+	}
+	if strings.TrimSpace(plain) == "" {
+		plain = htmlText
+	}
+	msg.Body = cleanBody(plain)
+
+	return msg, nil
+}
+
+// readBody returns the plain text and the text of the HTML version of a body, whichever exist.
+// Attachments, including forwarded messages, are skipped.
+func readBody(contentType, transferEncoding string, body io.Reader, depth int) (string, string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || contentType == "" {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxMultipartDepth || params["boundary"] == "" {
+			return "", "", nil
+		}
+		return readMultipart(multipart.NewReader(body, params["boundary"]), depth)
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+
+	text, err := decodeText(body, transferEncoding, params["charset"])
+	if err != nil {
+		return "", "", err
+	}
+	if mediaType == "text/html" {
+		return "", htmlToText(text), nil
+	}
+	return text, "", nil
+}
+
+// readMultipart collects the first plain text and HTML bodies of a multipart message
+func readMultipart(reader *multipart.Reader, depth int) (string, string, error) {
+	var plain, htmlText string
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// Truncated messages still have useful parts before the damage
+			break
+		}
+
+		if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+			continue
+		}
+
+		// multipart.Reader already decodes quoted-printable parts and removes the header
+		p, h, err := readBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, depth+1)
+		if err != nil {
+			return "", "", err
+		}
+		if plain == "" {
+			plain = p
+		}
+		if htmlText == "" {
+			htmlText = h
+		}
+	}
+	return plain, htmlText, nil
+}
+
+// decodeText undoes the transfer encoding of a text body and converts it to UTF-8
+func decodeText(body io.Reader, transferEncoding, charsetLabel string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: body})
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if charsetLabel != "" && !strings.EqualFold(charsetLabel, "utf-8") && !strings.EqualFold(charsetLabel, "us-ascii") {
+		if converted, err := charset.NewReaderLabel(charsetLabel, body); err == nil {
+			body = converted
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxEmailFileSize))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode message body: %w", err)
+	}
+	return strings.ReplaceAll(string(data), "\r\n", "\n"), nil
+}
+
+// newlineStripper drops line breaks so wrapped base64 can be decoded
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	for {
+		count, err := n.r.Read(p)
+		kept := 0
+		for _, b := range p[:count] {
+			if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+				p[kept] = b
+				kept++
+			}
+		}
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// htmlBlockElements start a new line when converting HTML to text
+var htmlBlockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "table": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "hr": true,
+}
+
+// htmlToText converts an HTML body to plain text, leaving out quoted replies
+func htmlToText(source string) string {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "head", "blockquote":
+				return
+			}
+			for _, attr := range n.Attr {
+				// Gmail wraps the quoted thread and the signature in these; Outlook's
+				// reply header is plain text and is cut off along with the plain text body
+				if attr.Key == "class" && (strings.Contains(attr.Val, "gmail_quote") || strings.Contains(attr.Val, "gmail_signature")) {
+					return
+				}
+			}
+		}
+
+		if n.Type == html.TextNode {
+			sb.WriteString(strings.Join(strings.Fields(n.Data), " "))
+			if strings.HasSuffix(n.Data, " ") || strings.HasSuffix(n.Data, "\n") {
+				sb.WriteString(" ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+
+		if n.Type == html.ElementNode && htmlBlockElements[n.Data] {
+			if n.Data == "br" || n.Data == "li" {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+	}
+	walk(doc)
+	return sb.String()
+}
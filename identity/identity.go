@@ -0,0 +1,127 @@
+// Package identity verifies the access tokens API clients present. An IdentityProvider
+// turns a bearer token into the verified identity of the caller; providers exist for any
+// OpenID Connect issuer (AWS Cognito being one) and for local development, where tokens are
+// signed with a key generated at startup so the API can run without network access.
+package identity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken is wrapped by every error returned for a token that can't be trusted
+var ErrInvalidToken = errors.New("invalid token")
+
+// Reasons a token is rejected, wrapped together with ErrInvalidToken where they apply
+var (
+	ErrExpired       = errors.New("token has expired")
+	ErrNotYetValid   = errors.New("token is not valid yet")
+	ErrWrongIssuer   = errors.New("token is from another issuer")
+	ErrWrongAudience = errors.New("token is for another audience")
+	ErrWrongTokenUse = errors.New("token is of the wrong type")
+	ErrUnknownKey    = errors.New("token is signed with an unknown key")
+)
+
+// Claims is the verified identity of a caller
+type Claims struct {
+	Subject string    // Stable user ID, stored as cognito_sub
+	Email   string    // May be empty; access tokens don't always carry it
+	Groups  []string  // Group memberships, used as application-wide roles
+	Issuer  string    // Who issued the token
+	Expiry  time.Time // When the token stops being valid
+	Scopes  []string  // What an API token may do; nil when the token carries the user's full access
+}
+
+// IdentityProvider verifies access tokens
+type IdentityProvider interface {
+	// Name identifies the provider in logs
+	Name() string
+	// Verify checks the signature, issuer, audience and lifetime of a token and returns the
+	// identity it carries. Untrusted tokens return an error wrapping ErrInvalidToken.
+	Verify(ctx context.Context, token string) (Claims, error)
+}
+
+// KeyChecker is implemented by providers that verify tokens with keys fetched from elsewhere
+type KeyChecker interface {
+	// CheckKeys reports whether signing keys are available to verify tokens with
+	CheckKeys(ctx context.Context) error
+}
+
+// Providers that can be configured
+const (
+	ProviderCognito = "cognito"
+	ProviderOIDC    = "oidc"
+	ProviderLocal   = "local"
+)
+
+// DefaultLeeway is how far token timestamps may be off to allow for clock skew
+const DefaultLeeway = time.Minute
+
+// Config selects and configures an identity provider
+type Config struct {
+	Provider string // ProviderCognito, ProviderOIDC or ProviderLocal
+
+	// OIDC settings, also used by Cognito
+	Issuer      string        // Expected iss claim; the discovery document is read from it
+	Audiences   []string      // Accepted aud (or Cognito client_id) values; at least one is required
+	JWKSURLs    []string      // Key sets trusted in addition to the one found by discovery
+	GroupsClaim string        // Claim listing the user's groups
+	TokenUse    string        // Required token_use claim, e.g. "access" for Cognito; unchecked when empty
+	Leeway      time.Duration // Allowed clock skew; DefaultLeeway when zero
+
+	// Cognito settings; the issuer is derived from them unless set
+	CognitoRegion     string
+	CognitoUserPoolID string
+
+	// Local settings
+	LocalKeyFile string // PEM private key, so local tokens survive restarts; generated when empty
+}
+
+// CognitoIssuer returns the issuer URL of a Cognito user pool
+func CognitoIssuer(region, userPoolID string) string {
+	return fmt.Sprintf("https://cognito-idp.%s.amazonaws.com/%s", region, userPoolID)
+}
+
+// Open creates the identity provider described by cfg. OIDC providers fetch their discovery
+// document and keys, so ctx should allow for a network round trip.
+func Open(ctx context.Context, cfg Config) (IdentityProvider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", ProviderCognito:
+		if cfg.Issuer == "" {
+			if cfg.CognitoRegion == "" || cfg.CognitoUserPoolID == "" {
+				return nil, errors.New("cognito provider needs a region and user pool ID")
+			}
+			cfg.Issuer = CognitoIssuer(cfg.CognitoRegion, cfg.CognitoUserPoolID)
+		}
+		if cfg.GroupsClaim == "" {
+			cfg.GroupsClaim = "cognito:groups"
+		}
+		if cfg.TokenUse == "" {
+			// Cognito ID tokens are signed with the same keys but aren't meant for APIs
+			cfg.TokenUse = "access"
+		}
+		return openOIDC(ctx, ProviderCognito, cfg)
+	case ProviderOIDC:
+		return openOIDC(ctx, ProviderOIDC, cfg)
+	case ProviderLocal:
+		p, err := NewLocalProvider(cfg.LocalKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown identity provider %q, expected %q, %q or %q",
+		cfg.Provider, ProviderCognito, ProviderOIDC, ProviderLocal)
+}
+
+// openOIDC wraps NewOIDCProvider so a failure returns a nil interface
+func openOIDC(ctx context.Context, name string, cfg Config) (IdentityProvider, error) {
+	p, err := NewOIDCProvider(ctx, name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
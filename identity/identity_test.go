@@ -0,0 +1,483 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"expvar"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIssuer serves an OpenID Connect discovery document and a key set for one RSA key.
+// The key can be rotated and the key set made to fail.
+type fakeIssuer struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	key     *rsa.PrivateKey
+	kid     string
+	failing bool
+	fetches int
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	f := &fakeIssuer{}
+	f.rotate(t, "key-1")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   f.server.URL,
+			"jwks_uri": f.server.URL + "/jwks.json",
+		})
+	})
+	mux.HandleFunc("/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.fetches++
+		if f.failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string][]jsonWebKey{
+			"keys": {testJSONWebKey(f.kid, &f.key.PublicKey)},
+		})
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// rotate replaces the issuer's key with a new one
+func (f *fakeIssuer) rotate(t *testing.T, kid string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.key, f.kid = key, kid
+}
+
+// setFailing makes the key set fail or recover
+func (f *fakeIssuer) setFailing(failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failing = failing
+}
+
+// fetchCount returns how often the key set was requested
+func (f *fakeIssuer) fetchCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fetches
+}
+
+// sign returns a token signed by the issuer's key with the given claims
+func (f *fakeIssuer) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = f.kid
+	signed, err := token.SignedString(f.key)
+	require.NoError(t, err)
+	return signed
+}
+
+// claims returns valid claims for the issuer, with overrides applied
+func (f *fakeIssuer) claims(overrides jwt.MapClaims) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":    f.server.URL,
+		"sub":    "alice",
+		"aud":    "web",
+		"email":  "alice@example.com",
+		"groups": []string{"admin"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+	return claims
+}
+
+func testJSONWebKey(kid string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kid: kid,
+		Kty: "RSA",
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// allowFetches lifts the rate limit of a key set, but not its backoff
+func allowFetches(s *KeySet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refetchInterval = 0
+	s.nextFetch = time.Time{}
+}
+
+func TestOIDCProvider(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := Open(ctx, Config{
+		Provider:    ProviderOIDC,
+		Issuer:      issuer.server.URL,
+		Audiences:   []string{"web"},
+		GroupsClaim: "groups",
+	})
+	require.NoError(t, err)
+	require.Equal(t, ProviderOIDC, provider.Name())
+
+	claims, err := provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+	require.Equal(t, "alice@example.com", claims.Email)
+	require.Equal(t, []string{"admin"}, claims.Groups)
+	require.Equal(t, issuer.server.URL, claims.Issuer)
+
+	// Cognito access tokens carry the client in client_id rather than aud
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(jwt.MapClaims{"aud": nil, "client_id": "web"})))
+	require.NoError(t, err)
+
+	// Within the leeway
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(jwt.MapClaims{"exp": time.Now().Add(-30 * time.Second).Unix()})))
+	require.NoError(t, err)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forged := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.claims(nil))
+	forged.Header["kid"] = issuer.kid
+	forgedToken, err := forged.SignedString(other)
+	require.NoError(t, err)
+
+	unknownKid := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.claims(nil))
+	unknownKid.Header["kid"] = "key-0"
+	unknownKidToken, err := unknownKid.SignedString(other)
+	require.NoError(t, err)
+
+	hs := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.claims(nil))
+	hs.Header["kid"] = issuer.kid
+	hsToken, err := hs.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	rejected := []struct {
+		name   string
+		token  string
+		reason error
+	}{
+		{"wrong issuer", issuer.sign(t, issuer.claims(jwt.MapClaims{"iss": "https://evil.example.com"})), ErrWrongIssuer},
+		{"wrong audience", issuer.sign(t, issuer.claims(jwt.MapClaims{"aud": "other"})), ErrWrongAudience},
+		{"no audience", issuer.sign(t, issuer.claims(jwt.MapClaims{"aud": nil})), ErrWrongAudience},
+		{"expired", issuer.sign(t, issuer.claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})), ErrExpired},
+		{"no expiry", issuer.sign(t, issuer.claims(jwt.MapClaims{"exp": nil})), nil},
+		{"not yet valid", issuer.sign(t, issuer.claims(jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()})), ErrNotYetValid},
+		{"no subject", issuer.sign(t, issuer.claims(jwt.MapClaims{"sub": nil})), nil},
+		{"forged signature", forgedToken, nil},
+		{"unknown key", unknownKidToken, ErrUnknownKey},
+		{"wrong algorithm", hsToken, nil},
+		{"malformed", "not-a-token", nil},
+	}
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.Verify(ctx, tt.token)
+			require.ErrorIs(t, err, ErrInvalidToken)
+			if tt.reason != nil {
+				require.ErrorIs(t, err, tt.reason)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderDiscovery(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	// The discovery document must be for the configured issuer
+	_, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL + "/other", Audiences: []string{"web"}})
+	require.Error(t, err)
+
+	// A trailing slash in the configuration is fine
+	_, err = NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL + "/", Audiences: []string{"web"}})
+	require.NoError(t, err)
+
+	// Additional key sets must load too
+	_, err = NewOIDCProvider(ctx, ProviderOIDC, Config{
+		Issuer:    issuer.server.URL,
+		Audiences: []string{"web"},
+		JWKSURLs:  []string{issuer.server.URL + "/missing.json"},
+	})
+	require.Error(t, err)
+}
+
+func TestOIDCProviderNeedsAudience(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	_, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL})
+	require.Error(t, err)
+
+	// A verifier without audiences rejects every token instead of skipping the check
+	v := verifier{issuer: issuer.server.URL}
+	claims := issuer.claims(jwt.MapClaims{"exp": float64(time.Now().Add(time.Hour).Unix())})
+	require.ErrorIs(t, v.validate(claims, time.Now()), ErrWrongAudience)
+}
+
+func TestOIDCProviderAdditionalKeySet(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	rotated := newFakeIssuer(t)
+	rotated.kid = "key-2"
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, ProviderOIDC, Config{
+		Issuer:    issuer.server.URL,
+		Audiences: []string{"web"},
+		JWKSURLs:  []string{rotated.server.URL + "/jwks.json"},
+	})
+	require.NoError(t, err)
+
+	// Signed with the key of the additional key set, for the configured issuer
+	_, err = provider.Verify(ctx, rotated.sign(t, issuer.claims(nil)))
+	require.NoError(t, err)
+}
+
+func TestCognitoTokenUse(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := Open(ctx, Config{
+		Provider:  ProviderCognito,
+		Issuer:    issuer.server.URL,
+		Audiences: []string{"web"},
+	})
+	require.NoError(t, err)
+
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(jwt.MapClaims{"token_use": "access"})))
+	require.NoError(t, err)
+
+	// ID tokens are signed with the same keys but aren't accepted
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(jwt.MapClaims{"token_use": "id"})))
+	require.ErrorIs(t, err, ErrWrongTokenUse)
+
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.ErrorIs(t, err, ErrWrongTokenUse)
+}
+
+func TestLeeway(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL, Audiences: []string{"web"}, Leeway: 5 * time.Minute})
+	require.NoError(t, err)
+
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(jwt.MapClaims{
+		"exp": time.Now().Add(-4 * time.Minute).Unix(),
+		"nbf": time.Now().Add(4 * time.Minute).Unix(),
+	})))
+	require.NoError(t, err)
+
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(jwt.MapClaims{"exp": time.Now().Add(-6 * time.Minute).Unix()})))
+	require.ErrorIs(t, err, ErrExpired)
+}
+
+func TestKeyRotation(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL, Audiences: []string{"web"}})
+	require.NoError(t, err)
+	allowFetches(provider.keySets[0])
+
+	// A token with a kid we haven't seen makes us fetch the key set again
+	issuer.rotate(t, "key-2")
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.NoError(t, err)
+	require.Equal(t, 2, issuer.fetchCount())
+
+	// Known keys don't cause fetches
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.NoError(t, err)
+	require.Equal(t, 2, issuer.fetchCount())
+}
+
+func TestKeyFetchRateLimit(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL, Audiences: []string{"web"}})
+	require.NoError(t, err)
+
+	// Unknown kids right after a fetch don't cause another one
+	issuer.rotate(t, "key-2")
+	for i := 0; i < 5; i++ {
+		_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+		require.ErrorIs(t, err, ErrUnknownKey)
+	}
+	require.Equal(t, 1, issuer.fetchCount())
+}
+
+func TestKeyFetchBackoff(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL, Audiences: []string{"web"}})
+	require.NoError(t, err)
+	keySet := provider.keySets[0]
+	allowFetches(keySet)
+
+	// A failed fetch keeps the old keys and backs off
+	token := issuer.sign(t, issuer.claims(nil))
+	issuer.setFailing(true)
+	issuer.rotate(t, "key-2")
+
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.ErrorIs(t, err, ErrUnknownKey)
+	require.Equal(t, 2, issuer.fetchCount())
+
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.ErrorIs(t, err, ErrUnknownKey)
+	require.Equal(t, 2, issuer.fetchCount())
+
+	_, err = provider.Verify(ctx, token)
+	require.NoError(t, err)
+
+	// The backoff doubles with every failure
+	keySet.mu.Lock()
+	require.Equal(t, 2*keyMinBackoff, keySet.backoff)
+	keySet.nextFetch = time.Time{}
+	keySet.mu.Unlock()
+
+	issuer.setFailing(false)
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.NoError(t, err)
+	require.Equal(t, 3, issuer.fetchCount())
+
+	keySet.mu.Lock()
+	require.Zero(t, keySet.backoff)
+	keySet.mu.Unlock()
+}
+
+func TestCheckKeys(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL, Audiences: []string{"web"}})
+	require.NoError(t, err)
+	require.NoError(t, provider.CheckKeys(ctx))
+
+	// Cached keys keep counting while the issuer fails
+	issuer.setFailing(true)
+	allowFetches(provider.keySets[0])
+	require.NoError(t, provider.CheckKeys(ctx))
+
+	// A key set that never loaded is fetched once, then backs off
+	keySet := NewKeySet(issuer.server.URL+"/jwks.json", nil)
+	fetches := issuer.fetchCount()
+	require.Error(t, keySet.Check(ctx))
+	require.Error(t, keySet.Check(ctx))
+	require.Equal(t, fetches+1, issuer.fetchCount())
+
+	issuer.setFailing(false)
+	allowFetches(keySet)
+	require.NoError(t, keySet.Check(ctx))
+}
+
+func TestVerificationMetrics(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	ctx := context.Background()
+
+	provider, err := NewOIDCProvider(ctx, ProviderOIDC, Config{Issuer: issuer.server.URL, Audiences: []string{"web"}})
+	require.NoError(t, err)
+
+	count := func(outcome string) int64 {
+		if v, ok := verifications.Get(outcome).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	ok, expired := count("ok"), count("expired")
+
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(nil)))
+	require.NoError(t, err)
+	_, err = provider.Verify(ctx, issuer.sign(t, issuer.claims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()})))
+	require.Error(t, err)
+
+	require.Equal(t, ok+1, count("ok"))
+	require.Equal(t, expired+1, count("expired"))
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := Open(ctx, Config{Provider: "saml"})
+	require.Error(t, err)
+
+	_, err = Open(ctx, Config{Provider: ProviderCognito})
+	require.Error(t, err)
+
+	_, err = Open(ctx, Config{Provider: ProviderOIDC})
+	require.Error(t, err)
+
+	provider, err := Open(ctx, Config{Provider: "LOCAL"})
+	require.NoError(t, err)
+	require.Equal(t, ProviderLocal, provider.Name())
+}
+
+func TestLocalProvider(t *testing.T) {
+	ctx := context.Background()
+	keyFile := filepath.Join(t.TempDir(), "keys", "local-auth.pem")
+
+	provider, err := NewLocalProvider(keyFile)
+	require.NoError(t, err)
+
+	token, err := provider.Issue("alice", "alice@example.com", []string{"admin"}, time.Hour)
+	require.NoError(t, err)
+
+	claims, err := provider.Verify(ctx, token)
+	require.NoError(t, err)
+	require.Equal(t, "alice", claims.Subject)
+	require.Equal(t, "alice@example.com", claims.Email)
+	require.Equal(t, []string{"admin"}, claims.Groups)
+	require.Equal(t, LocalIssuer, claims.Issuer)
+
+	// The saved key lets tokens survive a restart
+	restarted, err := NewLocalProvider(keyFile)
+	require.NoError(t, err)
+	_, err = restarted.Verify(ctx, token)
+	require.NoError(t, err)
+
+	// Tokens from another key are rejected
+	other, err := NewLocalProvider("")
+	require.NoError(t, err)
+	_, err = other.Verify(ctx, token)
+	require.ErrorIs(t, err, ErrInvalidToken)
+
+	expired, err := provider.Issue("alice", "", nil, -time.Hour)
+	require.NoError(t, err)
+	_, err = provider.Verify(ctx, expired)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
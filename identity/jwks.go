@@ -0,0 +1,227 @@
+package identity
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mbaxamb3/nusli/logging"
+)
+
+const (
+	// keyRefreshInterval is how long fetched keys are used before the key set is fetched again
+	keyRefreshInterval = 10 * time.Minute
+	// keyRefetchInterval is the least time between fetches, so tokens with made-up key IDs
+	// can't make us hammer the issuer
+	keyRefetchInterval = 30 * time.Second
+	// Failed fetches are retried after a backoff that doubles from keyMinBackoff up to keyMaxBackoff
+	keyMinBackoff = 5 * time.Second
+	keyMaxBackoff = 5 * time.Minute
+)
+
+// httpClient fetches discovery documents and key sets
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// KeySet is a JSON Web Key Set fetched from a URL. Only RSA signing keys are kept. Keys are
+// fetched again when they get old or a token names a key that isn't known yet, which is how
+// issuers roll out new keys. Fetches are rate limited and back off while the URL fails;
+// until a fetch succeeds the previous keys stay in use.
+type KeySet struct {
+	url    string
+	client *http.Client
+
+	refetchInterval time.Duration // keyRefetchInterval, shortened in tests
+
+	fetching sync.Mutex // Held while fetching, so concurrent misses cause one fetch
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey // By kid
+	fetched   time.Time                 // Last successful fetch
+	nextFetch time.Time                 // No fetches are attempted before this
+	backoff   time.Duration             // Wait after the next failed fetch
+}
+
+// NewKeySet creates a key set read from url. Keys are fetched on first use.
+func NewKeySet(url string, client *http.Client) *KeySet {
+	if client == nil {
+		client = httpClient
+	}
+	return &KeySet{url: url, client: client, refetchInterval: keyRefetchInterval}
+}
+
+// URL returns where the key set is fetched from
+func (s *KeySet) URL() string {
+	return s.url
+}
+
+// Key returns the key with the given ID. The key set is fetched again first when the cached
+// copy is older than keyRefreshInterval or doesn't have the key, unless a fetch was tried
+// too recently.
+func (s *KeySet) Key(ctx context.Context, kid string) (*rsa.PublicKey, bool) {
+	key, ok, stale := s.cached(kid)
+	if ok && !stale {
+		return key, true
+	}
+
+	s.fetching.Lock()
+	// Another request may have fetched the keys while we waited
+	key, ok, stale = s.cached(kid)
+	if (!ok || stale) && s.fetchAllowed() {
+		if err := s.Refresh(ctx); err != nil {
+			logging.FromContext(ctx).Warn("Failed to refresh JWKS", "url", s.url, "error", err)
+		}
+		key, ok, _ = s.cached(kid)
+	}
+	s.fetching.Unlock()
+
+	if !ok {
+		keyMisses.Add(1)
+	}
+	return key, ok
+}
+
+// Check reports whether the key set has keys to verify tokens with. Keys that are due for a
+// refresh still count; a key set without keys is fetched first if the rate limit allows.
+func (s *KeySet) Check(ctx context.Context) error {
+	if s.keyCount() > 0 {
+		return nil
+	}
+
+	s.fetching.Lock()
+	defer s.fetching.Unlock()
+	if s.keyCount() == 0 && s.fetchAllowed() {
+		if err := s.Refresh(ctx); err != nil {
+			return fmt.Errorf("no keys from %s: %w", s.url, err)
+		}
+	}
+	if s.keyCount() == 0 {
+		return fmt.Errorf("no keys from %s", s.url)
+	}
+	return nil
+}
+
+// keyCount returns the number of cached keys
+func (s *KeySet) keyCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.keys)
+}
+
+// cached looks up a key without fetching and reports whether the keys are due for a refresh
+func (s *KeySet) cached(kid string) (*rsa.PublicKey, bool, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[kid]
+	return key, ok, time.Since(s.fetched) > keyRefreshInterval
+}
+
+// fetchAllowed reports whether the rate limit and backoff allow a fetch now
+func (s *KeySet) fetchAllowed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !time.Now().Before(s.nextFetch)
+}
+
+// Refresh fetches the key set and replaces the cached keys. It isn't rate limited itself,
+// but counts towards the limits Key applies.
+func (s *KeySet) Refresh(ctx context.Context) error {
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		keyFetches.Add("error", 1)
+		if s.backoff < keyMinBackoff {
+			s.backoff = keyMinBackoff
+		}
+		s.nextFetch = time.Now().Add(s.backoff)
+		s.backoff = min(s.backoff*2, keyMaxBackoff)
+		return err
+	}
+
+	keyFetches.Add("ok", 1)
+	s.keys = keys
+	s.fetched = time.Now()
+	s.nextFetch = s.fetched.Add(s.refetchInterval)
+	s.backoff = 0
+	return nil
+}
+
+// fetch downloads and parses the key set
+func (s *KeySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS: %s", resp.Status)
+	}
+
+	var body struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range body.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			logging.FromContext(ctx).Warn("Skipping JWKS key", "url", s.url, "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("JWKS has no usable RSA keys")
+	}
+	return keys, nil
+}
+
+// jsonWebKey is an entry of a JWKS document
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// rsaPublicKey decodes the modulus and exponent of an RSA key
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+	if len(n) == 0 || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid key size")
+	}
+
+	var exponent int
+	for _, b := range e {
+		exponent = exponent<<8 + int(b)
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exponent}, nil
+}
@@ -0,0 +1,143 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// LocalIssuer is the issuer and audience of tokens signed by a LocalProvider
+const LocalIssuer = "nusli-local"
+
+// LocalProvider signs and verifies tokens with its own RSA key. It's meant for development
+// and integration tests: any caller that can reach Issue can sign in as anyone.
+type LocalProvider struct {
+	key      *rsa.PrivateKey
+	kid      string
+	verifier verifier
+}
+
+// NewLocalProvider creates a provider with the key in keyFile. The file is created with a
+// new key if it doesn't exist; with an empty keyFile the key only lives as long as the
+// process.
+func NewLocalProvider(keyFile string) (*LocalProvider, error) {
+	key, err := loadOrGenerateKey(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+
+	return &LocalProvider{
+		key: key,
+		kid: hex.EncodeToString(sum[:8]),
+		verifier: verifier{
+			issuer:      LocalIssuer,
+			audiences:   []string{LocalIssuer},
+			groupsClaim: "groups",
+		},
+	}, nil
+}
+
+// loadOrGenerateKey reads a PEM encoded RSA key, generating and saving one when the file
+// doesn't exist
+func loadOrGenerateKey(keyFile string) (*rsa.PrivateKey, error) {
+	if keyFile != "" {
+		data, err := os.ReadFile(keyFile)
+		if err == nil {
+			return parsePrivateKey(data)
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read local auth key: %w", err)
+		}
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate local auth key: %w", err)
+	}
+
+	if keyFile != "" {
+		data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+		if err := os.MkdirAll(filepath.Dir(keyFile), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to save local auth key: %w", err)
+		}
+		if err := os.WriteFile(keyFile, data, 0o600); err != nil {
+			return nil, fmt.Errorf("failed to save local auth key: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// parsePrivateKey decodes a PKCS #1 or PKCS #8 RSA private key
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("local auth key is not PEM encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse local auth key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("local auth key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Name identifies the provider in logs
+func (p *LocalProvider) Name() string {
+	return ProviderLocal
+}
+
+// Issue signs a token for the given user that is valid for ttl
+func (p *LocalProvider) Issue(subject, email string, groups []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss": LocalIssuer,
+		"aud": LocalIssuer,
+		"sub": subject,
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	}
+	if email != "" {
+		claims["email"] = email
+	}
+	if len(groups) > 0 {
+		claims["groups"] = groups
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = p.kid
+	return token.SignedString(p.key)
+}
+
+// Verify checks a token signed by Issue
+func (p *LocalProvider) Verify(ctx context.Context, token string) (Claims, error) {
+	return p.verifier.verify(token, func(kid string) (*rsa.PublicKey, bool) {
+		if kid != p.kid {
+			return nil, false
+		}
+		return &p.key.PublicKey, true
+	})
+}
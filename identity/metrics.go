@@ -0,0 +1,41 @@
+package identity
+
+import (
+	"errors"
+	"expvar"
+)
+
+// Verification metrics, published with expvar under "identity"
+var (
+	verifications = new(expvar.Map).Init() // Verified tokens by outcome, see outcome
+	keyFetches    = new(expvar.Map).Init() // Key set fetches, "ok" or "error"
+	keyMisses     = new(expvar.Int)        // Tokens naming a key that couldn't be found
+)
+
+func init() {
+	metrics := expvar.NewMap("identity")
+	metrics.Set("verifications", verifications)
+	metrics.Set("key_fetches", keyFetches)
+	metrics.Set("key_misses", keyMisses)
+}
+
+// outcome names the result of a verification for the metrics
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, ErrExpired):
+		return "expired"
+	case errors.Is(err, ErrNotYetValid):
+		return "not_yet_valid"
+	case errors.Is(err, ErrWrongIssuer):
+		return "wrong_issuer"
+	case errors.Is(err, ErrWrongAudience):
+		return "wrong_audience"
+	case errors.Is(err, ErrWrongTokenUse):
+		return "wrong_token_use"
+	case errors.Is(err, ErrUnknownKey):
+		return "unknown_key"
+	}
+	return "invalid"
+}
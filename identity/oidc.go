@@ -0,0 +1,250 @@
+package identity
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// OIDCProvider verifies tokens issued by an OpenID Connect provider. Its signing keys are
+// found through the issuer's discovery document; further key sets can be trusted as well,
+// e.g. while an issuer rotates to a new key set URL.
+type OIDCProvider struct {
+	name     string
+	verifier verifier
+	keySets  []*KeySet
+}
+
+// discoveryDocument holds the fields of an OpenID Connect discovery document that are used
+type discoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// NewOIDCProvider discovers the issuer in cfg and loads its keys. name identifies the
+// provider in logs.
+func NewOIDCProvider(ctx context.Context, name string, cfg Config) (*OIDCProvider, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("OIDC provider needs an issuer")
+	}
+	if len(cfg.Audiences) == 0 {
+		return nil, errors.New("OIDC provider needs at least one audience")
+	}
+	issuer := strings.TrimSuffix(cfg.Issuer, "/")
+
+	doc, err := discover(ctx, httpClient, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &OIDCProvider{
+		name: name,
+		verifier: verifier{
+			issuer:      doc.Issuer,
+			audiences:   cfg.Audiences,
+			groupsClaim: cfg.GroupsClaim,
+			tokenUse:    cfg.TokenUse,
+			leeway:      cfg.Leeway,
+		},
+	}
+	for _, url := range append([]string{doc.JWKSURI}, cfg.JWKSURLs...) {
+		keySet := NewKeySet(url, httpClient)
+		if err := keySet.Refresh(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load keys from %s: %w", url, err)
+		}
+		p.keySets = append(p.keySets, keySet)
+	}
+	return p, nil
+}
+
+// discover reads the discovery document of an issuer and checks that it belongs to it
+func discover(ctx context.Context, client *http.Client, issuer string) (discoveryDocument, error) {
+	url := issuer + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return discoveryDocument{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return discoveryDocument{}, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return discoveryDocument{}, fmt.Errorf("failed to fetch discovery document: %s", resp.Status)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return discoveryDocument{}, fmt.Errorf("failed to parse discovery document: %w", err)
+	}
+	if doc.Issuer != issuer {
+		return discoveryDocument{}, fmt.Errorf("discovery document is for issuer %q, expected %q", doc.Issuer, issuer)
+	}
+	if doc.JWKSURI == "" {
+		return discoveryDocument{}, errors.New("discovery document has no jwks_uri")
+	}
+	return doc, nil
+}
+
+// Name identifies the provider in logs
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+// Verify checks a token against the issuer's keys
+func (p *OIDCProvider) Verify(ctx context.Context, token string) (Claims, error) {
+	return p.verifier.verify(token, func(kid string) (*rsa.PublicKey, bool) {
+		for _, keySet := range p.keySets {
+			if key, ok := keySet.Key(ctx, kid); ok {
+				return key, true
+			}
+		}
+		return nil, false
+	})
+}
+
+// CheckKeys reports whether every key set has keys, fetching those that have none yet
+func (p *OIDCProvider) CheckKeys(ctx context.Context) error {
+	for _, keySet := range p.keySets {
+		if err := keySet.Check(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifier holds the checks shared by all providers that verify RS256 JWTs
+type verifier struct {
+	issuer      string
+	audiences   []string
+	groupsClaim string
+	tokenUse    string
+	leeway      time.Duration
+}
+
+// verify parses token, checks its signature with the key lookup returns for its kid and
+// validates the registered claims. The outcome is counted in the metrics.
+func (v verifier) verify(token string, lookup func(kid string) (*rsa.PublicKey, bool)) (Claims, error) {
+	claims, err := v.verifyClaims(token, lookup)
+	verifications.Add(outcome(err), 1)
+	return claims, err
+}
+
+// verifyClaims does the work of verify
+func (v verifier) verifyClaims(token string, lookup func(kid string) (*rsa.PublicKey, bool)) (Claims, error) {
+	parser := jwt.Parser{
+		ValidMethods:         []string{jwt.SigningMethodRS256.Alg()},
+		SkipClaimsValidation: true, // Validated below, with leeway
+	}
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, ok := t.Header["kid"].(string)
+		if !ok || kid == "" {
+			return nil, errors.New("missing kid in token header")
+		}
+		key, ok := lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+		}
+		return key, nil
+	})
+	if err != nil {
+		// Keep reasons from the key lookup inspectable through the jwt error
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if err := v.validate(claims, time.Now()); err != nil {
+		return Claims{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	exp, _ := claims["exp"].(float64)
+	return Claims{
+		Subject: sub,
+		Email:   email,
+		Groups:  stringList(claims[v.groupsClaim]),
+		Issuer:  v.issuer,
+		Expiry:  time.Unix(int64(exp), 0),
+	}, nil
+}
+
+// validate checks the issuer, subject, token use, audience and lifetime of a token.
+// Timestamps may be off by the leeway either way.
+func (v verifier) validate(claims jwt.MapClaims, now time.Time) error {
+	leeway := v.leeway
+	if leeway == 0 {
+		leeway = DefaultLeeway
+	}
+
+	if iss, _ := claims["iss"].(string); iss != v.issuer {
+		return fmt.Errorf("%w: %q", ErrWrongIssuer, iss)
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return errors.New("missing subject")
+	}
+	if v.tokenUse != "" {
+		if use, _ := claims["token_use"].(string); use != v.tokenUse {
+			return fmt.Errorf("%w: %q, expected %q", ErrWrongTokenUse, use, v.tokenUse)
+		}
+	}
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("missing expiry")
+	}
+	if now.Add(-leeway).After(time.Unix(int64(exp), 0)) {
+		return ErrExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(leeway).Before(time.Unix(int64(nbf), 0)) {
+		return ErrNotYetValid
+	}
+
+	// A verifier without audiences accepts no tokens rather than tokens for any client
+	if !v.audienceAccepted(claims) {
+		return ErrWrongAudience
+	}
+	return nil
+}
+
+// audienceAccepted reports whether the token's aud claim, or the client_id claim Cognito
+// puts in access tokens instead, names one of the accepted audiences
+func (v verifier) audienceAccepted(claims jwt.MapClaims) bool {
+	candidates := stringList(claims["aud"])
+	if clientID, ok := claims["client_id"].(string); ok {
+		candidates = append(candidates, clientID)
+	}
+	for _, candidate := range candidates {
+		for _, audience := range v.audiences {
+			if candidate == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stringList reads a claim that is either a string or a list of strings
+func stringList(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		list := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
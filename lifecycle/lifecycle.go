@@ -0,0 +1,154 @@
+// Package lifecycle runs the HTTP server together with the background jobs its requests
+// start, and shuts both down gracefully: the server stops taking requests, in-flight
+// requests and jobs get until a deadline to finish, and whatever still runs then is cancelled.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// jobCancelGrace is how long cancelled jobs get to return after the drain deadline
+const jobCancelGrace = 5 * time.Second
+
+// ErrDrainTimeout is returned by Run when requests or jobs were still running at the deadline
+var ErrDrainTimeout = errors.New("shutdown deadline passed before requests and jobs finished")
+
+// Manager tracks background jobs and whether the server is ready for traffic. Liveness only
+// means the process is serving; readiness ends as soon as shutdown begins, so load balancers
+// stop sending requests while the server drains.
+type Manager struct {
+	jobCtx     context.Context // Cancelled when the drain deadline passes
+	cancelJobs context.CancelFunc
+	stopping   chan struct{} // Closed when shutdown begins
+	stopOnce   sync.Once
+	ready      atomic.Bool
+
+	mu     sync.Mutex
+	closed bool // No jobs are started once the server has stopped
+	jobs   sync.WaitGroup
+}
+
+// New creates a manager. It isn't ready until Run is listening.
+func New() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{jobCtx: ctx, cancelJobs: cancel, stopping: make(chan struct{})}
+}
+
+// Go runs fn as a background job that outlives the request starting it. Its context is
+// cancelled when the drain deadline passes. Go reports false, without running fn, when the
+// server has already stopped.
+func (m *Manager) Go(fn func(ctx context.Context)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return false
+	}
+	m.jobs.Add(1)
+	go func() {
+		defer m.jobs.Done()
+		fn(m.jobCtx)
+	}()
+	return true
+}
+
+// Stopping returns a channel that is closed when shutdown begins. Long-lived responses,
+// such as event streams, should end then instead of holding up the drain.
+func (m *Manager) Stopping() <-chan struct{} {
+	return m.stopping
+}
+
+// Ready reports whether the server is listening and not shutting down
+func (m *Manager) Ready() bool {
+	return m.ready.Load()
+}
+
+// Run serves srv on its address until ctx is done, then shuts down: readiness ends, the
+// server stops accepting connections and waits for in-flight requests, then for background
+// jobs, all within drainTimeout. Requests and jobs still running at the deadline are cut off
+// and ErrDrainTimeout is returned.
+func (m *Manager) Run(ctx context.Context, srv *http.Server, drainTimeout time.Duration) error {
+	listener, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	return m.Serve(ctx, srv, listener, drainTimeout)
+}
+
+// Serve is Run with a listener that is already open
+func (m *Manager) Serve(ctx context.Context, srv *http.Server, listener net.Listener, drainTimeout time.Duration) error {
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.Serve(listener)
+	}()
+	m.ready.Store(true)
+
+	select {
+	case err := <-served:
+		// The server failed on its own; there are no requests left to drain
+		m.stop()
+		m.closeJobs()
+		m.cancelJobs()
+		return err
+	case <-ctx.Done():
+	}
+	return m.shutdown(srv, drainTimeout)
+}
+
+// shutdown drains requests and jobs within drainTimeout
+func (m *Manager) shutdown(srv *http.Server, drainTimeout time.Duration) error {
+	m.stop()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	var result error
+	if err := srv.Shutdown(drainCtx); err != nil {
+		srv.Close()
+		result = fmt.Errorf("%w: requests: %w", ErrDrainTimeout, err)
+	}
+
+	// Requests may start jobs until they are done, so jobs are only closed now
+	m.closeJobs()
+	done := make(chan struct{})
+	go func() {
+		m.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		m.cancelJobs()
+		return result
+	case <-drainCtx.Done():
+	}
+
+	m.cancelJobs()
+	select {
+	case <-done:
+	case <-time.After(jobCancelGrace):
+	}
+	if result == nil {
+		result = fmt.Errorf("%w: background jobs were cancelled", ErrDrainTimeout)
+	}
+	return result
+}
+
+// stop ends readiness and tells long-lived responses to finish
+func (m *Manager) stop() {
+	m.ready.Store(false)
+	m.stopOnce.Do(func() { close(m.stopping) })
+}
+
+// closeJobs stops Go from starting jobs
+func (m *Manager) closeJobs() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+}
@@ -0,0 +1,106 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve runs m with handler on a free port and returns the base URL, a function that starts
+// the shutdown and the channel Serve's result arrives on
+func serve(t *testing.T, m *Manager, handler http.Handler, drainTimeout time.Duration) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	result := make(chan error, 1)
+	go func() {
+		result <- m.Serve(ctx, &http.Server{Handler: handler}, listener, drainTimeout)
+	}()
+	require.Eventually(t, m.Ready, time.Second, 5*time.Millisecond)
+	return "http://" + listener.Addr().String(), cancel, result
+}
+
+func TestDrainsRequestsAndJobs(t *testing.T) {
+	m := New()
+	requestStarted := make(chan struct{})
+	release := make(chan struct{})
+	jobFinished := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestStarted)
+		<-release
+		// Jobs may still be started while the request drains
+		assert.True(t, m.Go(func(ctx context.Context) {
+			time.Sleep(50 * time.Millisecond)
+			assert.NoError(t, ctx.Err())
+			close(jobFinished)
+		}))
+		io.WriteString(w, "done")
+	})
+	url, shutdown, result := serve(t, m, handler, 5*time.Second)
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+	<-requestStarted
+
+	shutdown()
+	require.Eventually(t, func() bool { return !m.Ready() }, time.Second, 5*time.Millisecond)
+	select {
+	case <-m.Stopping():
+	default:
+		t.Fatal("Stopping wasn't closed when shutdown began")
+	}
+
+	close(release)
+	require.Equal(t, "done", <-response)
+	require.NoError(t, <-result)
+	<-jobFinished
+
+	require.False(t, m.Go(func(context.Context) {}), "jobs started after shutdown")
+}
+
+func TestDrainTimeoutCancelsJobs(t *testing.T) {
+	m := New()
+	cancelled := make(chan struct{})
+	require.True(t, m.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	}))
+
+	_, shutdown, result := serve(t, m, http.NotFoundHandler(), 50*time.Millisecond)
+	shutdown()
+
+	err := <-result
+	require.True(t, errors.Is(err, ErrDrainTimeout), "got %v", err)
+	<-cancelled
+}
+
+func TestRunReportsListenErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	m := New()
+	err = m.Run(context.Background(), &http.Server{Addr: listener.Addr().String()}, time.Second)
+	require.Error(t, err)
+	require.False(t, m.Ready())
+}
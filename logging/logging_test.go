@@ -0,0 +1,41 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "warn")
+	require.NoError(t, err)
+
+	logger.Info("dropped")
+	logger.Warn("kept", "datasource_id", 7)
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	require.Equal(t, "kept", record["msg"])
+	require.Equal(t, float64(7), record["datasource_id"])
+
+	_, err = New(&buf, "xml", "info")
+	require.Error(t, err)
+	_, err = New(&buf, "text", "loud")
+	require.Error(t, err)
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	require.Same(t, slog.Default(), FromContext(ctx))
+	require.Empty(t, RequestID(ctx))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	ctx = WithRequestID(WithLogger(ctx, logger), "req-1")
+	require.Same(t, logger, FromContext(ctx))
+	require.Equal(t, "req-1", RequestID(ctx))
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"expvar"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	_ "github.com/mbaxamb3/nusli/identity" // Publishes the "identity" expvar map
+)
+
+// identityCollector exposes the token verification counters the identity package publishes
+// with expvar
+type identityCollector struct {
+	verifications *prometheus.Desc
+	keyFetches    *prometheus.Desc
+	keyMisses     *prometheus.Desc
+}
+
+func newIdentityCollector() identityCollector {
+	return identityCollector{
+		verifications: prometheus.NewDesc(prometheus.BuildFQName(namespace, "identity", "verifications_total"),
+			"Access token verifications by outcome.", []string{"outcome"}, nil),
+		keyFetches: prometheus.NewDesc(prometheus.BuildFQName(namespace, "identity", "key_fetches_total"),
+			"Signing key set fetches by result.", []string{"result"}, nil),
+		keyMisses: prometheus.NewDesc(prometheus.BuildFQName(namespace, "identity", "key_misses_total"),
+			"Tokens naming a signing key that couldn't be found.", nil, nil),
+	}
+}
+
+func (c identityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.verifications
+	ch <- c.keyFetches
+	ch <- c.keyMisses
+}
+
+func (c identityCollector) Collect(ch chan<- prometheus.Metric) {
+	published, ok := expvar.Get("identity").(*expvar.Map)
+	if !ok {
+		return
+	}
+	collectCounts(ch, c.verifications, published.Get("verifications"))
+	collectCounts(ch, c.keyFetches, published.Get("key_fetches"))
+	if misses, ok := published.Get("key_misses").(*expvar.Int); ok {
+		ch <- prometheus.MustNewConstMetric(c.keyMisses, prometheus.CounterValue, float64(misses.Value()))
+	}
+}
+
+// collectCounts sends the entries of an expvar map of counts as one counter per key
+func collectCounts(ch chan<- prometheus.Metric, desc *prometheus.Desc, counts expvar.Var) {
+	m, ok := counts.(*expvar.Map)
+	if !ok {
+		return
+	}
+	m.Do(func(kv expvar.KeyValue) {
+		if count, ok := kv.Value.(*expvar.Int); ok {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(count.Value()), kv.Key)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+// Package metrics defines the Prometheus metrics of the server: HTTP requests, database
+// queries, datasource processing and token verification. They are served by Handler in the
+// Prometheus exposition format, on an internal listener separate from the API.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "nusli"
+
+// registry holds the metrics of this package and the Go runtime and process metrics
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to serve HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time to run database queries by query name.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"query"})
+	dbQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Database queries that failed, by query name.",
+	}, []string{"query"})
+
+	processingRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "datasource_processing_total",
+		Help:      "Datasource processing runs by source type and outcome.",
+	}, []string{"source_type", "outcome"})
+	processingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "datasource_processing_duration_seconds",
+		Help:      "Time to process datasources by source type.",
+		Buckets:   []float64{.1, .5, 1, 5, 10, 30, 60, 120, 300, 600, 1200},
+	}, []string{"source_type"})
+	paragraphsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "paragraphs_created_total",
+		Help:      "Paragraphs saved by datasource processing, by source type.",
+	}, []string{"source_type"})
+	crawlPages = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "crawl_pages_fetched",
+		Help:      "Pages fetched per website crawl.",
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000},
+	})
+	dedupItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dedup_items_total",
+		Help:      "Content items checked by the scrapers' final de-duplication, by source type.",
+	}, []string{"source_type"})
+	dedupDuplicates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dedup_duplicates_total",
+		Help:      "Content items removed as duplicates by the scrapers' final de-duplication, by source type.",
+	}, []string{"source_type"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration,
+		dbQueryDuration, dbQueryErrors,
+		processingRuns, processingDuration, paragraphsCreated, crawlPages, dedupItems, dedupDuplicates,
+		newIdentityCollector(),
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves the metrics at /metrics on listener until ctx is done. The metrics name
+// routes, queries and process internals, so the listener should only be reachable by the
+// Prometheus server, never through the public API address.
+func Serve(ctx context.Context, listener net.Listener) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	errs := make(chan error, 1)
+	go func() { errs <- srv.Serve(listener) }()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// ObserveRequest records a served HTTP request. route is the route pattern, so paths with
+// IDs don't each get their own series; requests that matched no route pass "".
+func ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ObserveQuery records a database query
+func ObserveQuery(query string, duration time.Duration, err error) {
+	dbQueryDuration.WithLabelValues(query).Observe(duration.Seconds())
+	if err != nil {
+		dbQueryErrors.WithLabelValues(query).Inc()
+	}
+}
+
+// ObserveProcessing records a datasource processing run and the paragraphs it saved
+func ObserveProcessing(sourceType string, duration time.Duration, paragraphs int, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "failed"
+	}
+	processingRuns.WithLabelValues(sourceType, outcome).Inc()
+	processingDuration.WithLabelValues(sourceType).Observe(duration.Seconds())
+	paragraphsCreated.WithLabelValues(sourceType).Add(float64(paragraphs))
+}
+
+// ObserveCrawl records the pages fetched by a website crawl
+func ObserveCrawl(pagesFetched int) {
+	crawlPages.Observe(float64(pagesFetched))
+}
+
+// ObserveDedup records a de-duplication pass that checked items and removed duplicates of them
+func ObserveDedup(sourceType string, items, duplicates int) {
+	dedupItems.WithLabelValues(sourceType).Add(float64(items))
+	dedupDuplicates.WithLabelValues(sourceType).Add(float64(duplicates))
+}
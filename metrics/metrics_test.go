@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// scrape returns what Handler serves
+func scrape(t *testing.T) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, 200, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestHandler(t *testing.T) {
+	ObserveRequest("GET", "/api/v1/companies/:id", 200, 20*time.Millisecond)
+	ObserveRequest("GET", "", 404, time.Millisecond)
+	ObserveQuery("GetCompany", 2*time.Millisecond, nil)
+	ObserveQuery("GetCompany", time.Millisecond, errors.New("connection reset"))
+	ObserveProcessing("website", time.Minute, 12, nil)
+	ObserveProcessing("email", time.Second, 0, errors.New("unreadable mbox"))
+	ObserveCrawl(8)
+	ObserveDedup("website", 10, 3)
+
+	body := scrape(t)
+	for _, want := range []string{
+		`nusli_http_requests_total{method="GET",route="/api/v1/companies/:id",status="200"} 1`,
+		`nusli_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`nusli_db_query_duration_seconds_count{query="GetCompany"} 2`,
+		`nusli_db_query_errors_total{query="GetCompany"} 1`,
+		`nusli_datasource_processing_total{outcome="ok",source_type="website"} 1`,
+		`nusli_datasource_processing_total{outcome="failed",source_type="email"} 1`,
+		`nusli_paragraphs_created_total{source_type="website"} 12`,
+		`nusli_crawl_pages_fetched_sum 8`,
+		`nusli_dedup_items_total{source_type="website"} 10`,
+		`nusli_dedup_duplicates_total{source_type="website"} 3`,
+		`nusli_identity_key_misses_total`,
+		`go_goroutines`,
+	} {
+		require.Contains(t, body, want)
+	}
+}
+
+func TestServe(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- Serve(ctx, listener) }()
+
+	res, err := http.Get("http://" + listener.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Contains(t, string(body), "go_goroutines")
+
+	// Only /metrics is served
+	res, err = http.Get("http://" + listener.Addr().String() + "/api/v1/companies")
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	cancel()
+	require.NoError(t, <-served)
+}
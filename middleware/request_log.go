@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mbaxamb3/nusli/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits request IDs taken from clients, so they can't flood the logs
+const maxRequestIDLength = 128
+
+// RequestLogger gives every request an ID, taken from the X-Request-ID header when a client
+// or proxy sent a usable one, and returns it in the response. The request context carries the
+// ID and a logger tagged with it and the trace ID, for handlers and the code they call. Each
+// request is logged once it's done.
+func RequestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+
+		id := ctx.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		ctx.Header(RequestIDHeader, id)
+		ctx.Set("request_id", id)
+
+		requestLogger := logger.With("request_id", id)
+		if span := trace.SpanFromContext(ctx.Request.Context()); span.SpanContext().IsValid() {
+			span.SetAttributes(attribute.String("request.id", id))
+			requestLogger = requestLogger.With("trace_id", span.SpanContext().TraceID().String())
+		}
+		requestCtx := logging.WithLogger(logging.WithRequestID(ctx.Request.Context(), id), requestLogger)
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case strings.HasPrefix(ctx.Request.URL.Path, "/health") && status < 400:
+			// Probes come every few seconds
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.String("route", ctx.FullPath()),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("bytes", ctx.Writer.Size()),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if sub := ctx.GetString("cognito_sub"); sub != "" {
+			attrs = append(attrs, slog.String("user", sub))
+		}
+		if len(ctx.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", ctx.Errors.String()))
+		}
+		requestLogger.LogAttrs(requestCtx, level, "Request", attrs...)
+	}
+}
+
+// validRequestID reports whether an ID sent by a client is safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
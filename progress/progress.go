@@ -0,0 +1,58 @@
+// Package progress defines the structured events scrapers emit while they work,
+// so callers can show live progress instead of reading console output.
+package progress
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// EventType identifies what happened during a scraping run
+type EventType string
+
+const (
+	EventStarted        EventType = "started"         // A run began
+	EventPageDiscovered EventType = "page_discovered" // A link was queued for visiting
+	EventPageFetched    EventType = "page_fetched"    // A page or document was downloaded or opened
+	EventItemsExtracted EventType = "items_extracted" // Content items were extracted from a page or document
+	EventError          EventType = "error"           // Something failed; the run may continue
+	EventCompleted      EventType = "completed"       // A run finished successfully
+	EventFailed         EventType = "failed"          // A run stopped because of an error
+)
+
+// Event is a single progress update
+type Event struct {
+	Type    EventType `json:"type"`
+	URL     string    `json:"url,omitempty"`     // Page URL, message ID or file name the event is about; never a local path
+	Depth   int       `json:"depth,omitempty"`   // Crawl depth of a discovered page
+	Count   int       `json:"count,omitempty"`   // Number of items extracted, bytes fetched, etc.
+	Message string    `json:"message,omitempty"` // Human readable detail, e.g. an error message
+	Time    time.Time `json:"time"`
+}
+
+// Emit sends an event on ch without blocking. Events are dropped when ch is nil
+// or full, so a slow listener can never stall a scraper; give the channel a buffer.
+func Emit(ch chan<- Event, event Event) {
+	if ch == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	select {
+	case ch <- event:
+	default:
+	}
+}
+
+// ErrorMessage describes err for an error event. Events are shown to users, so the paths of
+// file errors are left out.
+func ErrorMessage(err error) string {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Op + " file: " + pathErr.Err.Error()
+	}
+	return err.Error()
+}
@@ -0,0 +1,502 @@
+package scraper
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Tuning values for the main content extractor
+const (
+	minScoringTextLength  = 25   // Shortest text block that contributes to a candidate's score
+	minParagraphLength    = 25   // Shortest text block kept as a paragraph
+	maxParagraphLinkRatio = 0.5  // Paragraphs with more linked text than this are treated as navigation
+	siblingScoreRatio     = 0.2  // Siblings scoring at least this fraction of the top candidate are kept
+	minSiblingScore       = 10.0 // Absolute floor for keeping a sibling of the top candidate
+	enclosingTextRatio    = 0.75 // Climb to a parent when the candidate holds at least this share of its text
+)
+
+var (
+	// unlikelyCandidates matches class/id values of typical boilerplate regions
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|ad-break|agegate|banner|breadcrumb|combx|comment|community|consent|cookie|disqus|extra|foot|gdpr|header|legends|menu|modal|nav|newsletter|pager|pagination|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tweet|widget`)
+	// maybeCandidate rescues elements that match unlikelyCandidates but often hold content
+	maybeCandidate = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	// positiveClassHint and negativeClassHint adjust candidate scores based on class/id
+	positiveClassHint = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|post|text|blog|story`)
+	negativeClassHint = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+	// displayNone matches inline styles that hide an element
+	displayNone = regexp.MustCompile(`(?i)display\s*:\s*none|visibility\s*:\s*hidden`)
+)
+
+// skippedTags are never part of the readable content
+var skippedTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "iframe": true,
+	"svg": true, "canvas": true, "button": true, "select": true, "input": true,
+	"textarea": true, "form": true, "nav": true, "aside": true, "dialog": true,
+	"object": true, "embed": true, "head": true,
+}
+
+// boilerplateRoles are ARIA landmark roles that never hold the main content
+var boilerplateRoles = map[string]bool{
+	"navigation": true, "banner": true, "contentinfo": true, "complementary": true,
+	"dialog": true, "alertdialog": true, "menu": true, "menubar": true, "search": true,
+}
+
+// blockTags are elements that break the flow of inline text
+var blockTags = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "dd": true,
+	"details": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "h1": true,
+	"h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "header": true,
+	"hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "tbody": true, "td": true,
+	"tfoot": true, "th": true, "thead": true, "tr": true, "ul": true, "body": true,
+	"html": true,
+}
+
+// scoredTags are the text blocks whose length feeds the candidate scores
+var scoredTags = map[string]bool{
+	"p": true, "pre": true, "td": true, "blockquote": true, "li": true, "dd": true,
+}
+
+// ExtractMainContent locates the main content region of a page and splits it into
+// heading/paragraph sections. Navigation menus, cookie banners, footers and other
+// boilerplate regions are dropped, and nested containers never repeat their text.
+func ExtractMainContent(doc *goquery.Selection) []Section {
+	if doc == nil || len(doc.Nodes) == 0 {
+		return nil
+	}
+
+	root := doc.Nodes[0]
+	if root.Type == html.DocumentNode {
+		root = findElement(root, "html")
+		if root == nil {
+			root = doc.Nodes[0]
+		}
+	}
+
+	// Score candidate containers and pick the regions to walk
+	regions := selectContentRegions(root)
+
+	// Walk the regions in document order, grouping paragraphs under headings
+	builder := newSectionBuilder()
+	for _, region := range regions {
+		builder.walk(region)
+	}
+
+	return builder.finish()
+}
+
+// selectContentRegions scores the document and returns the top candidate together
+// with any sibling that scores high enough to belong to the same article
+func selectContentRegions(root *html.Node) []*html.Node {
+	scores := make(map[*html.Node]float64)
+
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && isBoilerplate(n) {
+			return
+		}
+
+		if n.Type == html.ElementNode && (scoredTags[n.Data] || (n.Data == "div" && !hasBlockChildren(n))) {
+			text := cleanText(nodeText(n))
+			if len(text) >= minScoringTextLength {
+				score := 1.0
+				score += float64(strings.Count(text, ","))
+				score += math.Min(math.Floor(float64(len(text))/100), 3)
+
+				if parent := n.Parent; parent != nil && parent.Type == html.ElementNode {
+					if _, ok := scores[parent]; !ok {
+						scores[parent] = initialScore(parent)
+					}
+					scores[parent] += score
+
+					if grandparent := parent.Parent; grandparent != nil && grandparent.Type == html.ElementNode {
+						if _, ok := scores[grandparent]; !ok {
+							scores[grandparent] = initialScore(grandparent)
+						}
+						scores[grandparent] += score / 2
+					}
+				}
+			}
+		}
+
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(root)
+
+	// Scale scores by the amount of non-link text
+	var top *html.Node
+	topScore := math.Inf(-1)
+	for node, score := range scores {
+		score *= 1 - linkDensity(node)
+		scores[node] = score
+		if score > topScore || (score == topScore && top != nil && isBefore(node, top)) {
+			top = node
+			topScore = score
+		}
+	}
+
+	if top == nil {
+		if body := findElement(root, "body"); body != nil {
+			return []*html.Node{body}
+		}
+		return []*html.Node{root}
+	}
+
+	// Climb to enclosing containers that hold little besides the candidate, so
+	// headings and bylines wrapped around the article body are kept
+	for top.Parent != nil && top.Parent.Type == html.ElementNode &&
+		top.Parent.Data != "body" && top.Parent.Data != "html" && !isBoilerplate(top.Parent) {
+		parentLength := len(cleanText(nodeText(top.Parent)))
+		if parentLength == 0 || float64(len(cleanText(nodeText(top))))/float64(parentLength) < enclosingTextRatio {
+			break
+		}
+		top = top.Parent
+		if score, ok := scores[top]; ok && score > topScore {
+			topScore = score
+		}
+	}
+
+	if top.Parent == nil || top.Data == "body" || top.Data == "html" {
+		return []*html.Node{top}
+	}
+
+	// Include siblings that look like part of the same content
+	threshold := math.Max(minSiblingScore, topScore*siblingScoreRatio)
+	var regions []*html.Node
+	for sibling := top.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+		if sibling == top {
+			regions = append(regions, sibling)
+			continue
+		}
+		if sibling.Type != html.ElementNode || isBoilerplate(sibling) {
+			continue
+		}
+		if score, ok := scores[sibling]; ok && score >= threshold {
+			regions = append(regions, sibling)
+		}
+	}
+
+	return regions
+}
+
+// initialScore gives a starting score based on the element's tag and class/id hints
+func initialScore(n *html.Node) float64 {
+	score := 0.0
+	switch n.Data {
+	case "article", "main":
+		score += 10
+	case "div", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	hint := attr(n, "class") + " " + attr(n, "id")
+	if negativeClassHint.MatchString(hint) {
+		score -= 25
+	}
+	if positiveClassHint.MatchString(hint) {
+		score += 25
+	}
+	return score
+}
+
+// isBoilerplate reports whether an element is a navigation, banner or other
+// non-content region that should be skipped entirely
+func isBoilerplate(n *html.Node) bool {
+	if skippedTags[n.Data] {
+		return true
+	}
+
+	if boilerplateRoles[strings.ToLower(attr(n, "role"))] {
+		return true
+	}
+
+	if hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" || displayNone.MatchString(attr(n, "style")) {
+		return true
+	}
+
+	// Page-level headers and footers are boilerplate, article headers are not
+	if (n.Data == "header" || n.Data == "footer") && !hasAncestor(n, "article", "main") {
+		return true
+	}
+
+	if n.Data == "body" || n.Data == "html" || n.Data == "article" || n.Data == "main" {
+		return false
+	}
+
+	hint := attr(n, "class") + " " + attr(n, "id")
+	return unlikelyCandidates.MatchString(hint) && !maybeCandidate.MatchString(hint)
+}
+
+// sectionBuilder accumulates paragraphs under the most recent heading
+type sectionBuilder struct {
+	sections []Section
+	inline   strings.Builder
+}
+
+// newSectionBuilder creates a builder with a default section for content before any heading
+func newSectionBuilder() *sectionBuilder {
+	return &sectionBuilder{
+		sections: []Section{{
+			Title:     "Page Content",
+			Level:     0,
+			Content:   []string{},
+			StartTime: time.Now(),
+		}},
+	}
+}
+
+// walk visits a node in document order, emitting headings and paragraphs
+func (b *sectionBuilder) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.inline.WriteString(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			b.walk(c)
+		}
+		return
+	}
+
+	if isBoilerplate(n) {
+		return
+	}
+
+	if n.Data == "br" {
+		b.inline.WriteString(" ")
+		return
+	}
+
+	// Inline elements simply contribute to the current run of text
+	if !blockTags[n.Data] {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			b.walk(c)
+		}
+		return
+	}
+
+	// A block element ends any run of inline text that preceded it
+	b.flushInline()
+
+	if level := headingLevel(n.Data); level > 0 {
+		b.addHeading(level, cleanText(nodeText(n)))
+		return
+	}
+
+	// Leaf blocks are emitted whole, containers are walked so nested text is seen once
+	if !hasBlockChildren(n) {
+		if linkDensity(n) <= maxParagraphLinkRatio {
+			b.addParagraph(cleanText(nodeText(n)))
+		}
+		return
+	}
+
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.walk(c)
+	}
+	b.flushInline()
+}
+
+// flushInline emits accumulated inline text as a paragraph
+func (b *sectionBuilder) flushInline() {
+	text := cleanText(b.inline.String())
+	b.inline.Reset()
+	b.addParagraph(text)
+}
+
+// addHeading starts a new section
+func (b *sectionBuilder) addHeading(level int, title string) {
+	if title == "" {
+		return
+	}
+	b.sections = append(b.sections, Section{
+		Title:     title,
+		Level:     level,
+		Content:   []string{},
+		StartTime: time.Now(),
+	})
+}
+
+// addParagraph adds text to the current section if it is long enough to be content
+func (b *sectionBuilder) addParagraph(text string) {
+	if len(text) < minParagraphLength {
+		return
+	}
+	current := &b.sections[len(b.sections)-1]
+	current.Content = append(current.Content, text)
+}
+
+// finish returns the sections that received content
+func (b *sectionBuilder) finish() []Section {
+	b.flushInline()
+
+	var sections []Section
+	for _, section := range b.sections {
+		if len(section.Content) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// DOM helpers
+
+// nodeText returns the concatenated text of a node and its descendants,
+// skipping boilerplate elements and separating blocks with whitespace
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if isBoilerplate(n) {
+				return
+			}
+			if n.Data == "br" || blockTags[n.Data] {
+				sb.WriteString(" ")
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return sb.String()
+}
+
+// linkDensity returns the fraction of a node's text that sits inside links
+func linkDensity(n *html.Node) float64 {
+	total := len(cleanText(nodeText(n)))
+	if total == 0 {
+		return 0
+	}
+
+	linked := 0
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "a" {
+			linked += len(cleanText(nodeText(n)))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+
+	return float64(linked) / float64(total)
+}
+
+// hasBlockChildren reports whether any descendant is a block-level element
+func hasBlockChildren(n *html.Node) bool {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || isBoilerplate(c) {
+			continue
+		}
+		if blockTags[c.Data] || hasBlockChildren(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// headingLevel returns 1-6 for heading tags and 0 otherwise
+func headingLevel(tag string) int {
+	if len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6' {
+		return int(tag[1] - '0')
+	}
+	return 0
+}
+
+// hasAncestor reports whether any ancestor has one of the given tags
+func hasAncestor(n *html.Node, tags ...string) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type != html.ElementNode {
+			continue
+		}
+		for _, tag := range tags {
+			if p.Data == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findElement returns the first element with the given tag in document order
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// isBefore reports whether a precedes b in document order
+func isBefore(a, b *html.Node) bool {
+	pathA, pathB := nodePath(a), nodePath(b)
+	for i := 0; i < len(pathA) && i < len(pathB); i++ {
+		if pathA[i] != pathB[i] {
+			return pathA[i] < pathB[i]
+		}
+	}
+	return len(pathA) < len(pathB)
+}
+
+// nodePath returns the child indexes leading from the root to a node
+func nodePath(n *html.Node) []int {
+	var path []int
+	for ; n.Parent != nil; n = n.Parent {
+		index := 0
+		for s := n.PrevSibling; s != nil; s = s.PrevSibling {
+			index++
+		}
+		path = append(path, index)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// attr returns the value of an attribute, or an empty string
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether an attribute is present
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
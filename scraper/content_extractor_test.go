@@ -0,0 +1,124 @@
+package scraper
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// renderSections formats extracted sections as plain text for golden comparisons
+func renderSections(sections []Section) string {
+	var sb strings.Builder
+	for _, section := range sections {
+		sb.WriteString(fmt.Sprintf("## [h%d] %s\n", section.Level, section.Title))
+		for _, paragraph := range section.Content {
+			sb.WriteString(paragraph)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func TestExtractMainContentGolden(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join("testdata", "content", "*.html"))
+	if err != nil {
+		t.Fatalf("Failed to list test pages: %v", err)
+	}
+	if len(pages) == 0 {
+		t.Fatal("No test pages found in testdata/content")
+	}
+
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(name, func(t *testing.T) {
+			file, err := os.Open(page)
+			if err != nil {
+				t.Fatalf("Failed to open %s: %v", page, err)
+			}
+			defer file.Close()
+
+			doc, err := goquery.NewDocumentFromReader(file)
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", page, err)
+			}
+
+			got := renderSections(ExtractMainContent(doc.Selection))
+
+			goldenPath := strings.TrimSuffix(page, ".html") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+					t.Fatalf("Failed to write golden file: %v", err)
+				}
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+			}
+
+			if got != string(want) {
+				t.Errorf("Extracted content does not match %s\n--- got ---\n%s\n--- want ---\n%s", goldenPath, got, want)
+			}
+		})
+	}
+}
+
+func TestExtractMainContentSkipsBoilerplate(t *testing.T) {
+	page := `<html><body>
+		<nav><p>Products, Solutions, Pricing, Blog, Careers, Contact us today</p></nav>
+		<div class="cookie-banner"><p>We use cookies to give you the best experience on our website.</p></div>
+		<article>
+			<h1>Quarterly results</h1>
+			<p>Revenue grew twelve percent year over year, driven by strong demand in the enterprise segment.</p>
+		</article>
+		<footer><p>Copyright Example Corp, all rights reserved, terms and privacy apply.</p></footer>
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Failed to parse page: %v", err)
+	}
+
+	sections := ExtractMainContent(doc.Selection)
+	if len(sections) != 1 {
+		t.Fatalf("Expected 1 section, got %d:\n%s", len(sections), renderSections(sections))
+	}
+
+	rendered := renderSections(sections)
+	for _, unwanted := range []string{"cookies", "Copyright", "Pricing"} {
+		if strings.Contains(rendered, unwanted) {
+			t.Errorf("Boilerplate text %q leaked into extracted content:\n%s", unwanted, rendered)
+		}
+	}
+	if sections[0].Title != "Quarterly results" {
+		t.Errorf("Expected section title %q, got %q", "Quarterly results", sections[0].Title)
+	}
+}
+
+func TestExtractMainContentNoDuplicateNestedText(t *testing.T) {
+	page := `<html><body><div class="content">
+		<div><div><div>Deeply nested text that should appear exactly once in the extracted output.</div></div></div>
+		<div>A second block of text that also lives in a div and should appear exactly once.</div>
+	</div></body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Failed to parse page: %v", err)
+	}
+
+	rendered := renderSections(ExtractMainContent(doc.Selection))
+	if count := strings.Count(rendered, "Deeply nested text"); count != 1 {
+		t.Errorf("Expected nested text once, found %d times:\n%s", count, rendered)
+	}
+	if count := strings.Count(rendered, "A second block"); count != 1 {
+		t.Errorf("Expected sibling text once, found %d times:\n%s", count, rendered)
+	}
+}
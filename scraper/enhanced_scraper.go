@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"github.com/gocolly/colly/v2"
+	"github.com/mbaxamb3/nusli/progress"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ContentItem represents a piece of content with title and paragraph
@@ -23,9 +25,14 @@ type ContentItem struct {
 // EnhancedScraper extends the basic Scraper functionality for paragraph extraction
 type EnhancedScraper struct {
 	*Scraper
-	ContentItems []ContentItem
-	seenContent  map[string]bool // Track already seen content by hash
-	mu           sync.Mutex
+	ContentItems      []ContentItem
+	Facts             []Fact          // Structured facts such as contact details and schema.org data
+	Selection         *PageSelection  // Pages to extract; nil extracts every crawled page
+	ItemsBeforeDedup  int             // Content items checked by the final de-duplication
+	DuplicatesRemoved int             // Content items it removed as duplicates
+	seenContent       map[string]bool // Track already seen content by hash
+	seenFacts         map[string]bool // Track already seen facts by hash
+	mu                sync.Mutex
 }
 
 // NewEnhancedScraper creates a new enhanced scraper instance
@@ -38,7 +45,9 @@ func NewEnhancedScraper(baseURL string, maxDepth int) (*EnhancedScraper, error)
 	return &EnhancedScraper{
 		Scraper:      scraper,
 		ContentItems: []ContentItem{},
+		Facts:        []Fact{},
 		seenContent:  make(map[string]bool),
+		seenFacts:    make(map[string]bool),
 	}, nil
 }
 
@@ -50,33 +59,34 @@ func (es *EnhancedScraper) ExtractTitleParagraphPairs() error {
 			return fmt.Errorf("error gathering links: %w", err)
 		}
 	}
+	es.applySelection()
+
+	ctx, span := es.startStage("extract content")
+	defer span.End()
 
 	// Now extract content from all visited links
 	baseDomain := getDomain(es.BaseURL)
 	wwwDomain := "www." + baseDomain
 
-	c := colly.NewCollector(
+	c := newCollector(ctx,
 		colly.AllowedDomains(baseDomain, wwwDomain),
 		colly.MaxDepth(es.MaxDepth),
 	)
 
-	// Track what URLs have been processed to avoid re-processing
-	processedURLs := make(map[string]bool)
-
-	// Define extractors for different types of content sections
-	c.OnHTML("article, section, div.content, div.main, .content-area", func(e *colly.HTMLElement) {
-		// Get the page URL
+	// Extract the main content and structured facts of each page once the whole document is parsed
+	c.OnHTML("html", func(e *colly.HTMLElement) {
 		pageURL := e.Request.URL.String()
+		added := es.addSections(pageURL, ExtractMainContent(e.DOM))
+		es.addFacts(ExtractFacts(e.DOM, pageURL))
+		progress.Emit(es.Progress, progress.Event{Type: progress.EventItemsExtracted, URL: pageURL, Count: added})
+	})
 
-		// Skip if this specific selector on this URL has already been processed
-		selectorPath := pageURL + "#" + e.Name + "-" + e.Attr("class") + "-" + e.Attr("id")
-		if processedURLs[selectorPath] {
-			return
-		}
-		processedURLs[selectorPath] = true
+	c.OnResponse(func(r *colly.Response) {
+		es.countFetch()
+	})
 
-		// Use the improved section extraction
-		extractImprovedContentSections(e, pageURL, es)
+	c.OnError(func(r *colly.Response, err error) {
+		progress.Emit(es.Progress, progress.Event{Type: progress.EventError, URL: r.Request.URL.String(), Message: err.Error()})
 	})
 
 	// Visit each page in our link tree
@@ -84,15 +94,16 @@ func (es *EnhancedScraper) ExtractTitleParagraphPairs() error {
 	for link := range es.LinksToVisit {
 		err := c.Visit(link)
 		if err != nil {
-			fmt.Printf("Error visiting %s: %v\n", link, err)
+			es.logger().Warn("Failed to visit page", "url", link, "error", err)
 			// Continue with other links
 		}
 		visitCount++
 
-		// Debug info
 		if visitCount%5 == 0 {
-			fmt.Printf("Visited %d links, found %d content items so far\n",
-				visitCount, len(es.ContentItems))
+			es.mu.Lock()
+			items := len(es.ContentItems)
+			es.mu.Unlock()
+			es.logger().Debug("Extraction progress", "pages_visited", visitCount, "content_items", items)
 		}
 	}
 
@@ -100,6 +111,10 @@ func (es *EnhancedScraper) ExtractTitleParagraphPairs() error {
 
 	// Apply additional de-duplication at the end
 	es.removeDuplicateContent()
+	span.SetAttributes(
+		attribute.Int("scraper.content_items", len(es.ContentItems)),
+		attribute.Int("scraper.duplicates_removed", es.DuplicatesRemoved),
+	)
 
 	return nil
 }
@@ -112,241 +127,89 @@ type Section struct {
 	StartTime time.Time
 }
 
-// extractImprovedContentSections implements the header + associated content approach
-// extractImprovedContentSections implements the header + associated content approach
-func extractImprovedContentSections(e *colly.HTMLElement, pageURL string, es *EnhancedScraper) {
-	fmt.Println("======= STARTING CONTENT EXTRACTION FOR:", pageURL, "=======")
-
-	// Track all sections we find
-	var sections []Section
+// addSections converts extracted sections into content items, skipping duplicates,
+// and returns how many new items were added
+func (es *EnhancedScraper) addSections(pageURL string, sections []Section) int {
+	es.mu.Lock()
+	defer es.mu.Unlock()
 
-	// Keep track of the current active section hierarchy
-	// The array index represents the heading level (0-6)
-	// and the value is the index in the sections array
-	sectionHierarchy := make([]int, 7)
-	for i := range sectionHierarchy {
-		sectionHierarchy[i] = -1 // -1 means no section at this level yet
-	}
+	added := 0
+	for _, section := range sections {
+		// Combine all content for this section
+		combinedContent := strings.Join(section.Content, "\n\n")
 
-	// Default section for content before any headings
-	defaultSection := Section{
-		Title:     "Page Content",
-		Level:     0,
-		Content:   []string{},
-		StartTime: time.Now(),
+		// Create hash to detect duplicates
+		hash := generateContentHash(section.Title, combinedContent)
+		if es.seenContent[hash] {
+			continue
+		}
+		es.seenContent[hash] = true
+
+		es.ContentItems = append(es.ContentItems, ContentItem{
+			URL:       pageURL,
+			Title:     section.Title,
+			Paragraph: combinedContent,
+			Hash:      hash,
+		})
+		added++
 	}
-	sections = append(sections, defaultSection)
-	sectionHierarchy[0] = 0 // The default section is at index 0
-
-	// Create an ordered list of all elements to process
-	var elements []*colly.HTMLElement
-
-	// First collect all relevant elements to ensure proper ordering
-	fmt.Println("Collecting elements for processing...")
-	elementCount := 0
-	e.ForEach("h1, h2, h3, h4, h5, h6, p, div", func(_ int, el *colly.HTMLElement) {
-		elements = append(elements, el)
-		elementCount++
-	})
-	fmt.Printf("Collected %d elements to process\n", elementCount)
-
-	// Process elements in document order
-	for i, el := range elements {
-		tagName := el.Name
+	return added
+}
 
-		// Log element info
-		rawText := el.Text
-		trimmedText := strings.TrimSpace(rawText)
-		fmt.Printf("\nElement #%d: Tag=%s, Length=%d\n", i, tagName, len(trimmedText))
+// addFacts records facts that haven't been seen on another page
+func (es *EnhancedScraper) addFacts(facts []Fact) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
 
-		if len(trimmedText) > 20 {
-			fmt.Printf("  Preview: '%s...'\n", trimmedText[:20])
-		} else if len(trimmedText) > 0 {
-			fmt.Printf("  Preview: '%s'\n", trimmedText)
+	for _, fact := range facts {
+		if es.seenFacts[fact.Hash()] {
+			continue
 		}
+		es.seenFacts[fact.Hash()] = true
+		es.Facts = append(es.Facts, fact)
+	}
+}
 
-		// Handle heading elements
-		if len(tagName) == 2 && tagName[0] == 'h' && tagName[1] >= '1' && tagName[1] <= '6' {
-			headingLevel := int(tagName[1] - '0')
-			headingText := strings.TrimSpace(el.Text)
-
-			if headingText == "" {
-				fmt.Println("  Skipping empty heading")
-				continue // Skip empty headings
-			}
-
-			fmt.Printf("  Processing heading L%d: '%s'\n", headingLevel, headingText)
-
-			// Create a new section for this heading
-			newSection := Section{
-				Title:     headingText,
-				Level:     headingLevel,
-				Content:   []string{},
-				StartTime: time.Now(),
-			}
-
-			// Add section to our collection
-			sections = append(sections, newSection)
-			currentIndex := len(sections) - 1
-			fmt.Printf("  Created section #%d with title: '%s'\n", currentIndex, headingText)
-
-			// Update the section hierarchy
-			sectionHierarchy[headingLevel] = currentIndex
-
-			// Clear any lower level sections (they're no longer active)
-			for i := headingLevel + 1; i < len(sectionHierarchy); i++ {
-				sectionHierarchy[i] = -1
-			}
-
-		} else if tagName == "p" || (tagName == "div" && !hasNestedBlockElements(el)) {
-			// Handle content elements
-			content := strings.TrimSpace(el.Text)
-			fmt.Printf("  Content element with %d chars, %d words\n",
-				len(content), countWords(content))
-
-			// Log full content for debugging
-			if len(content) > 0 {
-				// Print first 50 chars as preview
-				preview := content
-				if len(content) > 50 {
-					preview = content[:50] + "..."
-				}
-				fmt.Printf("  Content preview: '%s'\n", preview)
-
-				// Print last 20 chars to check for truncation
-				if len(content) > 20 {
-					lastChars := content[len(content)-20:]
-					fmt.Printf("  Content ending with: '%s'\n", lastChars)
-
-					// Check for ellipsis or other truncation signs
-					if strings.HasSuffix(content, "...") {
-						fmt.Printf("  WARNING: Content appears to be truncated\n")
-					}
-				}
-			}
-
-			// Skip if content is too short or empty
-			if len(content) < 50 || countWords(content) < 10 {
-				fmt.Println("  Skipping: Content too short")
-				continue
-			}
-
-			// Skip if content looks like HTML
-			if strings.Contains(content, "<") && strings.Contains(content, ">") {
-				fmt.Println("  Skipping: Content appears to contain HTML")
-				continue
-			}
-
-			// Find the active section to add this content to
-			// Start from the highest heading level and work down
-			activeSection := -1
-			for level := 6; level >= 0; level-- {
-				if sectionHierarchy[level] != -1 {
-					activeSection = sectionHierarchy[level]
-					break
-				}
-			}
+// applySelection drops gathered links outside the page selection and makes sure
+// explicitly selected pages are visited even when the crawl didn't reach them
+func (es *EnhancedScraper) applySelection() {
+	if es.Selection.IsEmpty() {
+		return
+	}
 
-			if activeSection != -1 {
-				// Print which section we're adding to
-				fmt.Printf("  Adding content to section #%d '%s'\n",
-					activeSection, sections[activeSection].Title)
-
-				// Check original HTML for debugging
-				// Check original HTML for debugging
-				htmlContent, htmlErr := el.DOM.Html()
-				if htmlErr != nil {
-					fmt.Printf("  Error getting HTML: %v\n", htmlErr)
-				} else {
-					htmlPreview := htmlContent
-					if len(htmlContent) > 100 {
-						htmlPreview = htmlContent[:100] + "..."
-					}
-					fmt.Printf("  Original HTML preview: %s\n", htmlPreview)
-				}
-
-				// Add content to the active section
-				sections[activeSection].Content = append(sections[activeSection].Content, content)
-			} else {
-				fmt.Println("  WARNING: No active section found for content")
-			}
-		} else {
-			fmt.Printf("  Skipping element: Not a heading, paragraph, or simple div\n")
+	for link := range es.LinksToVisit {
+		if !es.Selection.Includes(link) {
+			delete(es.LinksToVisit, link)
 		}
 	}
-
-	// Process each section to create ContentItems
-	fmt.Printf("\nProcessing %d sections to create content items...\n", len(sections))
-	for i, section := range sections {
-		// Skip sections with no content
-		if len(section.Content) == 0 {
-			fmt.Printf("Section #%d '%s': Skipping - No content\n", i, section.Title)
+	for _, selected := range es.Selection.URLs {
+		if !es.isSameDomain(selected) {
 			continue
 		}
-
-		// Combine all content for this section
-		combinedContent := strings.Join(section.Content, "\n\n")
-		fmt.Printf("Section #%d '%s': Combined %d content chunks, total length: %d\n",
-			i, section.Title, len(section.Content), len(combinedContent))
-
-		// Log first and last part of combined content
-		if len(combinedContent) > 0 {
-			firstPart := combinedContent
-			if len(combinedContent) > 50 {
-				firstPart = combinedContent[:50] + "..."
-			}
-			fmt.Printf("  Content starts with: '%s'\n", firstPart)
-
-			if len(combinedContent) > 20 {
-				lastPart := combinedContent[len(combinedContent)-20:]
-				fmt.Printf("  Content ends with: '%s'\n", lastPart)
-
-				if strings.HasSuffix(combinedContent, "...") {
-					fmt.Printf("  WARNING: Final combined content appears to be truncated!\n")
-				}
+		found := false
+		for link := range es.LinksToVisit {
+			if samePage(link, selected) {
+				found = true
+				break
 			}
 		}
-
-		// Create hash to detect duplicates
-		hash := generateContentHash(section.Title, combinedContent)
-
-		// Add to content items if not a duplicate
-		es.mu.Lock()
-		if !es.seenContent[hash] {
-			es.seenContent[hash] = true
-
-			// Create the content item
-			newItem := ContentItem{
-				URL:       pageURL,
-				Title:     section.Title,
-				Paragraph: combinedContent,
-				Hash:      hash,
-			}
-
-			// Log the item being created
-			fmt.Printf("  Adding content item: Title='%s', Length=%d\n",
-				newItem.Title, len(newItem.Paragraph))
-
-			// Check for potential truncation in final output
-			if strings.HasSuffix(newItem.Paragraph, "...") {
-				fmt.Printf("  CRITICAL: Final content item ends with '...', confirming truncation!\n")
-			}
-
-			es.ContentItems = append(es.ContentItems, newItem)
-		} else {
-			fmt.Printf("  Skipping duplicate content with hash: %s\n", hash)
+		if !found {
+			es.LinksToVisit[selected] = 0
 		}
-		es.mu.Unlock()
 	}
-
-	fmt.Printf("======= COMPLETED CONTENT EXTRACTION FOR %s: Added %d content items =======\n\n",
-		pageURL, len(es.ContentItems))
 }
 
 // Run executes the complete enhanced scraping process
 func (es *EnhancedScraper) Run() error {
-	// First run the basic scraping
-	err := es.Scraper.Run()
+	// First gather links and keep only the selected pages
+	err := es.GatherLinks()
+	if err != nil {
+		return fmt.Errorf("error gathering links: %w", err)
+	}
+	es.applySelection()
+
+	// Then run the basic scraping on the remaining pages
+	err = es.ScrapeLinks()
 	if err != nil {
 		return fmt.Errorf("error in basic scraping: %w", err)
 	}
@@ -377,9 +240,12 @@ func (es *EnhancedScraper) removeDuplicateContent() {
 		}
 	}
 
+	es.ItemsBeforeDedup += len(es.ContentItems)
+	es.DuplicatesRemoved += len(es.ContentItems) - len(uniqueItems)
+	es.logger().Debug("Removed duplicate content", "duplicates", len(es.ContentItems)-len(uniqueItems), "content_items", len(uniqueItems))
+
 	// Replace with deduplicated list
 	es.ContentItems = uniqueItems
-	fmt.Printf("Removed %d duplicate items\n", len(es.ContentItems)-len(uniqueItems))
 }
 
 // Helper functions
@@ -396,30 +262,18 @@ func generateContentHash(title, content string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// hasNestedBlockElements checks if an element contains common block-level elements
-func hasNestedBlockElements(e *colly.HTMLElement) bool {
-	blockElements := []string{"div", "p", "h1", "h2", "h3", "h4", "h5", "h6",
-		"ul", "ol", "table", "section", "article", "aside", "nav"}
-
-	for _, tag := range blockElements {
-		if e.DOM.Find(tag).Length() > 0 {
-			return true
-		}
-	}
-
-	return false
-}
-
 // countWords counts words in a string
 func countWords(s string) int {
 	return len(strings.Fields(s))
 }
 
+// whitespacePattern matches runs of whitespace
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
 // Clean up text by removing excess whitespace
 func cleanText(s string) string {
 	// Replace newlines and multiple spaces with a single space
-	re := regexp.MustCompile(`\s+`)
-	s = re.ReplaceAllString(s, " ")
+	s = whitespacePattern.ReplaceAllString(s, " ")
 	return strings.TrimSpace(s)
 }
 
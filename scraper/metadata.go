@@ -0,0 +1,617 @@
+package scraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// FactType identifies the kind of structured fact found on a page
+type FactType string
+
+const (
+	FactTypeOrganization  FactType = "organization"
+	FactTypePerson        FactType = "person"
+	FactTypeProduct       FactType = "product"
+	FactTypeOpenGraph     FactType = "open_graph"
+	FactTypeEmail         FactType = "email"
+	FactTypePhone         FactType = "phone"
+	FactTypeAddress       FactType = "address"
+	FactTypeSocialProfile FactType = "social_profile"
+)
+
+// Sources a fact can be extracted from
+const (
+	FactSourceJSONLD    = "json-ld"
+	FactSourceMicrodata = "microdata"
+	FactSourceMeta      = "meta"
+	FactSourceLink      = "link"
+	FactSourceText      = "text"
+	FactSourceTeamPage  = "team-page"
+)
+
+// Fact is a typed piece of structured information extracted from a page
+type Fact struct {
+	Type       FactType
+	Key        string            // What the value describes, e.g. "name", "industry", "linkedin", "og:title"
+	Value      string            // The fact itself
+	Attributes map[string]string // Extra fields, e.g. a person's job title or a product's brand
+	URL        string            // Page the fact was found on
+	Source     string            // Where on the page the fact came from
+}
+
+// Hash identifies a fact independently of the page it was found on
+func (f Fact) Hash() string {
+	return string(f.Type) + "|" + f.Key + "|" + strings.ToLower(f.Value)
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// phonePattern only matches numbers that are labelled or written in international format
+	phonePattern = regexp.MustCompile(`(?i)(?:(?:phone|tel|telephone|call|fax|mobile)\s*[.:]?\s*(\+?[\d][\d\s().\-/]{6,}\d)|(\+\d{1,3}[\s.\-]?\(?\d{1,4}\)?(?:[\s.\-]?\d{2,4}){2,4}))`)
+	// teamPagePattern matches URL paths that usually list a company's people
+	teamPagePattern = regexp.MustCompile(`(?i)/(team|our-team|people|leadership|management|about|about-us|company|founders|board)(/|$)`)
+	// personNamePattern matches two to four capitalized words
+	personNamePattern = regexp.MustCompile(`^\p{Lu}[\p{L}'\-]+(?:\s+\p{Lu}\.?[\p{L}'\-]*){1,3}$`)
+	// jobTitlePattern matches text that reads like a job title
+	jobTitlePattern = regexp.MustCompile(`(?i)\b(ceo|cto|cfo|coo|cmo|cio|chief|founder|co-founder|president|director|head|vp|vice president|manager|partner|officer|lead|chair|chairman|chairwoman|board member|principal|owner|engineer|architect|consultant)\b`)
+	// ignoredEmailSuffixes filters image names that look like addresses (e.g. logo@2x.png)
+	ignoredEmailSuffixes = []string{".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp"}
+)
+
+// socialNetworks maps hostnames to the network name used as fact key
+var socialNetworks = map[string]string{
+	"linkedin.com":   "linkedin",
+	"twitter.com":    "twitter",
+	"x.com":          "twitter",
+	"facebook.com":   "facebook",
+	"instagram.com":  "instagram",
+	"youtube.com":    "youtube",
+	"github.com":     "github",
+	"crunchbase.com": "crunchbase",
+	"xing.com":       "xing",
+	"tiktok.com":     "tiktok",
+}
+
+// sharePathPattern matches share-button URLs rather than profiles
+var sharePathPattern = regexp.MustCompile(`(?i)(share|sharer|intent|sharearticle|/dialog/)`)
+
+// factCollector gathers facts for a single page, skipping duplicates
+type factCollector struct {
+	pageURL string
+	facts   []Fact
+	seen    map[string]bool
+}
+
+// add records a fact if it has a value and hasn't been seen on this page
+func (fc *factCollector) add(fact Fact) {
+	fact.Value = cleanText(fact.Value)
+	if fact.Value == "" {
+		return
+	}
+	fact.URL = fc.pageURL
+	if fc.seen[fact.Hash()] {
+		return
+	}
+	fc.seen[fact.Hash()] = true
+	fc.facts = append(fc.facts, fact)
+}
+
+// ExtractFacts pulls structured facts out of a page: schema.org JSON-LD and
+// microdata, OpenGraph tags, contact emails and phone numbers, street addresses,
+// social profile links and people listed on team pages.
+func ExtractFacts(doc *goquery.Selection, pageURL string) []Fact {
+	if doc == nil || len(doc.Nodes) == 0 {
+		return nil
+	}
+
+	fc := &factCollector{pageURL: pageURL, seen: make(map[string]bool)}
+
+	extractJSONLD(doc, fc)
+	extractMicrodata(doc, fc)
+	extractOpenGraph(doc, fc)
+	extractContactLinks(doc, fc)
+	extractContactText(doc, fc)
+	extractAddressElements(doc, fc)
+
+	if isTeamPage(pageURL) {
+		extractTeamMembers(doc, fc)
+	}
+
+	return fc.facts
+}
+
+// extractJSONLD parses every JSON-LD block on the page
+func extractJSONLD(doc *goquery.Selection, fc *factCollector) {
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var data interface{}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(s.Text())), &data); err != nil {
+			return
+		}
+		collectSchemaEntities(data, FactSourceJSONLD, fc)
+	})
+}
+
+// extractMicrodata converts top-level itemscope elements into the same shape as
+// JSON-LD so both are handled by collectSchemaEntities
+func extractMicrodata(doc *goquery.Selection, fc *factCollector) {
+	doc.Find("[itemscope]").Each(func(_ int, s *goquery.Selection) {
+		// Nested items are reached through their parent's properties
+		if _, isProperty := s.Attr("itemprop"); isProperty {
+			return
+		}
+		collectSchemaEntities(microdataItem(s), FactSourceMicrodata, fc)
+	})
+}
+
+// microdataItem builds a map of properties for an itemscope element
+func microdataItem(item *goquery.Selection) map[string]interface{} {
+	entity := make(map[string]interface{})
+	if itemType, ok := item.Attr("itemtype"); ok {
+		entity["@type"] = path.Base(itemType)
+	}
+
+	item.Find("[itemprop]").Each(func(_ int, prop *goquery.Selection) {
+		// Only direct properties, not those of nested items
+		if owner := prop.Parent().Closest("[itemscope]"); owner.Length() > 0 && owner.Get(0) != item.Get(0) {
+			return
+		}
+
+		var value interface{}
+		if _, nested := prop.Attr("itemscope"); nested {
+			value = microdataItem(prop)
+		} else {
+			value = microdataValue(prop)
+		}
+
+		for _, name := range strings.Fields(prop.AttrOr("itemprop", "")) {
+			if existing, ok := entity[name]; ok {
+				if list, isList := existing.([]interface{}); isList {
+					entity[name] = append(list, value)
+				} else {
+					entity[name] = []interface{}{existing, value}
+				}
+				continue
+			}
+			entity[name] = value
+		}
+	})
+
+	return entity
+}
+
+// microdataValue returns the value of an itemprop element following the microdata rules
+func microdataValue(prop *goquery.Selection) string {
+	if content, ok := prop.Attr("content"); ok {
+		return content
+	}
+	switch goquery.NodeName(prop) {
+	case "a", "link", "area":
+		return prop.AttrOr("href", "")
+	case "img", "audio", "video", "source", "iframe", "embed":
+		return prop.AttrOr("src", "")
+	case "time":
+		if datetime, ok := prop.Attr("datetime"); ok {
+			return datetime
+		}
+	case "meta":
+		return prop.AttrOr("content", "")
+	}
+	return prop.Text()
+}
+
+// collectSchemaEntities walks decoded schema.org data and records facts for the
+// entity types we care about, descending into @graph and nested entities
+func collectSchemaEntities(data interface{}, source string, fc *factCollector) {
+	switch v := data.(type) {
+	case []interface{}:
+		for _, item := range v {
+			collectSchemaEntities(item, source, fc)
+		}
+	case map[string]interface{}:
+		if graph, ok := v["@graph"]; ok {
+			collectSchemaEntities(graph, source, fc)
+		}
+
+		switch {
+		case schemaTypeIs(v, "Person"):
+			addPersonFact(v, source, fc)
+		case schemaTypeIs(v, "Product", "SoftwareApplication", "Service"):
+			addProductFact(v, source, fc)
+		case schemaTypeIs(v, "PostalAddress"):
+			fc.add(Fact{Type: FactTypeAddress, Key: "postal", Value: formatPostalAddress(v), Source: source})
+		case isOrganizationType(v):
+			addOrganizationFacts(v, source, fc)
+		}
+
+		// Nested entities such as founders, employees or the page's main entity
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			if key != "@graph" && key != "address" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			switch v[key].(type) {
+			case map[string]interface{}, []interface{}:
+				collectSchemaEntities(v[key], source, fc)
+			}
+		}
+	}
+}
+
+// addOrganizationFacts records the descriptive fields of an Organization entity
+func addOrganizationFacts(entity map[string]interface{}, source string, fc *factCollector) {
+	fields := map[string]string{
+		"name":          schemaString(entity, "name"),
+		"legal_name":    schemaString(entity, "legalName"),
+		"description":   schemaString(entity, "description"),
+		"industry":      firstNonEmpty(schemaString(entity, "industry"), schemaString(entity, "naics"), schemaString(entity, "knowsAbout")),
+		"founding_date": schemaString(entity, "foundingDate"),
+		"employees":     schemaString(entity, "numberOfEmployees"),
+		"url":           schemaString(entity, "url"),
+	}
+
+	// Specific subtypes such as MedicalOrganization hint at the industry
+	if fields["industry"] == "" {
+		if orgType := schemaTypes(entity); len(orgType) > 0 && !genericOrganizationTypes[orgType[0]] {
+			fields["industry"] = splitCamelCase(orgType[0])
+		}
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fc.add(Fact{Type: FactTypeOrganization, Key: key, Value: fields[key], Source: source})
+	}
+
+	if address, ok := entity["address"]; ok {
+		for _, value := range schemaList(address) {
+			if postal, isMap := value.(map[string]interface{}); isMap {
+				fc.add(Fact{Type: FactTypeAddress, Key: "postal", Value: formatPostalAddress(postal), Source: source})
+			} else if text, isString := value.(string); isString {
+				fc.add(Fact{Type: FactTypeAddress, Key: "postal", Value: text, Source: source})
+			}
+		}
+	}
+
+	for _, email := range schemaStrings(entity, "email") {
+		fc.add(Fact{Type: FactTypeEmail, Key: "email", Value: strings.TrimPrefix(email, "mailto:"), Source: source})
+	}
+	for _, phone := range schemaStrings(entity, "telephone") {
+		fc.add(Fact{Type: FactTypePhone, Key: "phone", Value: phone, Source: source})
+	}
+	for _, profile := range schemaStrings(entity, "sameAs") {
+		if network := socialNetwork(profile); network != "" {
+			fc.add(Fact{Type: FactTypeSocialProfile, Key: network, Value: profile, Source: source})
+		}
+	}
+}
+
+// addPersonFact records a Person entity with its job title and contact details
+func addPersonFact(entity map[string]interface{}, source string, fc *factCollector) {
+	name := schemaString(entity, "name")
+	if name == "" {
+		name = strings.TrimSpace(schemaString(entity, "givenName") + " " + schemaString(entity, "familyName"))
+	}
+
+	attributes := map[string]string{}
+	setIfNotEmpty(attributes, "job_title", schemaString(entity, "jobTitle"))
+	setIfNotEmpty(attributes, "email", strings.TrimPrefix(schemaString(entity, "email"), "mailto:"))
+	setIfNotEmpty(attributes, "telephone", schemaString(entity, "telephone"))
+	setIfNotEmpty(attributes, "url", schemaString(entity, "url"))
+	for _, profile := range schemaStrings(entity, "sameAs") {
+		if network := socialNetwork(profile); network != "" {
+			setIfNotEmpty(attributes, network, profile)
+		}
+	}
+
+	fc.add(Fact{Type: FactTypePerson, Key: "name", Value: name, Attributes: attributes, Source: source})
+}
+
+// addProductFact records a Product-like entity
+func addProductFact(entity map[string]interface{}, source string, fc *factCollector) {
+	attributes := map[string]string{}
+	setIfNotEmpty(attributes, "description", schemaString(entity, "description"))
+	setIfNotEmpty(attributes, "brand", schemaString(entity, "brand"))
+	setIfNotEmpty(attributes, "sku", schemaString(entity, "sku"))
+	setIfNotEmpty(attributes, "category", firstNonEmpty(schemaString(entity, "category"), schemaString(entity, "applicationCategory")))
+
+	fc.add(Fact{Type: FactTypeProduct, Key: "name", Value: schemaString(entity, "name"), Attributes: attributes, Source: source})
+}
+
+// extractOpenGraph records og:* tags and the meta description
+func extractOpenGraph(doc *goquery.Selection, fc *factCollector) {
+	doc.Find("meta[property], meta[name]").Each(func(_ int, s *goquery.Selection) {
+		property := strings.ToLower(s.AttrOr("property", s.AttrOr("name", "")))
+		content := s.AttrOr("content", "")
+
+		switch {
+		case strings.HasPrefix(property, "og:"):
+			fc.add(Fact{Type: FactTypeOpenGraph, Key: property, Value: content, Source: FactSourceMeta})
+		case property == "description":
+			fc.add(Fact{Type: FactTypeOpenGraph, Key: "description", Value: content, Source: FactSourceMeta})
+		}
+	})
+}
+
+// extractContactLinks records mailto:, tel: and social profile links
+func extractContactLinks(doc *goquery.Selection, fc *factCollector) {
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		lower := strings.ToLower(href)
+
+		switch {
+		case strings.HasPrefix(lower, "mailto:"):
+			address := strings.SplitN(href[len("mailto:"):], "?", 2)[0]
+			if decoded, err := url.QueryUnescape(address); err == nil {
+				address = decoded
+			}
+			if isLikelyEmail(address) {
+				fc.add(Fact{Type: FactTypeEmail, Key: "email", Value: address, Source: FactSourceLink})
+			}
+		case strings.HasPrefix(lower, "tel:"):
+			fc.add(Fact{Type: FactTypePhone, Key: "phone", Value: href[len("tel:"):], Source: FactSourceLink})
+		default:
+			if network := socialNetwork(href); network != "" {
+				fc.add(Fact{Type: FactTypeSocialProfile, Key: network, Value: href, Source: FactSourceLink})
+			}
+		}
+	})
+}
+
+// extractContactText finds emails and labelled phone numbers in the visible text
+func extractContactText(doc *goquery.Selection, fc *factCollector) {
+	body := doc.Find("body")
+	if body.Length() == 0 {
+		body = doc
+	}
+	text := nodeText(body.Get(0))
+
+	for _, email := range emailPattern.FindAllString(text, -1) {
+		if isLikelyEmail(email) {
+			fc.add(Fact{Type: FactTypeEmail, Key: "email", Value: email, Source: FactSourceText})
+		}
+	}
+
+	for _, match := range phonePattern.FindAllStringSubmatch(text, -1) {
+		phone := firstNonEmpty(match[1], match[2])
+		digits := 0
+		for _, r := range phone {
+			if r >= '0' && r <= '9' {
+				digits++
+			}
+		}
+		if digits >= 7 && digits <= 15 {
+			fc.add(Fact{Type: FactTypePhone, Key: "phone", Value: strings.TrimSpace(phone), Source: FactSourceText})
+		}
+	}
+}
+
+// extractAddressElements records the text of <address> elements
+func extractAddressElements(doc *goquery.Selection, fc *factCollector) {
+	doc.Find("address").Each(func(_ int, s *goquery.Selection) {
+		text := cleanText(nodeText(s.Get(0)))
+		// Skip address blocks that only hold an email or a name
+		if len(text) < 10 || emailPattern.MatchString(text) && len(emailPattern.ReplaceAllString(text, "")) < 10 {
+			return
+		}
+		fc.add(Fact{Type: FactTypeAddress, Key: "postal", Value: text, Source: FactSourceText})
+	})
+}
+
+// extractTeamMembers looks for name/job-title pairs on team and about pages
+func extractTeamMembers(doc *goquery.Selection, fc *factCollector) {
+	doc.Find("h2, h3, h4, h5, strong, b, .name").Each(func(_ int, s *goquery.Selection) {
+		name := cleanText(s.Text())
+		if !personNamePattern.MatchString(name) {
+			return
+		}
+
+		// The job title is usually the next element or the next line in the same card
+		title := cleanText(s.Next().Text())
+		if title == "" {
+			title = cleanText(s.Parent().Next().Text())
+		}
+		if title == "" || len(title) > 80 || !jobTitlePattern.MatchString(title) {
+			return
+		}
+
+		fc.add(Fact{
+			Type:       FactTypePerson,
+			Key:        "name",
+			Value:      name,
+			Attributes: map[string]string{"job_title": title},
+			Source:     FactSourceTeamPage,
+		})
+	})
+}
+
+// isTeamPage reports whether a URL path looks like a team or about page
+func isTeamPage(pageURL string) bool {
+	parsed, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	return teamPagePattern.MatchString(parsed.Path)
+}
+
+// socialNetwork returns the network name for a profile URL, or "" if it isn't one
+func socialNetwork(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	network, ok := socialNetworks[host]
+	if !ok {
+		// Country subdomains such as de.linkedin.com
+		if dot := strings.Index(host, "."); dot > 0 {
+			network, ok = socialNetworks[host[dot+1:]]
+		}
+	}
+	if !ok {
+		return ""
+	}
+
+	// Profiles have a path; share buttons and home pages are skipped
+	profilePath := strings.Trim(parsed.Path, "/")
+	if profilePath == "" || sharePathPattern.MatchString(parsed.Path) {
+		return ""
+	}
+	return network
+}
+
+// isLikelyEmail filters out strings that match the email pattern but aren't addresses
+func isLikelyEmail(candidate string) bool {
+	if !emailPattern.MatchString(candidate) {
+		return false
+	}
+	lower := strings.ToLower(candidate)
+	for _, suffix := range ignoredEmailSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// schema.org helpers
+
+// genericOrganizationTypes carry no information about the industry
+var genericOrganizationTypes = map[string]bool{
+	"Organization": true, "Corporation": true, "LocalBusiness": true, "Company": true,
+	"OnlineBusiness": true, "OnlineStore": true,
+}
+
+// organizationTypeSuffixes identify schema.org Organization subtypes
+var organizationTypeSuffixes = []string{"Organization", "Corporation", "Business", "Company", "Store", "Agency", "NGO"}
+
+// isOrganizationType reports whether an entity is an Organization or a subtype of it
+func isOrganizationType(entity map[string]interface{}) bool {
+	for _, t := range schemaTypes(entity) {
+		for _, suffix := range organizationTypeSuffixes {
+			if strings.HasSuffix(t, suffix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaTypeIs reports whether an entity has one of the given @type values
+func schemaTypeIs(entity map[string]interface{}, types ...string) bool {
+	for _, t := range schemaTypes(entity) {
+		for _, want := range types {
+			if t == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// schemaTypes returns the entity's @type values without any vocabulary prefix
+func schemaTypes(entity map[string]interface{}) []string {
+	var types []string
+	for _, value := range schemaList(entity["@type"]) {
+		if t, ok := value.(string); ok {
+			t = strings.TrimPrefix(t, "schema:")
+			types = append(types, path.Base(t))
+		}
+	}
+	return types
+}
+
+// schemaList normalizes a single value or a list into a list
+func schemaList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	default:
+		return []interface{}{v}
+	}
+}
+
+// schemaString returns the first textual value of a property
+func schemaString(entity map[string]interface{}, key string) string {
+	values := schemaStrings(entity, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// schemaStrings returns all textual values of a property, using the name of nested entities
+func schemaStrings(entity map[string]interface{}, key string) []string {
+	var values []string
+	for _, value := range schemaList(entity[key]) {
+		switch v := value.(type) {
+		case string:
+			if s := strings.TrimSpace(v); s != "" {
+				values = append(values, s)
+			}
+		case float64:
+			values = append(values, fmt.Sprintf("%g", v))
+		case map[string]interface{}:
+			if name := firstNonEmpty(schemaString(v, "name"), schemaString(v, "value"), schemaString(v, "@id")); name != "" {
+				values = append(values, name)
+			}
+		}
+	}
+	return values
+}
+
+// formatPostalAddress joins the parts of a PostalAddress into one line
+func formatPostalAddress(address map[string]interface{}) string {
+	var parts []string
+	for _, key := range []string{"streetAddress", "postalCode", "addressLocality", "addressRegion", "addressCountry"} {
+		if value := schemaString(address, key); value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// splitCamelCase turns "MedicalOrganization" into "Medical Organization"
+func splitCamelCase(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			sb.WriteRune(' ')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// setIfNotEmpty sets a map entry only when the value is non-empty
+func setIfNotEmpty(m map[string]string, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		m[key] = value
+	}
+}
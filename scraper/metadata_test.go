@@ -0,0 +1,112 @@
+package scraper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func loadFactFixture(t *testing.T, name, pageURL string) []Fact {
+	t.Helper()
+
+	file, err := os.Open(filepath.Join("testdata", "facts", name))
+	if err != nil {
+		t.Fatalf("Failed to open fixture: %v", err)
+	}
+	defer file.Close()
+
+	doc, err := goquery.NewDocumentFromReader(file)
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+
+	return ExtractFacts(doc.Selection, pageURL)
+}
+
+// findFact returns the first fact with the given type, key and value
+func findFact(facts []Fact, factType FactType, key, value string) (Fact, bool) {
+	for _, fact := range facts {
+		if fact.Type == factType && fact.Key == key && fact.Value == value {
+			return fact, true
+		}
+	}
+	return Fact{}, false
+}
+
+func TestExtractFacts(t *testing.T) {
+	facts := loadFactFixture(t, "about_team.html", "https://northwind.example/about")
+
+	expected := []struct {
+		factType FactType
+		key      string
+		value    string
+	}{
+		{FactTypeOrganization, "name", "Northwind Logistics GmbH"},
+		{FactTypeOrganization, "industry", "Logistics"},
+		{FactTypeOrganization, "description", "Freight forwarding and cold-chain logistics for Central Europe."},
+		{FactTypeAddress, "postal", "Hauptstrasse 1, 10115, Berlin, DE"},
+		{FactTypeAddress, "postal", "Northwind Logistics, Hauptstrasse 1, 10115 Berlin"},
+		{FactTypePhone, "phone", "+49 30 1234567"},
+		{FactTypePhone, "phone", "+49 30 7654321"},
+		{FactTypeEmail, "email", "info@northwind.example"},
+		{FactTypeEmail, "email", "sales@northwind.example"},
+		{FactTypeSocialProfile, "linkedin", "https://www.linkedin.com/company/northwind-logistics"},
+		{FactTypeSocialProfile, "twitter", "https://twitter.com/northwind"},
+		{FactTypeSocialProfile, "github", "https://github.com/northwind"},
+		{FactTypeOpenGraph, "og:title", "About Northwind Logistics"},
+		{FactTypeOpenGraph, "description", "Temperature-controlled freight across Central Europe."},
+		{FactTypePerson, "name", "Maria Novak"},
+		{FactTypePerson, "name", "Jonas Weber"},
+		{FactTypeProduct, "name", "ColdTrack Monitoring"},
+	}
+
+	for _, want := range expected {
+		if _, ok := findFact(facts, want.factType, want.key, want.value); !ok {
+			t.Errorf("Missing fact %s/%s = %q", want.factType, want.key, want.value)
+		}
+	}
+
+	if person, ok := findFact(facts, FactTypePerson, "name", "Maria Novak"); ok {
+		if person.Attributes["job_title"] != "Chief Executive Officer" {
+			t.Errorf("Expected job title for Maria Novak, got %q", person.Attributes["job_title"])
+		}
+	}
+	if person, ok := findFact(facts, FactTypePerson, "name", "Jonas Weber"); ok {
+		if person.Source != FactSourceTeamPage || person.Attributes["job_title"] != "Head of Operations" {
+			t.Errorf("Unexpected team page person: %+v", person)
+		}
+	}
+	if product, ok := findFact(facts, FactTypeProduct, "name", "ColdTrack Monitoring"); ok {
+		if product.Attributes["brand"] != "Northwind" {
+			t.Errorf("Expected product brand Northwind, got %q", product.Attributes["brand"])
+		}
+	}
+
+	for _, fact := range facts {
+		if fact.Type == FactTypePerson && fact.Value == "Our Values" {
+			t.Errorf("Headline without a job title was taken for a person: %+v", fact)
+		}
+		if fact.Type == FactTypeEmail && fact.Value == "logo@2x.png" {
+			t.Errorf("Image file name was taken for an email: %+v", fact)
+		}
+		if fact.Type == FactTypeSocialProfile && fact.Key == "facebook" {
+			t.Errorf("Share link was taken for a social profile: %+v", fact)
+		}
+		if fact.URL != "https://northwind.example/about" {
+			t.Errorf("Expected fact URL to be the page URL, got %q", fact.URL)
+		}
+	}
+}
+
+func TestExtractFactsSkipsTeamHeuristicOutsideTeamPages(t *testing.T) {
+	facts := loadFactFixture(t, "about_team.html", "https://northwind.example/blog/post")
+
+	if _, ok := findFact(facts, FactTypePerson, "name", "Jonas Weber"); ok {
+		t.Error("Team page heuristic should only run on team and about pages")
+	}
+	if _, ok := findFact(facts, FactTypePerson, "name", "Maria Novak"); !ok {
+		t.Error("Structured Person data should be extracted on every page")
+	}
+}
@@ -0,0 +1,103 @@
+package scraper
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// PageSelection limits content extraction to chosen pages and sections of a site.
+// An empty selection includes every crawled page.
+type PageSelection struct {
+	URLs     []string `json:"urls,omitempty"`     // Individual pages to include
+	Subtrees []string `json:"subtrees,omitempty"` // Path prefixes to include, e.g. "/about" also matches "/about/team"
+}
+
+// IsEmpty reports whether the selection includes every page
+func (p *PageSelection) IsEmpty() bool {
+	return p == nil || (len(p.URLs) == 0 && len(p.Subtrees) == 0)
+}
+
+// Validate checks that every selected URL belongs to the site and normalizes subtree paths
+func (p *PageSelection) Validate(baseURL string) error {
+	if p == nil {
+		return nil
+	}
+
+	baseHost := normalizedHost(baseURL)
+	for _, link := range p.URLs {
+		parsed, err := url.Parse(link)
+		if err != nil || parsed.Hostname() == "" {
+			return fmt.Errorf("invalid page URL: %s", link)
+		}
+		if normalizedHost(link) != baseHost {
+			return fmt.Errorf("page %s is not on %s", link, baseHost)
+		}
+	}
+
+	for i, subtree := range p.Subtrees {
+		// Accept full URLs as well as bare paths
+		if parsed, err := url.Parse(subtree); err == nil && parsed.Hostname() != "" {
+			if normalizedHost(subtree) != baseHost {
+				return fmt.Errorf("subtree %s is not on %s", subtree, baseHost)
+			}
+			subtree = parsed.Path
+		}
+		p.Subtrees[i] = normalizedPath(subtree)
+	}
+
+	return nil
+}
+
+// Includes reports whether a page URL is part of the selection
+func (p *PageSelection) Includes(link string) bool {
+	if p.IsEmpty() {
+		return true
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	pagePath := normalizedPath(parsed.Path)
+
+	for _, selected := range p.URLs {
+		if samePage(link, selected) {
+			return true
+		}
+	}
+
+	for _, subtree := range p.Subtrees {
+		prefix := normalizedPath(subtree)
+		if prefix == "/" || pagePath == prefix || strings.HasPrefix(pagePath, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// samePage reports whether two URLs point at the same page, ignoring www, trailing slashes, queries and fragments
+func samePage(a, b string) bool {
+	parsedA, errA := url.Parse(a)
+	parsedB, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return normalizedHost(a) == normalizedHost(b) && normalizedPath(parsedA.Path) == normalizedPath(parsedB.Path)
+}
+
+// normalizedHost returns a URL's hostname without the www prefix
+func normalizedHost(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// normalizedPath cleans a URL path so "/about/" and "about" compare equal
+func normalizedPath(urlPath string) string {
+	return path.Clean("/" + strings.TrimSpace(urlPath))
+}
@@ -0,0 +1,102 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+)
+
+func TestPageSelectionIncludes(t *testing.T) {
+	selection := &PageSelection{
+		URLs:     []string{"https://www.example.com/careers/"},
+		Subtrees: []string{"/about"},
+	}
+
+	tests := []struct {
+		link string
+		want bool
+	}{
+		{"https://example.com/about", true},
+		{"https://example.com/about/team", true},
+		{"https://example.com/about-us", false},
+		{"https://example.com/careers", true},
+		{"https://example.com/careers/engineer", false},
+		{"https://example.com/blog/2019/archive", false},
+		{"https://example.com/", false},
+	}
+	for _, tt := range tests {
+		if got := selection.Includes(tt.link); got != tt.want {
+			t.Errorf("Includes(%q) = %v, want %v", tt.link, got, tt.want)
+		}
+	}
+
+	var empty *PageSelection
+	if !empty.Includes("https://example.com/blog") {
+		t.Error("Expected an empty selection to include every page")
+	}
+}
+
+func TestPageSelectionValidate(t *testing.T) {
+	selection := &PageSelection{Subtrees: []string{"about/", "https://www.example.com/customers/"}}
+	if err := selection.Validate("https://example.com"); err != nil {
+		t.Fatalf("Unexpected validation error: %v", err)
+	}
+	if selection.Subtrees[0] != "/about" || selection.Subtrees[1] != "/customers" {
+		t.Errorf("Subtrees not normalized: %v", selection.Subtrees)
+	}
+
+	offSite := &PageSelection{URLs: []string{"https://other.com/about"}}
+	if err := offSite.Validate("https://example.com"); err == nil {
+		t.Error("Expected an error for a page on another site")
+	}
+}
+
+func TestEnhancedScraperExtractsOnlySelectedPages(t *testing.T) {
+	pages := map[string]string{
+		"/":              `<a href="/about">About</a> <a href="/blog/old-post">Blog</a>`,
+		"/about":         `<a href="/about/team">Team</a>`,
+		"/about/team":    "",
+		"/blog/old-post": "",
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		links, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintf(w, `<html><head><title>%s</title></head><body><article><h1>%s</h1>
+			<p>This page at %s has enough text in it to count as a real paragraph of content.</p>
+			%s</article></body></html>`, r.URL.Path, r.URL.Path, r.URL.Path, links)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	es, err := NewEnhancedScraper(server.URL+"/", 1)
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	// The team page is deeper than the crawl goes, so it must be added from the selection
+	es.Selection = &PageSelection{
+		URLs:     []string{server.URL + "/about/team"},
+		Subtrees: []string{"/about"},
+	}
+
+	if err := es.Run(); err != nil {
+		t.Fatalf("Scraping failed: %v", err)
+	}
+
+	var titles []string
+	for _, item := range es.ContentItems {
+		titles = append(titles, item.Title)
+	}
+	sort.Strings(titles)
+
+	want := []string{"/about", "/about/team"}
+	if fmt.Sprint(titles) != fmt.Sprint(want) {
+		t.Errorf("Extracted pages %v, want %v", titles, want)
+	}
+}
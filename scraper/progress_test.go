@@ -0,0 +1,56 @@
+package scraper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mbaxamb3/nusli/progress"
+)
+
+func TestEnhancedScraperEmitsProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><body><article><h1>Home</h1>
+			<p>Enough text on the home page to be extracted as a paragraph of content.</p>
+			<a href="/missing">Missing</a></article></body></html>`)
+	}))
+	defer server.Close()
+
+	es, err := NewEnhancedScraper(server.URL+"/", 1)
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	events := make(chan progress.Event, 100)
+	es.Progress = events
+
+	if err := es.Run(); err != nil {
+		t.Fatalf("Scraping failed: %v", err)
+	}
+	close(events)
+
+	seen := make(map[progress.EventType]int)
+	extracted := 0
+	for event := range events {
+		seen[event.Type]++
+		if event.Type == progress.EventItemsExtracted {
+			extracted += event.Count
+		}
+		if event.Time.IsZero() {
+			t.Errorf("Event %s has no timestamp", event.Type)
+		}
+	}
+
+	for _, eventType := range []progress.EventType{progress.EventPageDiscovered, progress.EventPageFetched, progress.EventItemsExtracted, progress.EventError} {
+		if seen[eventType] == 0 {
+			t.Errorf("Expected at least one %s event, got %v", eventType, seen)
+		}
+	}
+	if extracted != len(es.ContentItems) {
+		t.Errorf("Extracted events counted %d items, scraper has %d", extracted, len(es.ContentItems))
+	}
+}
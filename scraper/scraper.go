@@ -1,12 +1,15 @@
 package scraper
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strings"
 	"sync"
 
 	"github.com/gocolly/colly/v2"
+	"github.com/mbaxamb3/nusli/progress"
 )
 
 // Scraper represents a web scraper with configurable depth
@@ -17,6 +20,10 @@ type Scraper struct {
 	VisitedLinks map[string]bool
 	mu           sync.Mutex
 	Data         map[string]PageData
+	Progress     chan<- progress.Event // Optional; receives progress events without blocking the scraper
+	Logger       *slog.Logger          // Optional; the default logger is used when nil
+	Context      context.Context       // Optional; cancels the crawl and parents its trace spans
+	pagesFetched int                   // Responses received by all collectors
 }
 
 // PageData stores information scraped from a page
@@ -44,6 +51,29 @@ func NewScraper(baseURL string, maxDepth int) (*Scraper, error) {
 	}, nil
 }
 
+// logger returns the logger to log to
+func (s *Scraper) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
+
+// PagesFetched returns the number of pages fetched so far, counting pages fetched again by
+// later stages
+func (s *Scraper) PagesFetched() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pagesFetched
+}
+
+// countFetch records a fetched page
+func (s *Scraper) countFetch() {
+	s.mu.Lock()
+	s.pagesFetched++
+	s.mu.Unlock()
+}
+
 // isSameDomain checks if the link belongs to the same domain as the base URL
 func (s *Scraper) isSameDomain(link string) bool {
 	baseURL, _ := url.Parse(s.BaseURL)
@@ -63,12 +93,15 @@ func (s *Scraper) isSameDomain(link string) bool {
 }
 
 // GatherLinks collects all links up to the specified depth
-func (s *Scraper) GatherLinks() error {
+func (s *Scraper) GatherLinks() (err error) {
+	ctx, span := s.startStage("gather links")
+	defer func() { endStage(span, err) }()
+
 	baseDomain := getDomain(s.BaseURL)
 	wwwDomain := "www." + baseDomain
 
 	// Create a collector with options for handling redirects
-	c := colly.NewCollector(
+	c := newCollector(ctx,
 		colly.MaxDepth(s.MaxDepth),
 		colly.AllowedDomains(baseDomain, wwwDomain),
 		colly.AllowURLRevisit(),
@@ -99,7 +132,8 @@ func (s *Scraper) GatherLinks() error {
 			s.mu.Lock()
 			if _, exists := s.LinksToVisit[absoluteURL]; !exists && !s.VisitedLinks[absoluteURL] {
 				s.LinksToVisit[absoluteURL] = currentDepth + 1
-				fmt.Printf("Adding to visit queue: %s (depth: %d)\n", absoluteURL, currentDepth+1)
+				s.logger().Debug("Queued link", "url", absoluteURL, "depth", currentDepth+1)
+				progress.Emit(s.Progress, progress.Event{Type: progress.EventPageDiscovered, URL: absoluteURL, Depth: currentDepth + 1})
 			}
 			s.mu.Unlock()
 		}
@@ -109,44 +143,48 @@ func (s *Scraper) GatherLinks() error {
 		s.mu.Lock()
 		s.VisitedLinks[r.URL.String()] = true
 		s.mu.Unlock()
-		fmt.Printf("Gathering links: %s\n", r.URL.String())
+		s.logger().Debug("Gathering links", "url", r.URL.String())
 	})
 
-	// Debug - print response info
 	c.OnResponse(func(r *colly.Response) {
-		fmt.Printf("Got response from %s: %d bytes, status: %d\n",
-			r.Request.URL, len(r.Body), r.StatusCode)
+		s.countFetch()
+		s.logger().Debug("Fetched page", "url", r.Request.URL.String(), "bytes", len(r.Body), "status", r.StatusCode)
+		progress.Emit(s.Progress, progress.Event{Type: progress.EventPageFetched, URL: r.Request.URL.String(), Count: len(r.Body)})
 	})
 
-	// Debug - print errors
 	c.OnError(func(r *colly.Response, err error) {
-		fmt.Printf("Error on %s: %s\n", r.Request.URL, err)
+		s.logger().Warn("Failed to fetch page", "url", r.Request.URL.String(), "status", r.StatusCode, "error", err)
+		progress.Emit(s.Progress, progress.Event{Type: progress.EventError, URL: r.Request.URL.String(), Message: err.Error()})
 	})
 
 	// Start with the base URL
-	err := c.Visit(s.BaseURL)
+	err = c.Visit(s.BaseURL)
 	if err != nil {
 		return err
 	}
 
 	c.Wait()
-	fmt.Printf("Found %d links to visit\n", len(s.LinksToVisit))
+	s.logger().Info("Gathered links", "url", s.BaseURL, "links", len(s.LinksToVisit))
 	return nil
 }
 
 // ScrapeLinks performs the actual scraping on the gathered links
 func (s *Scraper) ScrapeLinks() error {
+	ctx, span := s.startStage("scrape links")
+	defer span.End()
+
 	baseDomain := getDomain(s.BaseURL)
 	wwwDomain := "www." + baseDomain
 
-	c := colly.NewCollector(
+	c := newCollector(ctx,
 		colly.AllowedDomains(baseDomain, wwwDomain),
 		colly.AllowURLRevisit(),
 	)
 
-	// Debug - print response info
 	c.OnResponse(func(r *colly.Response) {
-		fmt.Printf("Scraping response from %s: %d bytes\n", r.Request.URL, len(r.Body))
+		s.countFetch()
+		s.logger().Debug("Fetched page", "url", r.Request.URL.String(), "bytes", len(r.Body), "status", r.StatusCode)
+		progress.Emit(s.Progress, progress.Event{Type: progress.EventPageFetched, URL: r.Request.URL.String(), Count: len(r.Body)})
 	})
 
 	// Extract page title
@@ -201,7 +239,7 @@ func (s *Scraper) ScrapeLinks() error {
 	})
 
 	c.OnRequest(func(r *colly.Request) {
-		fmt.Printf("Scraping: %s\n", r.URL.String())
+		s.logger().Debug("Scraping page", "url", r.URL.String())
 		s.mu.Lock()
 		url := r.URL.String()
 		if _, exists := s.Data[url]; !exists {
@@ -213,16 +251,16 @@ func (s *Scraper) ScrapeLinks() error {
 		s.mu.Unlock()
 	})
 
-	// Debug - print errors
 	c.OnError(func(r *colly.Response, err error) {
-		fmt.Printf("Error on %s: %s\n", r.Request.URL, err)
+		s.logger().Warn("Failed to fetch page", "url", r.Request.URL.String(), "status", r.StatusCode, "error", err)
+		progress.Emit(s.Progress, progress.Event{Type: progress.EventError, URL: r.Request.URL.String(), Message: err.Error()})
 	})
 
 	// Visit each link in LinksToVisit
 	for link := range s.LinksToVisit {
 		err := c.Visit(link)
 		if err != nil {
-			fmt.Printf("Error visiting %s: %v\n", link, err)
+			s.logger().Warn("Failed to visit page", "url", link, "error", err)
 			// Continue with other links
 		}
 	}
@@ -248,6 +286,15 @@ func (s *Scraper) Run() error {
 	return nil
 }
 
+// Discover gathers the site's links without scraping them and returns them as a tree,
+// so a subset of pages can be selected before content is extracted
+func (s *Scraper) Discover() (*TreeNode, error) {
+	if err := s.GatherLinks(); err != nil {
+		return nil, fmt.Errorf("error gathering links: %v", err)
+	}
+	return s.BuildLinkTree()
+}
+
 // Helper function to get depth from context
 func getDepthFromContext(ctx *colly.Context) int {
 	if ctx == nil {
@@ -10,14 +10,34 @@ import (
 
 // TreeNode represents a node in the site tree
 type TreeNode struct {
-	URL      string
-	Path     string
-	Title    string
-	Children map[string]*TreeNode
+	URL            string               `json:"url"`
+	Path           string               `json:"path"`
+	Title          string               `json:"title"`
+	ParagraphCount int                  `json:"paragraph_count"`
+	Children       map[string]*TreeNode `json:"children,omitempty"`
 }
 
 // BuildSiteTree constructs a tree representation of the site from scraped data
 func (s *Scraper) BuildSiteTree() (*TreeNode, error) {
+	titles := make(map[string]string, len(s.Data))
+	for pageURL, data := range s.Data {
+		titles[pageURL] = data.Title
+	}
+	return s.buildTree(titles)
+}
+
+// BuildLinkTree constructs a tree of the gathered links before any page is scraped.
+// Nodes are titled by their path segment since page titles aren't known yet.
+func (s *Scraper) BuildLinkTree() (*TreeNode, error) {
+	titles := make(map[string]string, len(s.LinksToVisit))
+	for link := range s.LinksToVisit {
+		titles[link] = ""
+	}
+	return s.buildTree(titles)
+}
+
+// buildTree arranges pages, given as URL to title, by their URL paths
+func (s *Scraper) buildTree(pages map[string]string) (*TreeNode, error) {
 	// Create the root node
 	baseURL, err := url.Parse(s.BaseURL)
 	if err != nil {
@@ -36,12 +56,12 @@ func (s *Scraper) BuildSiteTree() (*TreeNode, error) {
 	}
 
 	// Find the title for the root node if available
-	if data, exists := s.Data[s.BaseURL]; exists {
-		root.Title = data.Title
+	if title := pages[s.BaseURL]; title != "" {
+		root.Title = title
 	}
 
 	// Add all pages to the tree
-	for pageURL, data := range s.Data {
+	for pageURL, title := range pages {
 		// Skip if it's the root
 		if pageURL == s.BaseURL {
 			continue
@@ -50,7 +70,7 @@ func (s *Scraper) BuildSiteTree() (*TreeNode, error) {
 		// Parse URL
 		parsedURL, err := url.Parse(pageURL)
 		if err != nil {
-			fmt.Printf("Error parsing URL %s: %v\n", pageURL, err)
+			s.logger().Warn("Skipping page with invalid URL", "url", pageURL, "error", err)
 			continue
 		}
 
@@ -60,6 +80,15 @@ func (s *Scraper) BuildSiteTree() (*TreeNode, error) {
 			continue
 		}
 
+		// The home page may be recorded with a trailing slash
+		if path.Clean("/"+parsedURL.Path) == "/" {
+			root.URL = pageURL
+			if title != "" {
+				root.Title = strings.TrimSpace(title)
+			}
+			continue
+		}
+
 		// Get path segments
 		pathSegments := getPathSegments(parsedURL.Path)
 
@@ -73,30 +102,35 @@ func (s *Scraper) BuildSiteTree() (*TreeNode, error) {
 			}
 
 			currentPath = path.Join(currentPath, segment)
+			isPage := i == len(pathSegments)-1
 
 			// Check if this path segment already exists as a child
-			if child, exists := currentNode.Children[segment]; exists {
-				currentNode = child
-			} else {
-				// Create a new node for this path segment
-				newNode := &TreeNode{
-					URL:      pageURL,
+			child, exists := currentNode.Children[segment]
+			if !exists {
+				// Create a new node for this path segment; intermediate
+				// nodes point at the path itself until the page is found
+				pathURL := *parsedURL
+				pathURL.Path = currentPath
+				pathURL.RawQuery = ""
+				pathURL.Fragment = ""
+
+				child = &TreeNode{
+					URL:      pathURL.String(),
 					Path:     currentPath,
 					Title:    segment, // Default title is the path segment
 					Children: make(map[string]*TreeNode),
 				}
+				currentNode.Children[segment] = child
+			}
 
-				// If this is the last segment, use the page title
-				if i == len(pathSegments)-1 {
-					newNode.Title = data.Title
-					if newNode.Title == "" {
-						newNode.Title = segment
-					}
+			// If this is the last segment, use the page itself
+			if isPage {
+				child.URL = pageURL
+				if title != "" {
+					child.Title = strings.TrimSpace(title)
 				}
-
-				currentNode.Children[segment] = newNode
-				currentNode = newNode
 			}
+			currentNode = child
 		}
 	}
 
@@ -120,16 +154,36 @@ func PrintSiteTree(node *TreeNode, indent string) {
 	// Print current node
 	fmt.Printf("%s%s (%s)\n", indent, node.Title, node.Path)
 
-	// Get sorted child keys for consistent output
-	var childKeys []string
+	// Print children in a consistent order
+	for _, child := range sortedChildren(node) {
+		PrintSiteTree(child, indent+"  ")
+	}
+}
+
+// sortedChildren returns a node's children ordered by path segment
+func sortedChildren(node *TreeNode) []*TreeNode {
+	childKeys := make([]string, 0, len(node.Children))
 	for key := range node.Children {
 		childKeys = append(childKeys, key)
 	}
 	sort.Strings(childKeys)
 
-	// Print children
+	children := make([]*TreeNode, 0, len(childKeys))
 	for _, key := range childKeys {
-		PrintSiteTree(node.Children[key], indent+"  ")
+		children = append(children, node.Children[key])
+	}
+	return children
+}
+
+// SetParagraphCounts records how many paragraphs were extracted from each page, keyed by page URL
+func (node *TreeNode) SetParagraphCounts(counts map[string]int) {
+	if node == nil {
+		return
+	}
+
+	node.ParagraphCount = counts[node.URL]
+	for _, child := range node.Children {
+		child.SetParagraphCounts(counts)
 	}
 }
 
@@ -163,12 +217,15 @@ func exportDOTNodes(sb *strings.Builder, node *TreeNode, nodeIDs map[string]int,
 		return
 	}
 
-	// Add current node
-	nodeTitle := strings.Replace(node.Title, "\"", "\\\"", -1)
-	sb.WriteString(fmt.Sprintf("  node%d [label=\"%s\"];\n", nodeIDs[node.Path], nodeTitle))
+	// Add current node, labelled with its title and paragraph count
+	label := escapeDOTLabel(node.Title)
+	if node.ParagraphCount > 0 {
+		label += fmt.Sprintf("\\n%d paragraphs", node.ParagraphCount)
+	}
+	sb.WriteString(fmt.Sprintf("  node%d [label=\"%s\"];\n", nodeIDs[node.Path], label))
 
 	// Add children
-	for _, child := range node.Children {
+	for _, child := range sortedChildren(node) {
 		if _, exists := nodeIDs[child.Path]; !exists {
 			nodeIDs[child.Path] = *counter
 			*counter++
@@ -184,8 +241,15 @@ func exportDOTEdges(sb *strings.Builder, node *TreeNode, nodeIDs map[string]int)
 	}
 
 	// Add edges to children
-	for _, child := range node.Children {
+	for _, child := range sortedChildren(node) {
 		sb.WriteString(fmt.Sprintf("  node%d -> node%d;\n", nodeIDs[node.Path], nodeIDs[child.Path]))
 		exportDOTEdges(sb, child, nodeIDs)
 	}
 }
+
+// escapeDOTLabel escapes characters that would end or break a quoted DOT label
+func escapeDOTLabel(label string) string {
+	label = strings.ReplaceAll(label, "\\", "\\\\")
+	label = strings.ReplaceAll(label, "\"", "\\\"")
+	return strings.ReplaceAll(label, "\n", " ")
+}
@@ -1,6 +1,7 @@
 package scraper
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"testing"
@@ -49,3 +50,74 @@ func TestBuildSiteTree(t *testing.T) {
 	fmt.Printf("DOT graph saved to %s\n", dotFile)
 	fmt.Println("To visualize the graph, install Graphviz and run: dot -Tpng sitemap.dot -o sitemap.png")
 }
+
+func TestSiteTreeFromScrapedData(t *testing.T) {
+	s, err := NewScraper("https://example.com", 2)
+	if err != nil {
+		t.Fatalf("Failed to create scraper: %v", err)
+	}
+	s.Data = map[string]PageData{
+		"https://example.com/":              {URL: "https://example.com/", Title: "Example Home"},
+		"https://example.com/about":         {URL: "https://example.com/about", Title: "About \"Us\""},
+		"https://example.com/blog/post-one": {URL: "https://example.com/blog/post-one", Title: "First Post"},
+		"https://www.example.com/careers":   {URL: "https://www.example.com/careers", Title: "Careers"},
+		"https://other.com/about":           {URL: "https://other.com/about", Title: "Elsewhere"},
+	}
+
+	root, err := s.BuildSiteTree()
+	if err != nil {
+		t.Fatalf("Failed to build site tree: %v", err)
+	}
+	root.SetParagraphCounts(map[string]int{
+		"https://example.com/":              2,
+		"https://example.com/blog/post-one": 5,
+	})
+
+	if root.Title != "Example Home" || root.ParagraphCount != 2 {
+		t.Errorf("Unexpected root node: %+v", root)
+	}
+	if len(root.Children) != 3 {
+		t.Fatalf("Expected 3 top-level children, got %d", len(root.Children))
+	}
+
+	blog := root.Children["blog"]
+	if blog == nil || blog.URL != "https://example.com/blog" || blog.ParagraphCount != 0 {
+		t.Fatalf("Unexpected intermediate blog node: %+v", blog)
+	}
+	post := blog.Children["post-one"]
+	if post == nil || post.Title != "First Post" || post.ParagraphCount != 5 {
+		t.Errorf("Unexpected post node: %+v", post)
+	}
+
+	// The tree is stored as JSON and must survive a round trip
+	encoded, err := json.Marshal(root)
+	if err != nil {
+		t.Fatalf("Failed to encode site tree: %v", err)
+	}
+	var decoded TreeNode
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Failed to decode site tree: %v", err)
+	}
+	if decoded.Children["blog"].Children["post-one"].ParagraphCount != 5 {
+		t.Errorf("Paragraph count lost in JSON round trip: %s", encoded)
+	}
+
+	want := `digraph SiteMap {
+  node [shape=box, style=filled, fillcolor=lightblue];
+  rankdir=LR;
+
+  node0 [label="Example Home\n2 paragraphs"];
+  node1 [label="About \"Us\""];
+  node2 [label="blog"];
+  node3 [label="First Post\n5 paragraphs"];
+  node4 [label="Careers"];
+  node0 -> node1;
+  node0 -> node2;
+  node2 -> node3;
+  node0 -> node4;
+}
+`
+	if got := ExportSiteTreeDOT(&decoded); got != want {
+		t.Errorf("Unexpected DOT output:\n%s", got)
+	}
+}
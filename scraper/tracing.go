@@ -0,0 +1,76 @@
+package scraper
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gocolly/colly/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/mbaxamb3/nusli/scraper")
+
+// context returns the context the scraper runs in
+func (s *Scraper) context() context.Context {
+	if s.Context != nil {
+		return s.Context
+	}
+	return context.Background()
+}
+
+// startStage starts the span of a scraping stage, such as gathering links
+func (s *Scraper) startStage(name string) (context.Context, trace.Span) {
+	return tracer.Start(s.context(), name, trace.WithAttributes(
+		attribute.String("scraper.base_url", s.BaseURL),
+		attribute.Int("scraper.max_depth", s.MaxDepth),
+	))
+}
+
+// endStage ends the span of a scraping stage, recording err if it failed
+func endStage(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// newCollector creates a collector for a stage running in ctx. Its requests stop when ctx is
+// cancelled, and each page it fetches gets a span under the stage's span.
+func newCollector(ctx context.Context, options ...colly.CollectorOption) *colly.Collector {
+	c := colly.NewCollector(options...)
+	c.Context = ctx
+
+	// Colly shares request contexts between requests, so spans are tracked by request ID
+	var fetches sync.Map
+	c.OnRequest(func(r *colly.Request) {
+		_, span := tracer.Start(ctx, "fetch page",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.full", r.URL.String()),
+			))
+		fetches.Store(r.ID, span)
+	})
+	c.OnResponse(func(r *colly.Response) {
+		if span, ok := fetches.LoadAndDelete(r.Request.ID); ok {
+			span.(trace.Span).SetAttributes(attribute.Int("http.response.status_code", r.StatusCode))
+			span.(trace.Span).End()
+		}
+	})
+	c.OnError(func(r *colly.Response, err error) {
+		if span, ok := fetches.LoadAndDelete(r.Request.ID); ok {
+			span := span.(trace.Span)
+			if r.StatusCode != 0 {
+				span.SetAttributes(attribute.Int("http.response.status_code", r.StatusCode))
+			}
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+		}
+	})
+	return c
+}
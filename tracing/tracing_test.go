@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup(t *testing.T) {
+	ctx := context.Background()
+
+	shutdown, err := Setup(ctx, Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(ctx))
+
+	_, err = Setup(ctx, Config{Exporter: "zipkin"})
+	require.ErrorContains(t, err, "unknown trace exporter")
+
+	shutdown, err = Setup(ctx, Config{Exporter: ExporterStdout, SampleRatio: 1})
+	require.NoError(t, err)
+	_, span := otel.Tracer("test").Start(ctx, "test")
+	require.True(t, span.SpanContext().IsSampled(), "spans aren't recorded")
+	span.End()
+	require.NoError(t, shutdown(ctx))
+}
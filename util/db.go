@@ -3,7 +3,7 @@ package util
 import (
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 
 	_ "github.com/lib/pq"
 )
@@ -24,15 +24,20 @@ func ConnectDB(dbDriver, dbSource string) (*sql.DB, error) {
 	return db, nil
 }
 
-// SetupDBConnection establishes a database connection using config
+// SetupDBConnection establishes a database connection using config, with its pool settings
 func SetupDBConnection(config Config) (*sql.DB, error) {
 	db, err := ConnectDB(config.DBDriver, config.DBSource)
 	if err != nil {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
+
 	// Log successful connection
-	log.Printf("Connected to database: %s", config.DBSource)
+	slog.Info("Connected to database", "source", config.Redacted().DBSource, "max_open_conns", config.DBMaxOpenConns)
 
 	return db, nil
 }
@@ -45,7 +50,7 @@ func SetupTestDBConnection(config Config) (*sql.DB, error) {
 	}
 
 	// Log successful connection
-	log.Printf("Connected to test database: %s", config.DBSource)
+	slog.Info("Connected to test database", "source", config.Redacted().DBSource)
 
 	return db, nil
 }
@@ -55,9 +60,9 @@ func CloseDB(db *sql.DB) {
 	if db != nil {
 		err := db.Close()
 		if err != nil {
-			log.Printf("Error closing database: %v", err)
+			slog.Error("Failed to close database", "error", err)
 			return
 		}
-		log.Println("Database connection closed")
+		slog.Info("Database connection closed")
 	}
 }